@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	database "github.com/pkgzx/liliApi/src/internal/db"
 	"github.com/pkgzx/liliApi/src/internal/handlers"
@@ -17,6 +19,47 @@ func main() {
 	// Cargar configuración
 	cfg := config.Load()
 
+	if !services.IsValidTaxMode(cfg.Tax.PricingMode) {
+		log.Fatalf("Invalid TAX_PRICING_MODE %q (expected inclusive or exclusive)", cfg.Tax.PricingMode)
+	}
+
+	if !services.IsValidSyncPricePolicy(cfg.Sync.PricePolicy) {
+		log.Fatalf("Invalid SYNC_PRICE_POLICY %q (expected client or server)", cfg.Sync.PricePolicy)
+	}
+
+	if !services.IsValidNegativeStockPolicy(cfg.Inventory.NegativeStockPolicy) {
+		log.Fatalf("Invalid INVENTORY_NEGATIVE_STOCK %q (expected block or allow)", cfg.Inventory.NegativeStockPolicy)
+	}
+
+	if !repository.IsValidStockDeductionStatus(cfg.Inventory.DeductOnStatus) {
+		log.Fatalf("Invalid INVENTORY_DEDUCT_ON_STATUS %q (expected pending, preparing, ready, delivered or closed)", cfg.Inventory.DeductOnStatus)
+	}
+
+	if cfg.StockAlerts.IntervalSeconds <= 0 {
+		log.Fatalf("Invalid STOCK_ALERT_INTERVAL_SECONDS %d (expected a positive number of seconds)", cfg.StockAlerts.IntervalSeconds)
+	}
+
+	stockNotifier, err := newStockNotifier(cfg.StockAlerts)
+	if err != nil {
+		log.Fatalf("Invalid stock alert settings: %v", err)
+	}
+
+	if cfg.Scheduler.LeadMinutes < 0 || cfg.Scheduler.IntervalSeconds <= 0 {
+		log.Fatalf("Invalid order scheduler settings (lead %d minutes, interval %d seconds)", cfg.Scheduler.LeadMinutes, cfg.Scheduler.IntervalSeconds)
+	}
+
+	if cfg.Idempotency.TTLHours <= 0 {
+		log.Fatalf("Invalid IDEMPOTENCY_KEY_TTL_HOURS %d (expected a positive number of hours)", cfg.Idempotency.TTLHours)
+	}
+
+	if cfg.Feed.RetentionHours <= 0 {
+		log.Fatalf("Invalid ORDER_EVENTS_RETENTION_HOURS %d (expected a positive number of hours)", cfg.Feed.RetentionHours)
+	}
+
+	if cfg.Service.Rate < 0 || cfg.Service.Rate > 100 {
+		log.Fatalf("Invalid SERVICE_CHARGE_RATE %v (expected a percentage between 0 and 100)", cfg.Service.Rate)
+	}
+
 	// Conectar a la base de datos
 	db, err := database.NewConnection(&cfg.Database)
 	if err != nil {
@@ -24,22 +67,108 @@ func main() {
 	}
 	defer db.Close()
 
+	// Conexión LISTEN/NOTIFY para el feed de órdenes
+	listener, err := database.NewListener(&cfg.Database, repository.OrderEventsChannel)
+	if err != nil {
+		log.Fatalf("Failed to start database listener: %v", err)
+	}
+	defer listener.Close()
+
 	// Inicializar repositorios
 	userRepo := repository.NewUserRepository(db.DB)
+	productRepo := repository.NewProductRepository(db.DB)
+	orderRepo := repository.NewOrderRepository(db.DB, cfg.Inventory.DeductOnStatus)
+	tableRepo := repository.NewTableRepository(db.DB)
+	splitRepo := repository.NewSplitRepository(db.DB)
+	paymentRepo := repository.NewPaymentRepository(db.DB, cfg.Inventory.DeductOnStatus)
+	shiftRepo := repository.NewShiftRepository(db.DB)
+	discountRepo := repository.NewDiscountRepository(db.DB)
+	taxRepo := repository.NewTaxRepository(db.DB)
+	orderEventRepo := repository.NewOrderEventRepository(db.DB)
+	stationRepo := repository.NewStationRepository(db.DB, cfg.Inventory.DeductOnStatus)
+	idempotencyRepo := repository.NewIdempotencyRepository(db.DB)
+	syncRepo := repository.NewSyncRepository(db.DB, cfg.Inventory.DeductOnStatus)
+	ingredientRepo := repository.NewIngredientRepository(db.DB)
+	inventoryRepo := repository.NewInventoryRepository(db.DB)
+	stockAlertRepo := repository.NewStockAlertRepository(db.DB)
+	supplierRepo := repository.NewSupplierRepository(db.DB)
+	purchaseOrderRepo := repository.NewPurchaseOrderRepository(db.DB)
+	unitRepo := repository.NewUnitRepository(db.DB)
+	stockTakeRepo := repository.NewStockTakeRepository(db.DB)
+	lotRepo := repository.NewLotRepository(db.DB)
+	wasteRepo := repository.NewWasteRepository(db.DB)
+
+	// Catálogo de unidades de medida
+	if err := unitRepo.SeedDefaults(); err != nil {
+		log.Fatalf("Failed to seed measurement units: %v", err)
+	}
 
 	// Inicializar servicios
 	userService := services.NewUserService(userRepo)
-	authService := services.NewAuthService(userService, cfg.JWT.Secret)
+	authService := services.NewAuthService(userService, cfg.JWT.Secret, time.Duration(cfg.Feed.RetentionHours)*time.Hour)
+	orderService := services.NewOrderService(orderRepo, productRepo, discountRepo, stationRepo, services.OrderSettings{
+		TaxMode:                cfg.Tax.PricingMode,
+		ServiceChargeRate:      cfg.Service.Rate,
+		ServiceChargePartySize: cfg.Service.PartySize,
+	})
+	tableService := services.NewTableService(tableRepo, orderRepo, orderService)
+	splitService := services.NewSplitService(orderService, splitRepo)
+	shiftService := services.NewShiftService(shiftRepo)
+	paymentService := services.NewPaymentService(paymentRepo, splitRepo, orderService, shiftService)
+	discountService := services.NewDiscountService(discountRepo, orderService)
+	taxService := services.NewTaxService(taxRepo)
+	receiptService := services.NewReceiptService(orderService, paymentRepo, productRepo, tableRepo, cfg.Receipt)
+	stationService := services.NewStationService(stationRepo, orderRepo)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, time.Duration(cfg.Idempotency.TTLHours)*time.Hour)
+	syncService := services.NewSyncService(syncRepo, productRepo, orderService, cfg.Sync.PricePolicy)
+	unitService := services.NewUnitService(unitRepo)
+	ingredientService := services.NewIngredientService(ingredientRepo, productRepo, unitService)
+	inventoryService := services.NewInventoryService(inventoryRepo, ingredientService, cfg.Inventory.NegativeStockPolicy)
+	supplierService := services.NewSupplierService(supplierRepo)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, supplierService, ingredientService)
+	stockTakeService := services.NewStockTakeService(stockTakeRepo, ingredientService)
+	lotService := services.NewLotService(lotRepo, wasteRepo, ingredientService)
+	wasteService := services.NewWasteService(wasteRepo, productRepo, ingredientService)
+	stockAlertService := services.NewStockAlertService(stockAlertRepo, stockNotifier, time.Duration(cfg.StockAlerts.IntervalSeconds)*time.Second)
+	orderFeedService := services.NewOrderFeedService(orderEventRepo, listener, time.Duration(cfg.Feed.RetentionHours)*time.Hour)
+	orderSchedulerService := services.NewOrderSchedulerService(orderRepo, stationRepo,
+		time.Duration(cfg.Scheduler.LeadMinutes)*time.Minute, time.Duration(cfg.Scheduler.IntervalSeconds)*time.Second)
+	go orderFeedService.Run()
+	go idempotencyService.Run()
+	go orderSchedulerService.Run()
+	go stockAlertService.Run()
 
 	// Inicializar middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyService)
 
 	// Inicializar handlers
 	userHandler := handlers.NewUserHandler(userService, authService)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	tableHandler := handlers.NewTableHandler(tableService)
+	splitHandler := handlers.NewSplitHandler(splitService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+	discountHandler := handlers.NewDiscountHandler(discountService)
+	taxHandler := handlers.NewTaxHandler(taxService)
+	receiptHandler := handlers.NewReceiptHandler(receiptService)
+	orderFeedHandler := handlers.NewOrderFeedHandler(orderFeedService)
+	stationHandler := handlers.NewStationHandler(stationService)
+	syncHandler := handlers.NewSyncHandler(syncService)
+	ingredientHandler := handlers.NewIngredientHandler(ingredientService)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	stockAlertHandler := handlers.NewStockAlertHandler(stockAlertService)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	unitHandler := handlers.NewUnitHandler(unitService)
+	stockTakeHandler := handlers.NewStockTakeHandler(stockTakeService)
+	lotHandler := handlers.NewLotHandler(lotService)
+	wasteHandler := handlers.NewWasteHandler(wasteService)
 
 	// Configurar rutas
-	router := routes.NewRouter(authMiddleware)
-	mux := router.SetupRoutes(userHandler)
+	router := routes.NewRouter(authMiddleware, idempotencyMiddleware)
+	mux := router.SetupRoutes(userHandler, orderHandler, tableHandler, splitHandler, paymentHandler, shiftHandler, discountHandler, taxHandler, receiptHandler, orderFeedHandler, stationHandler, syncHandler, ingredientHandler, inventoryHandler, stockAlertHandler,
+		supplierHandler, purchaseOrderHandler, unitHandler, stockTakeHandler, lotHandler, wasteHandler)
 
 	// Servidor
 	server := &http.Server{
@@ -52,3 +181,22 @@ func main() {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// Notificador de alertas de stock bajo según la configuración
+func newStockNotifier(cfg config.StockAlertConfig) (services.StockAlertNotifier, error) {
+	switch cfg.Notifier {
+	case services.StockNotifierLog:
+		return services.LogStockNotifier{}, nil
+	case services.StockNotifierWebhook:
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("STOCK_ALERT_WEBHOOK_URL is required for the webhook notifier")
+		}
+		return services.NewWebhookStockNotifier(cfg.WebhookURL), nil
+	case services.StockNotifierEmail:
+		if cfg.EmailFrom == "" || len(cfg.EmailTo) == 0 {
+			return nil, fmt.Errorf("STOCK_ALERT_EMAIL_FROM and STOCK_ALERT_EMAIL_TO are required for the email notifier")
+		}
+		return services.NewEmailStockNotifier(cfg.SMTPAddr, cfg.EmailFrom, cfg.EmailTo), nil
+	}
+	return nil, fmt.Errorf("unknown STOCK_ALERT_NOTIFIER %q (expected log, webhook or email)", cfg.Notifier)
+}
@@ -4,8 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/pkgzx/liliApi/src/pkg/config"
 )
 
@@ -30,3 +31,22 @@ func NewConnection(cfg *config.DatabaseConfig) (*DB, error) {
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
+
+// Conexión dedicada a LISTEN/NOTIFY; se reconecta sola si se pierde
+func NewListener(cfg *config.DatabaseConfig, channels ...string) (*pq.Listener, error) {
+	listener := pq.NewListener(cfg.ConnectionString(), 10*time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("Database listener error: %v", err)
+			}
+		})
+
+	for _, channel := range channels {
+		if err := listener.Listen(channel); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("error listening on %s: %w", channel, err)
+		}
+	}
+
+	return listener, nil
+}
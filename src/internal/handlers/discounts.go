@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type DiscountHandler struct {
+	discountService *services.DiscountService
+}
+
+func NewDiscountHandler(discountService *services.DiscountService) *DiscountHandler {
+	return &DiscountHandler{
+		discountService: discountService,
+	}
+}
+
+type ApplyCouponRequest struct {
+	Code     string `json:"code"`
+	Customer string `json:"customer"`
+}
+
+// GET lista los descuentos predefinidos, POST crea uno
+func (h *DiscountHandler) HandlePresets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		presets, err := h.discountService.ListPresets()
+		if err != nil {
+			writeServiceError(w, "Failed to list discount presets", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Discount presets retrieved successfully", presets)
+
+	case http.MethodPost:
+		var preset data.DiscountPreset
+		if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.discountService.CreatePreset(&preset)
+		if err != nil {
+			writeServiceError(w, "Failed to create discount preset", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Discount preset created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *DiscountHandler) HandlePresetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid discount preset ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		preset, err := h.discountService.GetPreset(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get discount preset", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Discount preset retrieved successfully", preset)
+
+	case http.MethodPut:
+		var preset data.DiscountPreset
+		if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.discountService.UpdatePreset(id, &preset)
+		if err != nil {
+			writeServiceError(w, "Failed to update discount preset", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Discount preset updated successfully", updated)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET lista los cupones, POST crea uno
+func (h *DiscountHandler) HandleCoupons(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		coupons, err := h.discountService.ListCoupons()
+		if err != nil {
+			writeServiceError(w, "Failed to list coupons", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Coupons retrieved successfully", coupons)
+
+	case http.MethodPost:
+		var coupon data.Coupon
+		if err := json.NewDecoder(r.Body).Decode(&coupon); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.discountService.CreateCoupon(&coupon)
+		if err != nil {
+			writeServiceError(w, "Failed to create coupon", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Coupon created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *DiscountHandler) HandleCouponByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid coupon ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		coupon, err := h.discountService.GetCoupon(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get coupon", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Coupon retrieved successfully", coupon)
+
+	case http.MethodPut:
+		var coupon data.Coupon
+		if err := json.NewDecoder(r.Body).Decode(&coupon); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.discountService.UpdateCoupon(id, &coupon)
+		if err != nil {
+			writeServiceError(w, "Failed to update coupon", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Coupon updated successfully", updated)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET lista los descuentos de la orden, POST aplica uno (preset o manual)
+func (h *DiscountHandler) HandleOrderDiscounts(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		discounts, err := h.discountService.GetOrderDiscounts(orderID)
+		if err != nil {
+			writeServiceError(w, "Failed to get order discounts", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Order discounts retrieved successfully", discounts)
+
+	case http.MethodPost:
+		var req services.ApplyDiscountInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		detail, err := h.discountService.ApplyDiscount(orderID, req)
+		if err != nil {
+			writeServiceError(w, "Failed to apply discount", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Discount applied successfully", detail)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *DiscountHandler) HandleOrderDiscountByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	discountID, ok := pathID(r, "discountId")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid discount ID", "")
+		return
+	}
+
+	detail, err := h.discountService.RemoveDiscount(orderID, discountID)
+	if err != nil {
+		writeServiceError(w, "Failed to remove discount", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Discount removed successfully", detail)
+}
+
+// Canjea un código de cupón sobre la orden
+func (h *DiscountHandler) HandleApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	var req ApplyCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	detail, err := h.discountService.ApplyCoupon(orderID, req.Code, req.Customer)
+	if err != nil {
+		writeServiceError(w, "Failed to apply coupon", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Coupon applied successfully", detail)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type IngredientHandler struct {
+	ingredientService *services.IngredientService
+}
+
+func NewIngredientHandler(ingredientService *services.IngredientService) *IngredientHandler {
+	return &IngredientHandler{
+		ingredientService: ingredientService,
+	}
+}
+
+type RecipeRequest struct {
+	Items []data.RecipeItem `json:"items"`
+}
+
+type BundleComponentsRequest struct {
+	Components []data.BundleComponent `json:"components"`
+}
+
+type ProductModifiersRequest struct {
+	Modifiers []data.ProductModifier `json:"modifiers"`
+}
+
+// GET lista los ingredientes (?name=&low_stock=true), POST crea uno
+func (h *IngredientHandler) HandleIngredients(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		filter := repository.IngredientFilter{Name: r.URL.Query().Get("name")}
+		if value := r.URL.Query().Get("low_stock"); value != "" {
+			lowStock, err := strconv.ParseBool(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "Invalid low_stock value", err.Error())
+				return
+			}
+			filter.LowStock = lowStock
+		}
+
+		ingredients, err := h.ingredientService.ListIngredients(filter)
+		if err != nil {
+			writeServiceError(w, "Failed to list ingredients", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Ingredients retrieved successfully", ingredients)
+
+	case http.MethodPost:
+		var ingredient data.Ingredient
+		if err := json.NewDecoder(r.Body).Decode(&ingredient); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.ingredientService.CreateIngredient(&ingredient)
+		if err != nil {
+			writeServiceError(w, "Failed to create ingredient", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Ingredient created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *IngredientHandler) HandleIngredientByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid ingredient ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ingredient, err := h.ingredientService.GetIngredient(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get ingredient", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Ingredient retrieved successfully", ingredient)
+
+	case http.MethodPut:
+		var ingredient data.Ingredient
+		if err := json.NewDecoder(r.Body).Decode(&ingredient); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.ingredientService.UpdateIngredient(id, &ingredient)
+		if err != nil {
+			writeServiceError(w, "Failed to update ingredient", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Ingredient updated successfully", updated)
+
+	case http.MethodDelete:
+		if err := h.ingredientService.DeleteIngredient(id); err != nil {
+			writeServiceError(w, "Failed to delete ingredient", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Ingredient deleted successfully", nil)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET devuelve la receta de un producto, PUT la reemplaza completa
+func (h *IngredientHandler) HandleProductRecipe(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		recipe, err := h.ingredientService.GetRecipe(productID)
+		if err != nil {
+			writeServiceError(w, "Failed to get product recipe", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Product recipe retrieved successfully", recipe)
+
+	case http.MethodPut:
+		var req RecipeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		recipe, err := h.ingredientService.SetRecipe(productID, req.Items)
+		if err != nil {
+			writeServiceError(w, "Failed to update product recipe", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Product recipe updated successfully", recipe)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET devuelve los productos que incluye un combo, PUT los reemplaza
+func (h *IngredientHandler) HandleBundleComponents(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		components, err := h.ingredientService.GetBundleComponents(productID)
+		if err != nil {
+			writeServiceError(w, "Failed to get bundle components", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Bundle components retrieved successfully", components)
+
+	case http.MethodPut:
+		var req BundleComponentsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		components, err := h.ingredientService.SetBundleComponents(productID, req.Components)
+		if err != nil {
+			writeServiceError(w, "Failed to update bundle components", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Bundle components updated successfully", components)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET devuelve los modificadores de un producto, PUT los reemplaza
+func (h *IngredientHandler) HandleProductModifiers(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		modifiers, err := h.ingredientService.GetModifiers(productID)
+		if err != nil {
+			writeServiceError(w, "Failed to get product modifiers", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Product modifiers retrieved successfully", modifiers)
+
+	case http.MethodPut:
+		var req ProductModifiersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		modifiers, err := h.ingredientService.SetModifiers(productID, req.Modifiers)
+		if err != nil {
+			writeServiceError(w, "Failed to update product modifiers", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Product modifiers updated successfully", modifiers)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET lista las presentaciones de compra del ingrediente, POST agrega una
+func (h *IngredientHandler) HandleIngredientPacks(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid ingredient ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		packs, err := h.ingredientService.GetPacks(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get pack units", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Pack units retrieved successfully", packs)
+
+	case http.MethodPost:
+		var pack data.PackUnit
+		if err := json.NewDecoder(r.Body).Decode(&pack); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.ingredientService.CreatePack(id, &pack)
+		if err != nil {
+			writeServiceError(w, "Failed to create pack unit", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Pack unit created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *IngredientHandler) HandleIngredientPackByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid ingredient ID", "")
+		return
+	}
+
+	packID, ok := pathID(r, "packId")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid pack unit ID", "")
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	if err := h.ingredientService.DeletePack(id, packID); err != nil {
+		writeServiceError(w, "Failed to delete pack unit", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Pack unit deleted successfully", nil)
+}
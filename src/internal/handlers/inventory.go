@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+type InventoryHandler struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryHandler(inventoryService *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// GET historial de movimientos del ingrediente (?type=&limit=&offset=), POST registra uno
+func (h *InventoryHandler) HandleIngredientMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid ingredient ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit, err := queryInt(r, "limit")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid limit", err.Error())
+			return
+		}
+
+		offset, err := queryInt(r, "offset")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid offset", err.Error())
+			return
+		}
+
+		page, err := h.inventoryService.ListMovements(id, r.URL.Query().Get("type"), limit, offset)
+		if err != nil {
+			writeServiceError(w, "Failed to list inventory movements", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Inventory movements retrieved successfully", page)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req services.MovementInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		movement, err := h.inventoryService.RecordMovement(id, userClaims.UserID, req)
+		if err != nil {
+			writeServiceError(w, "Failed to register inventory movement", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Inventory movement registered successfully", movement)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type LotHandler struct {
+	lotService *services.LotService
+}
+
+func NewLotHandler(lotService *services.LotService) *LotHandler {
+	return &LotHandler{
+		lotService: lotService,
+	}
+}
+
+type WriteOffExpiredRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GET lista los lotes con existencias en orden de consumo
+// (?ingredient_id=&expiring_within_days=&include_empty=true)
+func (h *LotHandler) HandleLots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	ingredientID, err := queryInt(r, "ingredient_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid ingredient_id", err.Error())
+		return
+	}
+
+	filter := repository.LotFilter{
+		IngredientID: int32(ingredientID),
+		IncludeEmpty: r.URL.Query().Get("include_empty") == "true",
+	}
+
+	if r.URL.Query().Get("expiring_within_days") != "" {
+		days, err := queryInt(r, "expiring_within_days")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid expiring_within_days", err.Error())
+			return
+		}
+		filter.ExpiringWithinDays = &days
+	}
+
+	lots, err := h.lotService.ListLots(filter)
+	if err != nil {
+		writeServiceError(w, "Failed to list lots", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Lots retrieved successfully", lots)
+}
+
+func (h *LotHandler) HandleLotByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid lot ID", "")
+		return
+	}
+
+	lot, err := h.lotService.GetLot(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get lot", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Lot retrieved successfully", lot)
+}
+
+func (h *LotHandler) HandleWriteOffLot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid lot ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var req services.WriteOffInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	record, err := h.lotService.WriteOff(id, userClaims.UserID, req)
+	if err != nil {
+		writeServiceError(w, "Failed to write off lot", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Lot written off successfully", record)
+}
+
+// POST da de baja todos los lotes vencidos
+func (h *LotHandler) HandleWriteOffExpired(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var req WriteOffExpiredRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	records, err := h.lotService.WriteOffExpired(userClaims.UserID, req.Reason)
+	if err != nil {
+		writeServiceError(w, "Failed to write off expired lots", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Expired lots written off successfully", records)
+}
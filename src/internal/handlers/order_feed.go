@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Intervalo de comentarios keep-alive para que proxies no corten la conexión
+const feedHeartbeatInterval = 20 * time.Second
+
+// Espera antes de releer cuando hay eventos retenidos por una transacción abierta
+const feedPendingRetry = time.Second
+
+type OrderFeedHandler struct {
+	feedService *services.OrderFeedService
+}
+
+func NewOrderFeedHandler(feedService *services.OrderFeedService) *OrderFeedHandler {
+	return &OrderFeedHandler{
+		feedService: feedService,
+	}
+}
+
+// Stream SSE de eventos de órdenes. El id de cada evento es un cursor
+// "<tx_id>-<id>"; al reconectar, el navegador envía Last-Event-ID y se
+// reproducen los eventos posteriores.
+func (h *OrderFeedHandler) HandleOrderFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "Streaming not supported", "")
+		return
+	}
+
+	// Último evento recibido por el cliente; sin él, el stream arranca con los eventos nuevos
+	resumeFrom := r.Header.Get("Last-Event-ID")
+	if resumeFrom == "" {
+		resumeFrom = r.URL.Query().Get("last_event_id")
+	}
+
+	var cursor repository.OrderEventCursor
+	var err error
+	if resumeFrom != "" {
+		cursor, err = repository.ParseOrderEventCursor(resumeFrom)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid Last-Event-ID", err.Error())
+			return
+		}
+	} else if cursor, err = h.feedService.CurrentCursor(); err != nil {
+		writeServiceError(w, "Failed to open order feed", err)
+		return
+	}
+
+	// Suscribirse antes de reproducir para no perder eventos entre ambos pasos
+	wake, unsubscribe := h.feedService.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(feedHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var pending bool
+		if cursor, pending, err = h.sendEventsSince(w, cursor); err != nil {
+			log.Printf("Order feed stream error: %v", err)
+			return
+		}
+		flusher.Flush()
+
+		// La transacción que retiene eventos puede terminar sin notificar nada
+		var retry <-chan time.Time
+		if pending {
+			retry = time.After(feedPendingRetry)
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wake:
+		case <-retry:
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+	}
+}
+
+// Escribe todos los eventos posteriores al cursor y devuelve el cursor del
+// último enviado, y si quedaron eventos retenidos por leer
+func (h *OrderFeedHandler) sendEventsSince(w http.ResponseWriter, cursor repository.OrderEventCursor) (repository.OrderEventCursor, bool, error) {
+	for {
+		events, pending, err := h.feedService.EventsSince(cursor)
+		if err != nil {
+			return cursor, false, err
+		}
+
+		for _, event := range events {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return cursor, false, err
+			}
+
+			next := repository.OrderEventCursor{TxID: event.TxID, ID: event.ID}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", next, event.Type, payload); err != nil {
+				return cursor, false, err
+			}
+			cursor = next
+		}
+
+		if len(events) < h.feedService.BatchSize() {
+			return cursor, pending, nil
+		}
+	}
+}
@@ -1 +1,252 @@
-package handlers
\ No newline at end of file
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type OrderHandler struct {
+	orderService *services.OrderService
+}
+
+func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+	}
+}
+
+type AddItemsRequest struct {
+	Items []services.OrderItemInput `json:"items"`
+}
+
+type UpdateStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// Course vacío (o cuerpo vacío) marcha el siguiente tiempo retenido
+type FireCourseRequest struct {
+	Course int32 `json:"course"`
+}
+
+// GET lista las órdenes (filtros opcionales ?status=&type=&from=&to=), POST crea una orden
+func (h *OrderHandler) HandleOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		filter, err := parseOrderFilter(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+			return
+		}
+
+		orders, err := h.orderService.ListOrders(filter)
+		if err != nil {
+			writeServiceError(w, "Failed to list orders", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Orders retrieved successfully", orders)
+
+	case http.MethodPost:
+		var req services.CreateOrderInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		if len(req.Items) == 0 {
+			writeError(w, http.StatusBadRequest, "At least one item is required", "")
+			return
+		}
+
+		order, err := h.orderService.CreateOrder(req)
+		if err != nil {
+			writeServiceError(w, "Failed to create order", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Order created successfully", order)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *OrderHandler) HandleOrderByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	order, err := h.orderService.GetOrder(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get order", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Order retrieved successfully", order)
+}
+
+// Agrega items a una orden abierta
+func (h *OrderHandler) HandleOrderItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	var req AddItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.orderService.AddItems(id, req.Items)
+	if err != nil {
+		writeServiceError(w, "Failed to add items", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Items added successfully", order)
+}
+
+func (h *OrderHandler) HandleOrderStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if req.Status == "" {
+		writeError(w, http.StatusBadRequest, "Status is required", "")
+		return
+	}
+
+	if err := h.orderService.UpdateStatus(id, req.Status); err != nil {
+		writeServiceError(w, "Failed to update order status", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Order status updated successfully", nil)
+}
+
+// Marcha el siguiente tiempo de una orden en mesa: sus items pasan a las comandas de cocina
+func (h *OrderHandler) HandleFireCourse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	var req FireCourseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.orderService.FireCourse(id, req.Course)
+	if err != nil {
+		writeServiceError(w, "Failed to fire course", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Course fired successfully", order)
+}
+
+// Pre-órdenes programadas aún no liberadas a cocina (?from=&to= por hora programada, ?type=)
+func (h *OrderHandler) HandleScheduledOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	orders, err := h.orderService.ListScheduled(filter)
+	if err != nil {
+		writeServiceError(w, "Failed to list scheduled orders", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Scheduled orders retrieved successfully", orders)
+}
+
+// Reporte de ventas por tipo de orden (?type=&from=&to=)
+func (h *OrderHandler) HandleSalesReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	sales, err := h.orderService.GetSalesByType(filter)
+	if err != nil {
+		writeServiceError(w, "Failed to get sales report", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Sales report retrieved successfully", sales)
+}
+
+func parseOrderFilter(r *http.Request) (repository.OrderFilter, error) {
+	query := r.URL.Query()
+	filter := repository.OrderFilter{
+		Status:    query.Get("status"),
+		OrderType: query.Get("type"),
+	}
+
+	from, err := queryDate(r, "from")
+	if err != nil {
+		return filter, err
+	}
+	to, err := queryDate(r, "to")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.From = from
+	if to != nil {
+		// Incluir el día completo de la fecha final
+		end := to.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+
+	return filter, nil
+}
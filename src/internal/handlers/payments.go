@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+type RegisterPaymentRequest struct {
+	SplitID    *int32                 `json:"split_id,omitempty"`
+	Tenders    []services.TenderInput `json:"tenders"`
+	TipStaffID *int32                 `json:"tip_staff_id,omitempty"`
+}
+
+type ReversePaymentRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GET lista los pagos de la orden, POST registra un cobro
+func (h *PaymentHandler) HandleOrderPayments(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		payments, err := h.paymentService.GetPayments(orderID)
+		if err != nil {
+			writeServiceError(w, "Failed to get payments", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Payments retrieved successfully", payments)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req RegisterPaymentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		receipt, err := h.paymentService.RegisterPayments(orderID, req.SplitID, req.Tenders, req.TipStaffID, userClaims.UserID)
+		if err != nil {
+			writeServiceError(w, "Failed to register payment", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Payment registered successfully", receipt)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *PaymentHandler) HandleReversePayment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	paymentID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid payment ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var req ReversePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	result, err := h.paymentService.ReversePayment(paymentID, req.Reason, userClaims.UserID)
+	if err != nil {
+		writeServiceError(w, "Failed to reverse payment", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Payment reversed successfully", result)
+}
+
+// Propinas por empleado del periodo (?from=&to=) para su reparto
+func (h *PaymentHandler) HandleTipsReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	tips, err := h.paymentService.GetTipsByStaff(filter.From, filter.To)
+	if err != nil {
+		writeServiceError(w, "Failed to get tips report", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Tips report retrieved successfully", tips)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseService *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(purchaseService *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseService: purchaseService,
+	}
+}
+
+// GET lista las órdenes de compra (?status=&supplier_id=), POST crea un borrador
+func (h *PurchaseOrderHandler) HandlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		supplierID, err := queryInt(r, "supplier_id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid supplier_id", err.Error())
+			return
+		}
+
+		orders, err := h.purchaseService.ListPurchaseOrders(repository.PurchaseOrderFilter{
+			Status:     r.URL.Query().Get("status"),
+			SupplierID: int32(supplierID),
+		})
+		if err != nil {
+			writeServiceError(w, "Failed to list purchase orders", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Purchase orders retrieved successfully", orders)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req services.PurchaseOrderInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		order, err := h.purchaseService.CreatePurchaseOrder(req, userClaims.UserID)
+		if err != nil {
+			writeServiceError(w, "Failed to create purchase order", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Purchase order created successfully", order)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET detalle con items y diferencias, PUT edita un borrador
+func (h *PurchaseOrderHandler) HandlePurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid purchase order ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		order, err := h.purchaseService.GetPurchaseOrder(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get purchase order", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Purchase order retrieved successfully", order)
+
+	case http.MethodPut:
+		var req services.PurchaseOrderInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		order, err := h.purchaseService.UpdatePurchaseOrder(id, req)
+		if err != nil {
+			writeServiceError(w, "Failed to update purchase order", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Purchase order updated successfully", order)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *PurchaseOrderHandler) HandleSendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid purchase order ID", "")
+		return
+	}
+
+	order, err := h.purchaseService.SendPurchaseOrder(id)
+	if err != nil {
+		writeServiceError(w, "Failed to send purchase order", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Purchase order sent successfully", order)
+}
+
+func (h *PurchaseOrderHandler) HandleCancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid purchase order ID", "")
+		return
+	}
+
+	order, err := h.purchaseService.CancelPurchaseOrder(id)
+	if err != nil {
+		writeServiceError(w, "Failed to cancel purchase order", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Purchase order cancelled successfully", order)
+}
+
+// Registra mercancía recibida; puede llamarse varias veces hasta completar la orden
+func (h *PurchaseOrderHandler) HandleReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid purchase order ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var req services.ReceivePurchaseInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.purchaseService.ReceivePurchaseOrder(id, userClaims.UserID, req)
+	if err != nil {
+		writeServiceError(w, "Failed to receive purchase order", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Purchase order received successfully", order)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+type ReceiptHandler struct {
+	receiptService *services.ReceiptService
+}
+
+func NewReceiptHandler(receiptService *services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{
+		receiptService: receiptService,
+	}
+}
+
+// Recibo de la orden (?format=text|html|escpos&width=42|48)
+func (h *ReceiptHandler) HandleOrderReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	width := 0
+	if value := r.URL.Query().Get("width"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid receipt width", err.Error())
+			return
+		}
+		width = parsed
+	}
+
+	receipt, err := h.receiptService.RenderReceipt(orderID, r.URL.Query().Get("format"), width)
+	if err != nil {
+		writeServiceError(w, "Failed to render receipt", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", receipt.ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(receipt.Body)
+}
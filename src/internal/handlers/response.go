@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+// Respuesta genérica para los handlers de recursos
+type DataResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, message string, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(DataResponse{
+		Success: true,
+		Message: message,
+		Data:    payload,
+	})
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message, errorDetail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Success: false,
+		Message: message,
+		Error:   errorDetail,
+	})
+}
+
+// Traduce los errores de los servicios a códigos HTTP
+func writeServiceError(w http.ResponseWriter, message string, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		writeError(w, http.StatusForbidden, message, msg)
+	case strings.Contains(msg, "not found"):
+		writeError(w, http.StatusNotFound, message, msg)
+	case strings.Contains(msg, "already exists"):
+		writeError(w, http.StatusConflict, message, msg)
+	case strings.HasPrefix(msg, "error "):
+		writeError(w, http.StatusInternalServerError, message, msg)
+	default:
+		writeError(w, http.StatusBadRequest, message, msg)
+	}
+}
+
+// Obtiene un ID numérico de la ruta (ej. /api/orders/{id})
+func pathID(r *http.Request, name string) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// Lee una fecha (YYYY-MM-DD) de los parámetros de la consulta
+func queryDate(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%s must use the format YYYY-MM-DD", name)
+	}
+
+	return &date, nil
+}
+
+// Lee un entero opcional de los parámetros de la consulta; 0 si no viene
+func queryInt(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+
+	return number, nil
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+type ShiftHandler struct {
+	shiftService *services.ShiftService
+}
+
+func NewShiftHandler(shiftService *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{
+		shiftService: shiftService,
+	}
+}
+
+type OpenShiftRequest struct {
+	Register     string  `json:"register"`
+	OpeningFloat float64 `json:"opening_float"`
+	Notes        string  `json:"notes"`
+}
+
+type CashMovementRequest struct {
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash *float64 `json:"counted_cash"`
+	Notes       string   `json:"notes"`
+}
+
+// GET lista los turnos (?status=), POST abre un turno para el usuario actual
+func (h *ShiftHandler) HandleShifts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		shifts, err := h.shiftService.ListShifts(r.URL.Query().Get("status"))
+		if err != nil {
+			writeServiceError(w, "Failed to list cash shifts", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Cash shifts retrieved successfully", shifts)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req OpenShiftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		shift, err := h.shiftService.OpenShift(userClaims.UserID, req.Register, req.OpeningFloat, req.Notes)
+		if err != nil {
+			writeServiceError(w, "Failed to open cash shift", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Cash shift opened successfully", shift)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Turno abierto del usuario autenticado
+func (h *ShiftHandler) HandleCurrentShift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	shift, err := h.shiftService.GetCurrentShift(userClaims.UserID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "No open cash shift", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Cash shift retrieved successfully", shift)
+}
+
+func (h *ShiftHandler) HandleShiftByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid shift ID", "")
+		return
+	}
+
+	shift, err := h.shiftService.GetShift(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get cash shift", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Cash shift retrieved successfully", shift)
+}
+
+// GET lista las entradas/salidas de efectivo, POST registra una
+func (h *ShiftHandler) HandleCashMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid shift ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		movements, err := h.shiftService.GetMovements(id)
+		if err != nil {
+			writeServiceError(w, "Failed to list cash movements", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Cash movements retrieved successfully", movements)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req CashMovementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		movement, err := h.shiftService.AddMovement(id, userClaims.UserID, userClaims.Role, req.Type, req.Amount, req.Reason)
+		if err != nil {
+			writeServiceError(w, "Failed to register cash movement", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Cash movement registered successfully", movement)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Cierra el turno con el efectivo contado y devuelve el reporte Z
+func (h *ShiftHandler) HandleCloseShift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid shift ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var req CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if req.CountedCash == nil {
+		writeError(w, http.StatusBadRequest, "Counted cash is required", "")
+		return
+	}
+
+	report, err := h.shiftService.CloseShift(id, userClaims.UserID, userClaims.Role, *req.CountedCash, req.Notes)
+	if err != nil {
+		writeServiceError(w, "Failed to close cash shift", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Cash shift closed successfully", report)
+}
+
+func (h *ShiftHandler) HandleShiftReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid shift ID", "")
+		return
+	}
+
+	report, err := h.shiftService.GetReport(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get shift report", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Shift report retrieved successfully", report)
+}
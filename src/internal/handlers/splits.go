@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type SplitHandler struct {
+	splitService *services.SplitService
+}
+
+func NewSplitHandler(splitService *services.SplitService) *SplitHandler {
+	return &SplitHandler{
+		splitService: splitService,
+	}
+}
+
+// Modo "items" usa checks, modo "equal" usa parts
+type SplitOrderRequest struct {
+	Mode   string                     `json:"mode"`
+	Checks []services.SplitCheckInput `json:"checks,omitempty"`
+	Parts  int                        `json:"parts,omitempty"`
+}
+
+// GET lista las subcuentas, POST divide la orden, DELETE anula la división
+func (h *SplitHandler) HandleOrderSplits(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		splits, err := h.splitService.GetSplits(orderID)
+		if err != nil {
+			writeServiceError(w, "Failed to get splits", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Splits retrieved successfully", splits)
+
+	case http.MethodPost:
+		var req SplitOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		var (
+			splits []data.OrderSplit
+			err    error
+		)
+		switch req.Mode {
+		case "items":
+			splits, err = h.splitService.SplitByItems(orderID, req.Checks)
+		case "equal":
+			splits, err = h.splitService.SplitEqually(orderID, req.Parts)
+		default:
+			writeError(w, http.StatusBadRequest, "Mode must be 'items' or 'equal'", "")
+			return
+		}
+
+		if err != nil {
+			writeServiceError(w, "Failed to split order", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Order split successfully", splits)
+
+	case http.MethodDelete:
+		if err := h.splitService.CancelSplits(orderID); err != nil {
+			writeServiceError(w, "Failed to cancel splits", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Splits cancelled successfully", nil)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
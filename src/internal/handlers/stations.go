@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+// Valor de {stationId} para la comanda de items sin estación asignada
+const unassignedStation = "unassigned"
+
+type StationHandler struct {
+	stationService *services.StationService
+}
+
+func NewStationHandler(stationService *services.StationService) *StationHandler {
+	return &StationHandler{
+		stationService: stationService,
+	}
+}
+
+type AssignStationRequest struct {
+	StationID *int32 `json:"station_id"`
+}
+
+type PrepTimeRequest struct {
+	PrepMinutes int32 `json:"prep_minutes"`
+}
+
+type UpdateKitchenStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type TicketStatusResponse struct {
+	Status       string `json:"status"`
+	ItemsUpdated int64  `json:"items_updated"`
+}
+
+// GET lista las estaciones de cocina, POST crea una
+func (h *StationHandler) HandleStations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		stations, err := h.stationService.ListStations()
+		if err != nil {
+			writeServiceError(w, "Failed to list kitchen stations", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Kitchen stations retrieved successfully", stations)
+
+	case http.MethodPost:
+		var station data.KitchenStation
+		if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.stationService.CreateStation(&station)
+		if err != nil {
+			writeServiceError(w, "Failed to create kitchen station", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Kitchen station created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *StationHandler) HandleStationByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid kitchen station ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		station, err := h.stationService.GetStation(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get kitchen station", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Kitchen station retrieved successfully", station)
+
+	case http.MethodPut:
+		var station data.KitchenStation
+		if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.stationService.UpdateStation(id, &station)
+		if err != nil {
+			writeServiceError(w, "Failed to update kitchen station", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Kitchen station updated successfully", updated)
+
+	case http.MethodDelete:
+		if err := h.stationService.DeleteStation(id); err != nil {
+			writeServiceError(w, "Failed to delete kitchen station", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Kitchen station deleted successfully", nil)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Comandas abiertas de la estación (?include_ready=true agrega las ya listas)
+func (h *StationHandler) HandleStationTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid kitchen station ID", "")
+		return
+	}
+
+	includeReady := false
+	if value := r.URL.Query().Get("include_ready"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid include_ready value", err.Error())
+			return
+		}
+		includeReady = parsed
+	}
+
+	tickets, err := h.stationService.GetStationTickets(id, includeReady)
+	if err != nil {
+		writeServiceError(w, "Failed to get station tickets", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Station tickets retrieved successfully", tickets)
+}
+
+// Asigna la estación de un producto (null para dejarlo sin estación)
+func (h *StationHandler) HandleProductStation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", "")
+		return
+	}
+
+	var req AssignStationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.stationService.AssignToProduct(id, req.StationID); err != nil {
+		writeServiceError(w, "Failed to assign product station", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Product station updated successfully", req)
+}
+
+// Tiempo estimado de preparación de un producto, usado para la hora estimada de las órdenes
+func (h *StationHandler) HandleProductPrepTime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", "")
+		return
+	}
+
+	var req PrepTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.stationService.SetPrepTime(id, req.PrepMinutes); err != nil {
+		writeServiceError(w, "Failed to update product prep time", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Product prep time updated successfully", req)
+}
+
+// Comandas de una orden, una por estación
+func (h *StationHandler) HandleOrderTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	tickets, err := h.stationService.GetOrderTickets(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get order tickets", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Order tickets retrieved successfully", tickets)
+}
+
+// Cambia el estado de preparación de un item
+func (h *StationHandler) HandleItemStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	itemID, ok := pathID(r, "itemId")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order item ID", "")
+		return
+	}
+
+	var req UpdateKitchenStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.stationService.UpdateItemStatus(orderID, itemID, req.Status); err != nil {
+		writeServiceError(w, "Failed to update item status", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Item status updated successfully", req)
+}
+
+// Avanza toda la comanda de una estación en la orden ({stationId} = "unassigned"
+// para los items sin estación)
+func (h *StationHandler) HandleTicketStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	var stationID *int32
+	if r.PathValue("stationId") != unassignedStation {
+		id, ok := pathID(r, "stationId")
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Invalid kitchen station ID", "")
+			return
+		}
+		stationID = &id
+	}
+
+	var req UpdateKitchenStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updated, err := h.stationService.UpdateTicketStatus(orderID, stationID, req.Status)
+	if err != nil {
+		writeServiceError(w, "Failed to update ticket status", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Ticket status updated successfully", TicketStatusResponse{
+		Status:       req.Status,
+		ItemsUpdated: updated,
+	})
+}
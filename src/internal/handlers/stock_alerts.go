@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type StockAlertHandler struct {
+	alertService *services.StockAlertService
+}
+
+func NewStockAlertHandler(alertService *services.StockAlertService) *StockAlertHandler {
+	return &StockAlertHandler{
+		alertService: alertService,
+	}
+}
+
+// Alertas de stock bajo (?status=open|acknowledged|resolved; sin él, las activas)
+func (h *StockAlertHandler) HandleStockAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	alerts, err := h.alertService.ListAlerts(r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, "Failed to list stock alerts", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Stock alerts retrieved successfully", alerts)
+}
+
+func (h *StockAlertHandler) HandleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	h.handleTransition(w, r, "acknowledge")
+}
+
+func (h *StockAlertHandler) HandleResolveAlert(w http.ResponseWriter, r *http.Request) {
+	h.handleTransition(w, r, "resolve")
+}
+
+func (h *StockAlertHandler) handleTransition(w http.ResponseWriter, r *http.Request, action string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid stock alert ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var alert *data.StockAlert
+	var err error
+	if action == "acknowledge" {
+		alert, err = h.alertService.Acknowledge(id, userClaims.UserID)
+	} else {
+		alert, err = h.alertService.Resolve(id, userClaims.UserID)
+	}
+	if err != nil {
+		writeServiceError(w, "Failed to "+action+" stock alert", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Stock alert updated successfully", alert)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+type StockTakeHandler struct {
+	stockTakeService *services.StockTakeService
+}
+
+func NewStockTakeHandler(stockTakeService *services.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{
+		stockTakeService: stockTakeService,
+	}
+}
+
+type StockCountsRequest struct {
+	Counts []services.StockCountInput `json:"counts"`
+}
+
+// GET lista los conteos físicos (?status=), POST abre uno nuevo
+func (h *StockTakeHandler) HandleStockTakes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		takes, err := h.stockTakeService.ListStockTakes(r.URL.Query().Get("status"))
+		if err != nil {
+			writeServiceError(w, "Failed to list stock takes", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Stock takes retrieved successfully", takes)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req services.StockTakeInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		take, err := h.stockTakeService.OpenStockTake(req, userClaims.UserID)
+		if err != nil {
+			writeServiceError(w, "Failed to open stock take", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Stock take opened successfully", take)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET devuelve el conteo con sus líneas y diferencias
+func (h *StockTakeHandler) HandleStockTakeByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid stock take ID", "")
+		return
+	}
+
+	take, err := h.stockTakeService.GetStockTake(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get stock take", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Stock take retrieved successfully", take)
+}
+
+// PUT registra cantidades contadas; se puede repetir para recontar
+func (h *StockTakeHandler) HandleStockTakeCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid stock take ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	var req StockCountsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	take, err := h.stockTakeService.RecordCounts(id, userClaims.UserID, req.Counts)
+	if err != nil {
+		writeServiceError(w, "Failed to record stock counts", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Stock counts recorded successfully", take)
+}
+
+func (h *StockTakeHandler) HandleApproveStockTake(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid stock take ID", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	take, err := h.stockTakeService.ApproveStockTake(id, userClaims.UserID)
+	if err != nil {
+		writeServiceError(w, "Failed to approve stock take", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Stock take approved successfully", take)
+}
+
+func (h *StockTakeHandler) HandleCancelStockTake(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid stock take ID", "")
+		return
+	}
+
+	take, err := h.stockTakeService.CancelStockTake(id)
+	if err != nil {
+		writeServiceError(w, "Failed to cancel stock take", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Stock take cancelled successfully", take)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type SupplierHandler struct {
+	supplierService *services.SupplierService
+}
+
+func NewSupplierHandler(supplierService *services.SupplierService) *SupplierHandler {
+	return &SupplierHandler{
+		supplierService: supplierService,
+	}
+}
+
+// GET lista los proveedores, POST crea uno
+func (h *SupplierHandler) HandleSuppliers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		suppliers, err := h.supplierService.ListSuppliers()
+		if err != nil {
+			writeServiceError(w, "Failed to list suppliers", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Suppliers retrieved successfully", suppliers)
+
+	case http.MethodPost:
+		var supplier data.Supplier
+		if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.supplierService.CreateSupplier(&supplier)
+		if err != nil {
+			writeServiceError(w, "Failed to create supplier", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Supplier created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *SupplierHandler) HandleSupplierByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid supplier ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		supplier, err := h.supplierService.GetSupplier(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get supplier", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Supplier retrieved successfully", supplier)
+
+	case http.MethodPut:
+		var supplier data.Supplier
+		if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.supplierService.UpdateSupplier(id, &supplier)
+		if err != nil {
+			writeServiceError(w, "Failed to update supplier", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Supplier updated successfully", updated)
+
+	case http.MethodDelete:
+		if err := h.supplierService.DeleteSupplier(id); err != nil {
+			writeServiceError(w, "Failed to delete supplier", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Supplier deleted successfully", nil)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
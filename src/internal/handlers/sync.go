@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+)
+
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// Recibe el lote de órdenes tomadas sin conexión. Responde 200 aunque alguna
+// orden se rechace: el resultado de cada una viene en orders[].status.
+func (h *SyncHandler) HandleSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	var req services.SyncBatchInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	result, err := h.syncService.SyncBatch(req)
+	if err != nil {
+		writeServiceError(w, "Failed to sync orders", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Sync completed", result)
+}
+
+// Cambios del catálogo desde ?since=<sync_token> (sin él, el catálogo completo)
+func (h *SyncHandler) HandleCatalogChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	catalog, err := h.syncService.GetCatalogChanges(r.URL.Query().Get("since"))
+	if err != nil {
+		writeServiceError(w, "Failed to get catalog changes", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Catalog changes retrieved successfully", catalog)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type TableHandler struct {
+	tableService *services.TableService
+}
+
+func NewTableHandler(tableService *services.TableService) *TableHandler {
+	return &TableHandler{
+		tableService: tableService,
+	}
+}
+
+type TableRequest struct {
+	Number   int32  `json:"number"`
+	Zone     string `json:"zone"`
+	Capacity int32  `json:"capacity"`
+	Status   string `json:"status"`
+}
+
+type MoveOrderRequest struct {
+	TableID int32 `json:"table_id"`
+}
+
+type MergeTablesRequest struct {
+	SourceTableID int32 `json:"source_table_id"`
+}
+
+// GET lista las mesas, POST crea una mesa
+func (h *TableHandler) HandleTables(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tables, err := h.tableService.ListTables()
+		if err != nil {
+			writeServiceError(w, "Failed to list tables", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Tables retrieved successfully", tables)
+
+	case http.MethodPost:
+		var req TableRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		table, err := h.tableService.CreateTable(req.Number, req.Zone, req.Capacity)
+		if err != nil {
+			writeServiceError(w, "Failed to create table", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Table created successfully", table)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *TableHandler) HandleTableByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid table ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		table, err := h.tableService.GetTable(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get table", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Table retrieved successfully", table)
+
+	case http.MethodPut:
+		var req TableRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		table := &data.Table{
+			ID:       id,
+			Number:   req.Number,
+			Zone:     req.Zone,
+			Capacity: req.Capacity,
+			Status:   req.Status,
+		}
+
+		if err := h.tableService.UpdateTable(table); err != nil {
+			writeServiceError(w, "Failed to update table", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Table updated successfully", table)
+
+	case http.MethodDelete:
+		if err := h.tableService.DeleteTable(id); err != nil {
+			writeServiceError(w, "Failed to delete table", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Table deleted successfully", nil)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET lista las cuentas abiertas de la mesa, POST abre una nueva cuenta
+func (h *TableHandler) HandleTableOrders(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid table ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		orders, err := h.tableService.GetOpenOrders(id)
+		if err != nil {
+			writeServiceError(w, "Failed to list table orders", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Table orders retrieved successfully", orders)
+
+	case http.MethodPost:
+		var req services.CreateOrderInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		order, err := h.tableService.OpenOrder(id, req.Notes, req.PartySize, req.Items)
+		if err != nil {
+			writeServiceError(w, "Failed to open order", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Order opened successfully", order)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Une las cuentas de otra mesa en esta mesa
+func (h *TableHandler) HandleMergeTables(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid table ID", "")
+		return
+	}
+
+	var req MergeTablesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.tableService.MergeTables(req.SourceTableID, id)
+	if err != nil {
+		writeServiceError(w, "Failed to merge tables", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Tables merged successfully", order)
+}
+
+// Mueve una orden a otra mesa
+func (h *TableHandler) HandleMoveOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	orderID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", "")
+		return
+	}
+
+	var req MoveOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.tableService.MoveOrder(orderID, req.TableID); err != nil {
+		writeServiceError(w, "Failed to move order", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Order moved successfully", nil)
+}
+
+func (h *TableHandler) HandleFloorPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	plan, err := h.tableService.GetFloorPlan()
+	if err != nil {
+		writeServiceError(w, "Failed to get floor plan", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Floor plan retrieved successfully", plan)
+}
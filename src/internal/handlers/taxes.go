@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type TaxHandler struct {
+	taxService *services.TaxService
+}
+
+func NewTaxHandler(taxService *services.TaxService) *TaxHandler {
+	return &TaxHandler{
+		taxService: taxService,
+	}
+}
+
+type AssignTaxRateRequest struct {
+	TaxRateID *int32 `json:"tax_rate_id"`
+}
+
+// GET lista las tarifas de impuesto, POST crea una
+func (h *TaxHandler) HandleTaxRates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rates, err := h.taxService.ListTaxRates()
+		if err != nil {
+			writeServiceError(w, "Failed to list tax rates", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Tax rates retrieved successfully", rates)
+
+	case http.MethodPost:
+		var rate data.TaxRate
+		if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.taxService.CreateTaxRate(&rate)
+		if err != nil {
+			writeServiceError(w, "Failed to create tax rate", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Tax rate created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *TaxHandler) HandleTaxRateByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid tax rate ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rate, err := h.taxService.GetTaxRate(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get tax rate", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Tax rate retrieved successfully", rate)
+
+	case http.MethodPut:
+		var rate data.TaxRate
+		if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.taxService.UpdateTaxRate(id, &rate)
+		if err != nil {
+			writeServiceError(w, "Failed to update tax rate", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Tax rate updated successfully", updated)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Asigna la tarifa de un producto (null para heredar la de su categoría)
+func (h *TaxHandler) HandleProductTaxRate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", "")
+		return
+	}
+
+	var req AssignTaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.taxService.AssignToProduct(id, req.TaxRateID); err != nil {
+		writeServiceError(w, "Failed to assign product tax rate", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Product tax rate updated successfully", req)
+}
+
+// Asigna la tarifa por defecto de una categoría
+func (h *TaxHandler) HandleCategoryTaxRate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid category ID", "")
+		return
+	}
+
+	var req AssignTaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.taxService.AssignToCategory(id, req.TaxRateID); err != nil {
+		writeServiceError(w, "Failed to assign category tax rate", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Category tax rate updated successfully", req)
+}
+
+// Impuestos por tarifa del periodo (?from=&to=&type=)
+func (h *TaxHandler) HandleTaxReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	report, err := h.taxService.GetTaxReport(filter)
+	if err != nil {
+		writeServiceError(w, "Failed to get tax report", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Tax report retrieved successfully", report)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type UnitHandler struct {
+	unitService *services.UnitService
+}
+
+func NewUnitHandler(unitService *services.UnitService) *UnitHandler {
+	return &UnitHandler{
+		unitService: unitService,
+	}
+}
+
+// GET lista el catálogo de unidades, POST agrega una
+func (h *UnitHandler) HandleUnits(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		units, err := h.unitService.ListUnits()
+		if err != nil {
+			writeServiceError(w, "Failed to list units", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Units retrieved successfully", units)
+
+	case http.MethodPost:
+		var unit data.MeasurementUnit
+		if err := json.NewDecoder(r.Body).Decode(&unit); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		created, err := h.unitService.CreateUnit(&unit)
+		if err != nil {
+			writeServiceError(w, "Failed to create unit", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Unit created successfully", created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func (h *UnitHandler) HandleUnitByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid unit ID", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		unit, err := h.unitService.GetUnit(id)
+		if err != nil {
+			writeServiceError(w, "Failed to get unit", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Unit retrieved successfully", unit)
+
+	case http.MethodPut:
+		var unit data.MeasurementUnit
+		if err := json.NewDecoder(r.Body).Decode(&unit); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		updated, err := h.unitService.UpdateUnit(id, &unit)
+		if err != nil {
+			writeServiceError(w, "Failed to update unit", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Unit updated successfully", updated)
+
+	case http.MethodDelete:
+		if err := h.unitService.DeleteUnit(id); err != nil {
+			writeServiceError(w, "Failed to delete unit", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Unit deleted successfully", nil)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/pkgzx/liliApi/src/internal/middleware"
 	"github.com/pkgzx/liliApi/src/internal/services"
@@ -43,6 +44,10 @@ type CreateUserRequest struct {
 	FullName string `json:"full_name"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
 type UserResponse struct {
 	Success bool      `json:"success"`
 	Message string    `json:"message"`
@@ -168,6 +173,47 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// Ticket para abrir el feed SSE con ?ticket=, en lugar de poner el token de
+// sesión en la URL. Vale durante la retención del feed, así que las
+// reconexiones automáticas de EventSource pueden reutilizarlo.
+func (h *UserHandler) StreamTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	ticket, expiresAt, err := h.authService.IssueStreamTicket(userClaims)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Cannot issue stream ticket", err.Error())
+		return
+	}
+
+	type StreamTicketResponse struct {
+		Success   bool      `json:"success"`
+		Message   string    `json:"message"`
+		Ticket    string    `json:"ticket"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	response := StreamTicketResponse{
+		Success:   true,
+		Message:   "Stream ticket issued successfully",
+		Ticket:    ticket,
+		ExpiresAt: expiresAt,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // Crear usuario
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -226,6 +272,39 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// Cambiar el rol de un usuario (solo administradores)
+func (h *UserHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid user ID", "")
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.userService.UpdateRole(userClaims.Role, id, req.Role); err != nil {
+		writeServiceError(w, "Failed to update user role", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "User role updated successfully", nil)
+}
+
 // Función auxiliar para escribir respuestas de error
 func (h *UserHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message, errorDetail string) {
 	response := ErrorResponse{
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkgzx/liliApi/src/internal/middleware"
+	"github.com/pkgzx/liliApi/src/internal/services"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type WasteHandler struct {
+	wasteService *services.WasteService
+}
+
+func NewWasteHandler(wasteService *services.WasteService) *WasteHandler {
+	return &WasteHandler{
+		wasteService: wasteService,
+	}
+}
+
+// GET lista las mermas (?from=&to=&reason=&user_id=), POST registra una
+func (h *WasteHandler) HandleWaste(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		period, err := parseOrderFilter(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+			return
+		}
+
+		userID, err := queryInt(r, "user_id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid user_id", err.Error())
+			return
+		}
+
+		records, err := h.wasteService.ListWaste(repository.WasteFilter{
+			From:   period.From,
+			To:     period.To,
+			Reason: r.URL.Query().Get("reason"),
+			UserID: int32(userID),
+		})
+		if err != nil {
+			writeServiceError(w, "Failed to list waste records", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, "Waste records retrieved successfully", records)
+
+	case http.MethodPost:
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "User not authenticated", "")
+			return
+		}
+
+		var req services.WasteInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+
+		waste, err := h.wasteService.RecordWaste(req, userClaims.UserID)
+		if err != nil {
+			writeServiceError(w, "Failed to record waste", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, "Waste recorded successfully", waste)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// GET devuelve la merma con los ingredientes que descargó
+func (h *WasteHandler) HandleWasteByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid waste record ID", "")
+		return
+	}
+
+	waste, err := h.wasteService.GetWaste(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get waste record", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Waste record retrieved successfully", waste)
+}
+
+// GET costo de las mermas por motivo, ingrediente y empleado (?from=&to=)
+func (h *WasteHandler) HandleWasteReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	period, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	report, err := h.wasteService.GetWasteReport(period.From, period.To)
+	if err != nil {
+		writeServiceError(w, "Failed to get waste report", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, "Waste report retrieved successfully", report)
+}
@@ -30,11 +30,35 @@ type ErrorResponse struct {
 }
 
 func (m *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+    return m.requireAuth(next, false)
+}
+
+// Igual que RequireAuth pero acepta un ticket de stream en ?ticket=, porque
+// EventSource en el navegador no permite enviar el header Authorization.
+// El ticket solo vale para el feed, así que el token de sesión nunca viaja en la URL.
+func (m *AuthMiddleware) RequireStreamAuth(next http.HandlerFunc) http.HandlerFunc {
+    return m.requireAuth(next, true)
+}
+
+func (m *AuthMiddleware) requireAuth(next http.HandlerFunc, allowTicket bool) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         w.Header().Set("Content-Type", "application/json")
 
         // Obtener token del header Authorization
         authHeader := r.Header.Get("Authorization")
+        if authHeader == "" && allowTicket {
+            if ticket := r.URL.Query().Get("ticket"); ticket != "" {
+                claims, err := m.authService.ValidateStreamTicket(ticket)
+                if err != nil {
+                    m.writeErrorResponse(w, http.StatusUnauthorized, "Invalid stream ticket", err.Error())
+                    return
+                }
+
+                next(w, r.WithContext(context.WithValue(r.Context(), UserContextKey, claims)))
+                return
+            }
+        }
+
         if authHeader == "" {
             m.writeErrorResponse(w, http.StatusUnauthorized, "Authorization header required", "")
             return
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "io"
+    "log"
+    "net/http"
+
+    "github.com/pkgzx/liliApi/src/internal/services"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type IdempotencyMiddleware struct {
+    idempotencyService *services.IdempotencyService
+}
+
+func NewIdempotencyMiddleware(idempotencyService *services.IdempotencyService) *IdempotencyMiddleware {
+    return &IdempotencyMiddleware{
+        idempotencyService: idempotencyService,
+    }
+}
+
+// Para endpoints POST que crean recursos. Si la petición trae Idempotency-Key,
+// los reintentos con el mismo cuerpo reciben la respuesta original en vez de
+// ejecutarse de nuevo. Debe ir dentro de RequireAuth: las claves son por usuario.
+func (m *IdempotencyMiddleware) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        key := r.Header.Get(IdempotencyKeyHeader)
+        if r.Method != http.MethodPost || key == "" {
+            next(w, r)
+            return
+        }
+
+        user, ok := GetUserFromContext(r)
+        if !ok {
+            next(w, r)
+            return
+        }
+
+        body, err := io.ReadAll(r.Body)
+        if err != nil {
+            m.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+            return
+        }
+        r.Body = io.NopCloser(bytes.NewReader(body))
+
+        stored, err := m.idempotencyService.Begin(user.UserID, key, r.Method, r.URL.Path, body)
+        if err != nil {
+            switch {
+            case errors.Is(err, services.ErrInvalidIdempotencyKey):
+                m.writeErrorResponse(w, http.StatusBadRequest, "Invalid idempotency key", err.Error())
+            case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+                m.writeErrorResponse(w, http.StatusUnprocessableEntity, "Idempotency key reused", err.Error())
+            case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+                m.writeErrorResponse(w, http.StatusConflict, "Request in progress", err.Error())
+            default:
+                m.writeErrorResponse(w, http.StatusInternalServerError, "Failed to check idempotency key", err.Error())
+            }
+            return
+        }
+
+        // Reintento de una petición ya resuelta: se repite la respuesta original
+        if stored != nil {
+            w.Header().Set("Content-Type", "application/json")
+            w.Header().Set("Idempotent-Replayed", "true")
+            w.WriteHeader(stored.StatusCode)
+            w.Write(stored.ResponseBody)
+            return
+        }
+
+        recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+        finished := false
+        defer func() {
+            // Si el handler entra en pánico se libera la clave para permitir el reintento
+            if !finished {
+                if err := m.idempotencyService.Abort(user.UserID, key); err != nil {
+                    log.Printf("Failed to release idempotency key: %v", err)
+                }
+            }
+        }()
+
+        next(recorder, r)
+        finished = true
+
+        if err := m.idempotencyService.Finish(user.UserID, key, recorder.statusCode, recorder.body.Bytes()); err != nil {
+            log.Printf("Failed to save idempotent response: %v", err)
+        }
+    }
+}
+
+func (m *IdempotencyMiddleware) writeErrorResponse(w http.ResponseWriter, statusCode int, message, errorDetail string) {
+    response := ErrorResponse{
+        Success: false,
+        Message: message,
+        Error:   errorDetail,
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(statusCode)
+    json.NewEncoder(w).Encode(response)
+}
+
+// Copia la respuesta mientras se envía al cliente
+type responseRecorder struct {
+    http.ResponseWriter
+    statusCode  int
+    wroteHeader bool
+    body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+    if !rec.wroteHeader {
+        rec.statusCode = statusCode
+        rec.wroteHeader = true
+    }
+    rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+    rec.wroteHeader = true
+    rec.body.Write(b)
+    return rec.ResponseWriter.Write(b)
+}
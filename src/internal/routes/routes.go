@@ -8,19 +8,45 @@ import (
 )
 
 type Router struct {
-	authMiddleware *middleware.AuthMiddleware
+	authMiddleware        *middleware.AuthMiddleware
+	idempotencyMiddleware *middleware.IdempotencyMiddleware
 }
 
-func NewRouter(authMiddleware *middleware.AuthMiddleware) *Router {
+func NewRouter(authMiddleware *middleware.AuthMiddleware, idempotencyMiddleware *middleware.IdempotencyMiddleware) *Router {
 	return &Router{
-		authMiddleware: authMiddleware,
+		authMiddleware:        authMiddleware,
+		idempotencyMiddleware: idempotencyMiddleware,
 	}
 }
 
+// Autenticación más Idempotency-Key, para los endpoints que crean órdenes, pagos o movimientos de inventario
+func (r *Router) requireAuthIdempotent(next http.HandlerFunc) http.HandlerFunc {
+	return r.authMiddleware.RequireAuth(r.idempotencyMiddleware.Idempotent(next))
+}
+
 func (r *Router) SetupRoutes(
 	userHandler *handlers.UserHandler,
 	// productHandler *handlers.ProductHandler,
-	// orderHandler *handlers.OrderHandler,
+	orderHandler *handlers.OrderHandler,
+	tableHandler *handlers.TableHandler,
+	splitHandler *handlers.SplitHandler,
+	paymentHandler *handlers.PaymentHandler,
+	shiftHandler *handlers.ShiftHandler,
+	discountHandler *handlers.DiscountHandler,
+	taxHandler *handlers.TaxHandler,
+	receiptHandler *handlers.ReceiptHandler,
+	orderFeedHandler *handlers.OrderFeedHandler,
+	stationHandler *handlers.StationHandler,
+	syncHandler *handlers.SyncHandler,
+	ingredientHandler *handlers.IngredientHandler,
+	inventoryHandler *handlers.InventoryHandler,
+	stockAlertHandler *handlers.StockAlertHandler,
+	supplierHandler *handlers.SupplierHandler,
+	purchaseOrderHandler *handlers.PurchaseOrderHandler,
+	unitHandler *handlers.UnitHandler,
+	stockTakeHandler *handlers.StockTakeHandler,
+	lotHandler *handlers.LotHandler,
+	wasteHandler *handlers.WasteHandler,
 ) *http.ServeMux {
 	mux := http.NewServeMux()
 
@@ -29,7 +55,25 @@ func (r *Router) SetupRoutes(
 
 	// Configurar otras rutas (cuando estén listas)
 	// r.setupProductRoutes(mux, productHandler)
-	// r.setupOrderRoutes(mux, orderHandler)
+	r.setupOrderRoutes(mux, orderHandler)
+	r.setupTableRoutes(mux, tableHandler)
+	r.setupSplitRoutes(mux, splitHandler)
+	r.setupPaymentRoutes(mux, paymentHandler)
+	r.setupShiftRoutes(mux, shiftHandler)
+	r.setupDiscountRoutes(mux, discountHandler)
+	r.setupTaxRoutes(mux, taxHandler)
+	r.setupReceiptRoutes(mux, receiptHandler)
+	r.setupOrderFeedRoutes(mux, orderFeedHandler)
+	r.setupStationRoutes(mux, stationHandler)
+	r.setupSyncRoutes(mux, syncHandler)
+	r.setupIngredientRoutes(mux, ingredientHandler)
+	r.setupInventoryRoutes(mux, inventoryHandler)
+	r.setupStockAlertRoutes(mux, stockAlertHandler)
+	r.setupPurchasingRoutes(mux, supplierHandler, purchaseOrderHandler)
+	r.setupUnitRoutes(mux, unitHandler)
+	r.setupStockTakeRoutes(mux, stockTakeHandler)
+	r.setupLotRoutes(mux, lotHandler)
+	r.setupWasteRoutes(mux, wasteHandler)
 
 	return mux
 }
@@ -44,6 +88,8 @@ func (r *Router) setupAuthRoutes(mux *http.ServeMux, userHandler *handlers.UserH
 
 	// Rutas protegidas
 	mux.HandleFunc("/api/auth/profile", r.authMiddleware.RequireAuth(userHandler.GetProfile))
+	mux.HandleFunc("/api/auth/stream-ticket", r.authMiddleware.RequireAuth(userHandler.StreamTicket))
+	mux.HandleFunc("/api/users/{id}/role", r.authMiddleware.RequireAuth(userHandler.UpdateRole))
 }
 
 // Rutas de productos (para cuando implementes el handler)
@@ -58,10 +104,160 @@ func (r *Router) setupAuthRoutes(mux *http.ServeMux, userHandler *handlers.UserH
 // 	mux.HandleFunc("/api/admin/products/", r.authMiddleware.RequireAuth(productHandler.AdminHandleProductByID))
 // }
 
-// Rutas de pedidos (para cuando implementes el handler)
-// func (r *Router) setupOrderRoutes(mux *http.ServeMux, orderHandler *handlers.OrderHandler) {
-// 	// Todas las rutas de pedidos requieren autenticación
-// 	mux.HandleFunc("/api/orders", r.authMiddleware.RequireAuth(orderHandler.HandleOrders))
-// 	mux.HandleFunc("/api/orders/", r.authMiddleware.RequireAuth(orderHandler.HandleOrderByID))
-// 	mux.HandleFunc("/api/orders/status/", r.authMiddleware.RequireAuth(orderHandler.HandleOrdersByStatus))
-// }
+// Rutas de pedidos
+func (r *Router) setupOrderRoutes(mux *http.ServeMux, orderHandler *handlers.OrderHandler) {
+	// Todas las rutas de pedidos requieren autenticación (filtro por estado con ?status=)
+	mux.HandleFunc("/api/orders", r.requireAuthIdempotent(orderHandler.HandleOrders))
+	mux.HandleFunc("/api/orders/scheduled", r.authMiddleware.RequireAuth(orderHandler.HandleScheduledOrders))
+	mux.HandleFunc("/api/orders/{id}", r.authMiddleware.RequireAuth(orderHandler.HandleOrderByID))
+	mux.HandleFunc("/api/orders/{id}/items", r.requireAuthIdempotent(orderHandler.HandleOrderItems))
+	mux.HandleFunc("/api/orders/{id}/status", r.authMiddleware.RequireAuth(orderHandler.HandleOrderStatus))
+	mux.HandleFunc("/api/orders/{id}/courses/fire", r.authMiddleware.RequireAuth(orderHandler.HandleFireCourse))
+
+	// Reportes
+	mux.HandleFunc("/api/reports/sales", r.authMiddleware.RequireAuth(orderHandler.HandleSalesReport))
+}
+
+// Rutas de mesas (servicio en salón)
+func (r *Router) setupTableRoutes(mux *http.ServeMux, tableHandler *handlers.TableHandler) {
+	mux.HandleFunc("/api/tables", r.authMiddleware.RequireAuth(tableHandler.HandleTables))
+	mux.HandleFunc("/api/tables/floor-plan", r.authMiddleware.RequireAuth(tableHandler.HandleFloorPlan))
+	mux.HandleFunc("/api/tables/{id}", r.authMiddleware.RequireAuth(tableHandler.HandleTableByID))
+	mux.HandleFunc("/api/tables/{id}/orders", r.requireAuthIdempotent(tableHandler.HandleTableOrders))
+	mux.HandleFunc("/api/tables/{id}/merge", r.authMiddleware.RequireAuth(tableHandler.HandleMergeTables))
+	mux.HandleFunc("/api/orders/{id}/move", r.authMiddleware.RequireAuth(tableHandler.HandleMoveOrder))
+}
+
+// Rutas de división de cuentas
+func (r *Router) setupSplitRoutes(mux *http.ServeMux, splitHandler *handlers.SplitHandler) {
+	mux.HandleFunc("/api/orders/{id}/splits", r.authMiddleware.RequireAuth(splitHandler.HandleOrderSplits))
+}
+
+// Rutas de pagos
+func (r *Router) setupPaymentRoutes(mux *http.ServeMux, paymentHandler *handlers.PaymentHandler) {
+	mux.HandleFunc("/api/orders/{id}/payments", r.requireAuthIdempotent(paymentHandler.HandleOrderPayments))
+	mux.HandleFunc("/api/payments/{id}/reverse", r.authMiddleware.RequireAuth(paymentHandler.HandleReversePayment))
+	mux.HandleFunc("/api/reports/tips", r.authMiddleware.RequireAuth(paymentHandler.HandleTipsReport))
+}
+
+// Rutas de turnos de caja
+func (r *Router) setupShiftRoutes(mux *http.ServeMux, shiftHandler *handlers.ShiftHandler) {
+	mux.HandleFunc("/api/shifts", r.authMiddleware.RequireAuth(shiftHandler.HandleShifts))
+	mux.HandleFunc("/api/shifts/current", r.authMiddleware.RequireAuth(shiftHandler.HandleCurrentShift))
+	mux.HandleFunc("/api/shifts/{id}", r.authMiddleware.RequireAuth(shiftHandler.HandleShiftByID))
+	mux.HandleFunc("/api/shifts/{id}/cash-movements", r.authMiddleware.RequireAuth(shiftHandler.HandleCashMovements))
+	mux.HandleFunc("/api/shifts/{id}/close", r.authMiddleware.RequireAuth(shiftHandler.HandleCloseShift))
+	mux.HandleFunc("/api/shifts/{id}/report", r.authMiddleware.RequireAuth(shiftHandler.HandleShiftReport))
+}
+
+// Rutas de descuentos y cupones
+func (r *Router) setupDiscountRoutes(mux *http.ServeMux, discountHandler *handlers.DiscountHandler) {
+	mux.HandleFunc("/api/discounts", r.authMiddleware.RequireAuth(discountHandler.HandlePresets))
+	mux.HandleFunc("/api/discounts/{id}", r.authMiddleware.RequireAuth(discountHandler.HandlePresetByID))
+	mux.HandleFunc("/api/coupons", r.authMiddleware.RequireAuth(discountHandler.HandleCoupons))
+	mux.HandleFunc("/api/coupons/{id}", r.authMiddleware.RequireAuth(discountHandler.HandleCouponByID))
+	mux.HandleFunc("/api/orders/{id}/discounts", r.authMiddleware.RequireAuth(discountHandler.HandleOrderDiscounts))
+	mux.HandleFunc("/api/orders/{id}/discounts/{discountId}", r.authMiddleware.RequireAuth(discountHandler.HandleOrderDiscountByID))
+	mux.HandleFunc("/api/orders/{id}/coupon", r.authMiddleware.RequireAuth(discountHandler.HandleApplyCoupon))
+}
+
+// Rutas de impuestos
+func (r *Router) setupTaxRoutes(mux *http.ServeMux, taxHandler *handlers.TaxHandler) {
+	mux.HandleFunc("/api/tax-rates", r.authMiddleware.RequireAuth(taxHandler.HandleTaxRates))
+	mux.HandleFunc("/api/tax-rates/{id}", r.authMiddleware.RequireAuth(taxHandler.HandleTaxRateByID))
+	mux.HandleFunc("/api/products/{id}/tax-rate", r.authMiddleware.RequireAuth(taxHandler.HandleProductTaxRate))
+	mux.HandleFunc("/api/categories/{id}/tax-rate", r.authMiddleware.RequireAuth(taxHandler.HandleCategoryTaxRate))
+	mux.HandleFunc("/api/reports/taxes", r.authMiddleware.RequireAuth(taxHandler.HandleTaxReport))
+}
+
+// Rutas de recibos
+func (r *Router) setupReceiptRoutes(mux *http.ServeMux, receiptHandler *handlers.ReceiptHandler) {
+	mux.HandleFunc("/api/orders/{id}/receipt", r.authMiddleware.RequireAuth(receiptHandler.HandleOrderReceipt))
+}
+
+// Feed en tiempo real (SSE) para las pantallas de cocina
+func (r *Router) setupOrderFeedRoutes(mux *http.ServeMux, orderFeedHandler *handlers.OrderFeedHandler) {
+	mux.HandleFunc("/api/orders/events", r.authMiddleware.RequireStreamAuth(orderFeedHandler.HandleOrderFeed))
+}
+
+// Rutas de estaciones de cocina y comandas
+func (r *Router) setupStationRoutes(mux *http.ServeMux, stationHandler *handlers.StationHandler) {
+	mux.HandleFunc("/api/stations", r.authMiddleware.RequireAuth(stationHandler.HandleStations))
+	mux.HandleFunc("/api/stations/{id}", r.authMiddleware.RequireAuth(stationHandler.HandleStationByID))
+	mux.HandleFunc("/api/stations/{id}/tickets", r.authMiddleware.RequireAuth(stationHandler.HandleStationTickets))
+	mux.HandleFunc("/api/products/{id}/station", r.authMiddleware.RequireAuth(stationHandler.HandleProductStation))
+	mux.HandleFunc("/api/products/{id}/prep-time", r.authMiddleware.RequireAuth(stationHandler.HandleProductPrepTime))
+	mux.HandleFunc("/api/orders/{id}/tickets", r.authMiddleware.RequireAuth(stationHandler.HandleOrderTickets))
+	mux.HandleFunc("/api/orders/{id}/items/{itemId}/status", r.authMiddleware.RequireAuth(stationHandler.HandleItemStatus))
+	mux.HandleFunc("/api/orders/{id}/stations/{stationId}/status", r.authMiddleware.RequireAuth(stationHandler.HandleTicketStatus))
+}
+
+// Sincronización de POS que trabajan sin conexión
+func (r *Router) setupSyncRoutes(mux *http.ServeMux, syncHandler *handlers.SyncHandler) {
+	mux.HandleFunc("/api/sync", r.authMiddleware.RequireAuth(syncHandler.HandleSync))
+	mux.HandleFunc("/api/sync/catalog", r.authMiddleware.RequireAuth(syncHandler.HandleCatalogChanges))
+}
+
+// Rutas de ingredientes, recetas, combos y modificadores
+func (r *Router) setupIngredientRoutes(mux *http.ServeMux, ingredientHandler *handlers.IngredientHandler) {
+	mux.HandleFunc("/api/ingredients", r.authMiddleware.RequireAuth(ingredientHandler.HandleIngredients))
+	mux.HandleFunc("/api/ingredients/{id}", r.authMiddleware.RequireAuth(ingredientHandler.HandleIngredientByID))
+	mux.HandleFunc("/api/ingredients/{id}/packs", r.authMiddleware.RequireAuth(ingredientHandler.HandleIngredientPacks))
+	mux.HandleFunc("/api/ingredients/{id}/packs/{packId}", r.authMiddleware.RequireAuth(ingredientHandler.HandleIngredientPackByID))
+	mux.HandleFunc("/api/products/{id}/recipe", r.authMiddleware.RequireAuth(ingredientHandler.HandleProductRecipe))
+	mux.HandleFunc("/api/products/{id}/components", r.authMiddleware.RequireAuth(ingredientHandler.HandleBundleComponents))
+	mux.HandleFunc("/api/products/{id}/modifiers", r.authMiddleware.RequireAuth(ingredientHandler.HandleProductModifiers))
+}
+
+// Movimientos de inventario
+func (r *Router) setupInventoryRoutes(mux *http.ServeMux, inventoryHandler *handlers.InventoryHandler) {
+	mux.HandleFunc("/api/ingredients/{id}/movements", r.authMiddleware.RequireAuth(inventoryHandler.HandleIngredientMovements))
+}
+
+// Alertas de stock bajo
+func (r *Router) setupStockAlertRoutes(mux *http.ServeMux, stockAlertHandler *handlers.StockAlertHandler) {
+	mux.HandleFunc("/api/stock-alerts", r.authMiddleware.RequireAuth(stockAlertHandler.HandleStockAlerts))
+	mux.HandleFunc("/api/stock-alerts/{id}/acknowledge", r.authMiddleware.RequireAuth(stockAlertHandler.HandleAcknowledgeAlert))
+	mux.HandleFunc("/api/stock-alerts/{id}/resolve", r.authMiddleware.RequireAuth(stockAlertHandler.HandleResolveAlert))
+}
+
+// Proveedores y órdenes de compra
+func (r *Router) setupPurchasingRoutes(mux *http.ServeMux, supplierHandler *handlers.SupplierHandler, purchaseOrderHandler *handlers.PurchaseOrderHandler) {
+	mux.HandleFunc("/api/suppliers", r.authMiddleware.RequireAuth(supplierHandler.HandleSuppliers))
+	mux.HandleFunc("/api/suppliers/{id}", r.authMiddleware.RequireAuth(supplierHandler.HandleSupplierByID))
+	mux.HandleFunc("/api/purchase-orders", r.authMiddleware.RequireAuth(purchaseOrderHandler.HandlePurchaseOrders))
+	mux.HandleFunc("/api/purchase-orders/{id}", r.authMiddleware.RequireAuth(purchaseOrderHandler.HandlePurchaseOrderByID))
+	mux.HandleFunc("/api/purchase-orders/{id}/send", r.authMiddleware.RequireAuth(purchaseOrderHandler.HandleSendPurchaseOrder))
+	mux.HandleFunc("/api/purchase-orders/{id}/cancel", r.authMiddleware.RequireAuth(purchaseOrderHandler.HandleCancelPurchaseOrder))
+	mux.HandleFunc("/api/purchase-orders/{id}/receive", r.requireAuthIdempotent(purchaseOrderHandler.HandleReceivePurchaseOrder))
+}
+
+// Catálogo de unidades de medida
+func (r *Router) setupUnitRoutes(mux *http.ServeMux, unitHandler *handlers.UnitHandler) {
+	mux.HandleFunc("/api/units", r.authMiddleware.RequireAuth(unitHandler.HandleUnits))
+	mux.HandleFunc("/api/units/{id}", r.authMiddleware.RequireAuth(unitHandler.HandleUnitByID))
+}
+
+// Conteos físicos del almacén
+func (r *Router) setupStockTakeRoutes(mux *http.ServeMux, stockTakeHandler *handlers.StockTakeHandler) {
+	mux.HandleFunc("/api/stock-takes", r.authMiddleware.RequireAuth(stockTakeHandler.HandleStockTakes))
+	mux.HandleFunc("/api/stock-takes/{id}", r.authMiddleware.RequireAuth(stockTakeHandler.HandleStockTakeByID))
+	mux.HandleFunc("/api/stock-takes/{id}/counts", r.authMiddleware.RequireAuth(stockTakeHandler.HandleStockTakeCounts))
+	mux.HandleFunc("/api/stock-takes/{id}/approve", r.requireAuthIdempotent(stockTakeHandler.HandleApproveStockTake))
+	mux.HandleFunc("/api/stock-takes/{id}/cancel", r.authMiddleware.RequireAuth(stockTakeHandler.HandleCancelStockTake))
+}
+
+// Lotes con vencimiento y sus bajas
+func (r *Router) setupLotRoutes(mux *http.ServeMux, lotHandler *handlers.LotHandler) {
+	mux.HandleFunc("/api/lots", r.authMiddleware.RequireAuth(lotHandler.HandleLots))
+	mux.HandleFunc("/api/lots/write-off-expired", r.requireAuthIdempotent(lotHandler.HandleWriteOffExpired))
+	mux.HandleFunc("/api/lots/{id}", r.authMiddleware.RequireAuth(lotHandler.HandleLotByID))
+	mux.HandleFunc("/api/lots/{id}/write-off", r.requireAuthIdempotent(lotHandler.HandleWriteOffLot))
+}
+
+// Registro de mermas y su reporte
+func (r *Router) setupWasteRoutes(mux *http.ServeMux, wasteHandler *handlers.WasteHandler) {
+	mux.HandleFunc("/api/waste", r.requireAuthIdempotent(wasteHandler.HandleWaste))
+	mux.HandleFunc("/api/waste/{id}", r.authMiddleware.RequireAuth(wasteHandler.HandleWasteByID))
+	mux.HandleFunc("/api/reports/waste", r.authMiddleware.RequireAuth(wasteHandler.HandleWasteReport))
+}
@@ -10,17 +10,19 @@ import (
 )
 
 type AuthService struct {
-    userService *UserService
-    jwtSecret   string
+    userService     *UserService
+    jwtSecret       string
+    streamTicketTTL time.Duration
 }
 
-func NewAuthService(userService *UserService, jwtSecret string) *AuthService {
+func NewAuthService(userService *UserService, jwtSecret string, streamTicketTTL time.Duration) *AuthService {
     if jwtSecret == "" {
         jwtSecret = "default-secret-key-change-in-production"
     }
     return &AuthService{
-        userService: userService,
-        jwtSecret:   jwtSecret,
+        userService:     userService,
+        jwtSecret:       jwtSecret,
+        streamTicketTTL: streamTicketTTL,
     }
 }
 
@@ -28,9 +30,26 @@ type TokenClaims struct {
     UserID   int32  `json:"user_id"`
     Username string `json:"username"`
     FullName string `json:"full_name"`
+    Role     string `json:"role"`
     jwt.RegisteredClaims
 }
 
+// Los tickets de stream autentican el feed SSE: EventSource no puede enviar
+// el header Authorization, así que el cliente pide un ticket y lo pasa en la
+// URL en lugar del token de sesión. EventSource reconecta con la misma URL,
+// por eso el ticket dura lo que la retención del feed: mientras se puedan
+// reproducir eventos, la reconexión automática sigue autenticada. El ticket
+// solo sirve para el feed, que es de lectura.
+const streamTicketAudience = "order-feed"
+
+// Error de los servicios cuando el usuario no tiene permiso para la operación
+var ErrForbidden = errors.New("not allowed")
+
+// Indica si el rol puede operar sobre recursos de otros usuarios (turnos ajenos)
+func isManagerRole(role string) bool {
+    return role == data.UserRoleManager || role == data.UserRoleAdmin
+}
+
 type LoginResult struct {
     User  *data.User `json:"user"`
     Token string     `json:"token"`
@@ -61,6 +80,7 @@ func (s *AuthService) generateToken(user *data.User) (string, error) {
         UserID:   user.ID,
         Username: user.Username,
         FullName: user.FullName,
+        Role:     user.Role,
         RegisteredClaims: jwt.RegisteredClaims{
             ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 horas
             IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -83,6 +103,53 @@ func (s *AuthService) generateToken(user *data.User) (string, error) {
 }
 
 func (s *AuthService) ValidateToken(tokenString string) (*TokenClaims, error) {
+    claims, err := s.parseToken(tokenString)
+    if err != nil {
+        return nil, err
+    }
+
+    // Un ticket de stream no sirve como token de sesión
+    if len(claims.Audience) > 0 {
+        return nil, errors.New("invalid token")
+    }
+
+    return claims, nil
+}
+
+// Emite un ticket de stream para el usuario del token de sesión
+func (s *AuthService) IssueStreamTicket(user *TokenClaims) (string, time.Time, error) {
+    now := time.Now()
+    expiresAt := now.Add(s.streamTicketTTL)
+
+    claims := TokenClaims{
+        UserID:   user.UserID,
+        Username: user.Username,
+        FullName: user.FullName,
+        Role:     user.Role,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ExpiresAt: jwt.NewNumericDate(expiresAt),
+            IssuedAt:  jwt.NewNumericDate(now),
+            NotBefore: jwt.NewNumericDate(now),
+            Issuer:    "liliapi",
+            Subject:   fmt.Sprintf("user_%d", user.UserID),
+            Audience:  jwt.ClaimStrings{streamTicketAudience},
+        },
+    }
+
+    ticket, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+    if err != nil {
+        return "", time.Time{}, fmt.Errorf("error signing stream ticket: %w", err)
+    }
+
+    return ticket, expiresAt, nil
+}
+
+// Valida un ticket de stream; los tokens de sesión no se aceptan aquí
+func (s *AuthService) ValidateStreamTicket(ticket string) (*TokenClaims, error) {
+    return s.parseToken(ticket, jwt.WithAudience(streamTicketAudience))
+}
+
+func (s *AuthService) parseToken(tokenString string, options ...jwt.ParserOption) (*TokenClaims, error) {
     // Parsear token
     token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
         // Verificar método de firma
@@ -90,7 +157,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*TokenClaims, error) {
             return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
         }
         return []byte(s.jwtSecret), nil
-    })
+    }, options...)
 
     if err != nil {
         return nil, err
@@ -0,0 +1,34 @@
+package services
+
+import (
+    "testing"
+    "time"
+)
+
+func TestStreamTicketOutlivesReconnects(t *testing.T) {
+    const retention = 6 * time.Hour
+    auth := NewAuthService(nil, "secret", retention)
+    user := &TokenClaims{UserID: 7, Username: "ana", Role: "staff"}
+
+    ticket, expiresAt, err := auth.IssueStreamTicket(user)
+    if err != nil {
+        t.Fatalf("IssueStreamTicket() error = %v", err)
+    }
+
+    // EventSource reconecta con la misma URL: el ticket debe valer toda la retención
+    if remaining := time.Until(expiresAt); remaining < retention-time.Minute || remaining > retention {
+        t.Errorf("ticket expires in %v, want about %v", remaining, retention)
+    }
+
+    claims, err := auth.ValidateStreamTicket(ticket)
+    if err != nil {
+        t.Fatalf("ValidateStreamTicket() error = %v", err)
+    }
+    if claims.UserID != user.UserID {
+        t.Errorf("ticket user = %d, want %d", claims.UserID, user.UserID)
+    }
+
+    if _, err := auth.ValidateToken(ticket); err == nil {
+        t.Error("ValidateToken() accepted a stream ticket as a session token")
+    }
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+    "errors"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type DiscountService struct {
+    discountRepo *repository.DiscountRepository
+    orderService *OrderService
+}
+
+func NewDiscountService(discountRepo *repository.DiscountRepository, orderService *OrderService) *DiscountService {
+    return &DiscountService{
+        discountRepo: discountRepo,
+        orderService: orderService,
+    }
+}
+
+// Descuento a aplicar: un preset o un descuento manual (nombre, tipo y valor)
+type ApplyDiscountInput struct {
+    OrderItemID *int32  `json:"order_item_id,omitempty"`
+    PresetID    *int32  `json:"preset_id,omitempty"`
+    Name        string  `json:"name,omitempty"`
+    Type        string  `json:"type,omitempty"`
+    Value       float64 `json:"value,omitempty"`
+}
+
+// ---- Descuentos predefinidos ----
+
+func (s *DiscountService) ListPresets() ([]data.DiscountPreset, error) {
+    return s.discountRepo.GetAllPresets()
+}
+
+func (s *DiscountService) GetPreset(id int32) (*data.DiscountPreset, error) {
+    preset, err := s.discountRepo.GetPresetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if preset == nil {
+        return nil, errors.New("discount preset not found")
+    }
+
+    return preset, nil
+}
+
+func (s *DiscountService) CreatePreset(preset *data.DiscountPreset) (*data.DiscountPreset, error) {
+    if err := validatePreset(preset); err != nil {
+        return nil, err
+    }
+
+    if err := s.discountRepo.CreatePreset(preset); err != nil {
+        return nil, err
+    }
+
+    return preset, nil
+}
+
+func (s *DiscountService) UpdatePreset(id int32, preset *data.DiscountPreset) (*data.DiscountPreset, error) {
+    existing, err := s.GetPreset(id)
+    if err != nil {
+        return nil, err
+    }
+
+    preset.ID = existing.ID
+    preset.CreatedAt = existing.CreatedAt
+    if err := validatePreset(preset); err != nil {
+        return nil, err
+    }
+
+    if err := s.discountRepo.UpdatePreset(preset); err != nil {
+        return nil, err
+    }
+
+    return preset, nil
+}
+
+// ---- Cupones ----
+
+func (s *DiscountService) ListCoupons() ([]data.Coupon, error) {
+    return s.discountRepo.GetAllCoupons()
+}
+
+func (s *DiscountService) GetCoupon(id int32) (*data.Coupon, error) {
+    coupon, err := s.discountRepo.GetCouponByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if coupon == nil {
+        return nil, errors.New("coupon not found")
+    }
+
+    return coupon, nil
+}
+
+func (s *DiscountService) CreateCoupon(coupon *data.Coupon) (*data.Coupon, error) {
+    if err := validateCoupon(coupon); err != nil {
+        return nil, err
+    }
+
+    existing, err := s.discountRepo.GetCouponByCode(coupon.Code)
+    if err != nil {
+        return nil, err
+    }
+
+    if existing != nil {
+        return nil, errors.New("coupon code already exists")
+    }
+
+    coupon.TimesUsed = 0
+    if err := s.discountRepo.CreateCoupon(coupon); err != nil {
+        return nil, err
+    }
+
+    return coupon, nil
+}
+
+func (s *DiscountService) UpdateCoupon(id int32, coupon *data.Coupon) (*data.Coupon, error) {
+    existing, err := s.GetCoupon(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := validateCoupon(coupon); err != nil {
+        return nil, err
+    }
+
+    if coupon.Code != existing.Code {
+        other, err := s.discountRepo.GetCouponByCode(coupon.Code)
+        if err != nil {
+            return nil, err
+        }
+
+        if other != nil && other.ID != id {
+            return nil, errors.New("coupon code already exists")
+        }
+    }
+
+    // El contador de usos solo lo modifica el canje
+    coupon.ID = existing.ID
+    coupon.TimesUsed = existing.TimesUsed
+    coupon.CreatedAt = existing.CreatedAt
+    if err := s.discountRepo.UpdateCoupon(coupon); err != nil {
+        return nil, err
+    }
+
+    return coupon, nil
+}
+
+// ---- Descuentos sobre órdenes ----
+
+func (s *DiscountService) GetOrderDiscounts(orderID int32) ([]data.OrderDiscount, error) {
+    if _, err := s.orderService.GetOrder(orderID); err != nil {
+        return nil, err
+    }
+
+    return s.discountRepo.GetByOrder(orderID)
+}
+
+// Aplica un descuento a la orden completa o a uno de sus items y devuelve la orden recalculada
+func (s *DiscountService) ApplyDiscount(orderID int32, input ApplyDiscountInput) (*OrderDetail, error) {
+    discount := data.OrderDiscount{
+        OrderID:     orderID,
+        OrderItemID: input.OrderItemID,
+    }
+
+    if input.PresetID != nil {
+        preset, err := s.GetPreset(*input.PresetID)
+        if err != nil {
+            return nil, err
+        }
+
+        if !preset.IsActive {
+            return nil, errors.New("discount preset is not active")
+        }
+
+        if preset.Scope == data.DiscountScopeItem && input.OrderItemID == nil {
+            return nil, errors.New("discount preset applies to items, order_item_id is required")
+        }
+
+        if preset.Scope == data.DiscountScopeOrder && input.OrderItemID != nil {
+            return nil, errors.New("discount preset applies to the whole order")
+        }
+
+        discount.PresetID = &preset.ID
+        discount.Name = preset.Name
+        discount.Type = preset.Type
+        discount.Value = preset.Value
+    } else {
+        discount.Name = strings.TrimSpace(input.Name)
+        discount.Type = input.Type
+        discount.Value = input.Value
+
+        if discount.Name == "" {
+            return nil, errors.New("discount name is required")
+        }
+
+        if err := validateDiscountValue(discount.Type, discount.Value); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.discountRepo.AddToOrder(&discount); err != nil {
+        return nil, err
+    }
+
+    return s.orderService.GetOrder(orderID)
+}
+
+// Canjea un código de cupón; el cliente se identifica por la referencia indicada o el teléfono de la orden
+func (s *DiscountService) ApplyCoupon(orderID int32, code, customerRef string) (*OrderDetail, error) {
+    code = normalizeCouponCode(code)
+    if code == "" {
+        return nil, errors.New("coupon code is required")
+    }
+
+    detail, err := s.orderService.GetOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    coupon, err := s.discountRepo.GetCouponByCode(code)
+    if err != nil {
+        return nil, err
+    }
+
+    if coupon == nil {
+        return nil, errors.New("coupon not found")
+    }
+
+    if !coupon.IsActive {
+        return nil, errors.New("coupon is not active")
+    }
+
+    now := time.Now()
+    if coupon.ValidFrom != nil && now.Before(*coupon.ValidFrom) {
+        return nil, errors.New("coupon is not valid yet")
+    }
+
+    if coupon.ValidUntil != nil && now.After(*coupon.ValidUntil) {
+        return nil, errors.New("coupon has expired")
+    }
+
+    customerRef = strings.TrimSpace(customerRef)
+    if customerRef == "" {
+        customerRef = detail.Order.CustomerPhone
+    }
+
+    if coupon.MaxUsesPerCustomer > 0 && customerRef == "" {
+        return nil, errors.New("customer is required to redeem this coupon")
+    }
+
+    discount := data.OrderDiscount{
+        OrderID:  orderID,
+        CouponID: &coupon.ID,
+        Name:     coupon.Code,
+        Type:     coupon.Type,
+        Value:    coupon.Value,
+    }
+
+    if err := s.discountRepo.RedeemCoupon(&discount, coupon, customerRef); err != nil {
+        return nil, err
+    }
+
+    return s.orderService.GetOrder(orderID)
+}
+
+func (s *DiscountService) RemoveDiscount(orderID, discountID int32) (*OrderDetail, error) {
+    if err := s.discountRepo.RemoveFromOrder(orderID, discountID); err != nil {
+        return nil, err
+    }
+
+    return s.orderService.GetOrder(orderID)
+}
+
+func validatePreset(preset *data.DiscountPreset) error {
+    preset.Name = strings.TrimSpace(preset.Name)
+    if preset.Name == "" {
+        return errors.New("discount preset name is required")
+    }
+
+    if preset.Scope != data.DiscountScopeItem && preset.Scope != data.DiscountScopeOrder {
+        return errors.New("invalid discount scope")
+    }
+
+    return validateDiscountValue(preset.Type, preset.Value)
+}
+
+func validateCoupon(coupon *data.Coupon) error {
+    coupon.Code = normalizeCouponCode(coupon.Code)
+    if coupon.Code == "" {
+        return errors.New("coupon code is required")
+    }
+
+    if coupon.MinOrderAmount < 0 {
+        return errors.New("minimum order amount cannot be negative")
+    }
+
+    if coupon.MaxUses < 0 || coupon.MaxUsesPerCustomer < 0 {
+        return errors.New("usage limits cannot be negative")
+    }
+
+    if coupon.ValidFrom != nil && coupon.ValidUntil != nil && coupon.ValidUntil.Before(*coupon.ValidFrom) {
+        return errors.New("coupon valid_until must be after valid_from")
+    }
+
+    return validateDiscountValue(coupon.Type, coupon.Value)
+}
+
+func validateDiscountValue(discountType string, value float64) error {
+    switch discountType {
+    case data.DiscountTypePercentage:
+        if value <= 0 || value > 100 {
+            return errors.New("percentage discount must be between 0 and 100")
+        }
+    case data.DiscountTypeFixed:
+        if value <= 0 {
+            return errors.New("discount value must be greater than zero")
+        }
+    default:
+        return errors.New("invalid discount type")
+    }
+
+    return nil
+}
+
+func normalizeCouponCode(code string) string {
+    return strings.ToUpper(strings.TrimSpace(code))
+}
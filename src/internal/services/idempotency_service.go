@@ -0,0 +1,108 @@
+package services
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "log"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Largo máximo aceptado para el header Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
+// Tiempo que una petición en curso retiene su clave. Si el proceso cae sin
+// guardar la respuesta, pasado este plazo un reintento puede volver a ejecutarla.
+const idempotencyLease = 2 * time.Minute
+
+var (
+    ErrInvalidIdempotencyKey    = errors.New("invalid idempotency key")
+    ErrIdempotencyKeyMismatch   = errors.New("idempotency key was already used with a different request")
+    ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Evita que un reintento (doble toque, red inestable) cree dos veces la misma
+// orden o pago: la primera respuesta queda guardada y se repite tal cual.
+type IdempotencyService struct {
+    idempotencyRepo *repository.IdempotencyRepository
+    ttl             time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+    return &IdempotencyService{
+        idempotencyRepo: idempotencyRepo,
+        ttl:             ttl,
+    }
+}
+
+// Reserva la clave para esta petición. Devuelve la respuesta guardada si es
+// un reintento ya resuelto, o nil si la petición debe ejecutarse.
+func (s *IdempotencyService) Begin(userID int32, key, method, path string, body []byte) (*data.IdempotencyKey, error) {
+    if key == "" || len(key) > maxIdempotencyKeyLength {
+        return nil, ErrInvalidIdempotencyKey
+    }
+
+    record := &data.IdempotencyKey{
+        UserID:      userID,
+        Key:         key,
+        Method:      method,
+        Path:        path,
+        RequestHash: requestHash(method, path, body),
+        ExpiresAt:   time.Now().Add(s.ttl),
+    }
+
+    existing, err := s.idempotencyRepo.Reserve(record, idempotencyLease)
+    if err != nil {
+        return nil, err
+    }
+
+    if existing == nil {
+        return nil, nil
+    }
+
+    if existing.RequestHash != record.RequestHash {
+        return nil, ErrIdempotencyKeyMismatch
+    }
+
+    if existing.StatusCode == 0 {
+        return nil, ErrIdempotencyKeyInProgress
+    }
+
+    return existing, nil
+}
+
+// Guarda la respuesta. Los errores del servidor no se guardan: la clave se
+// libera para que el cliente pueda reintentar.
+func (s *IdempotencyService) Finish(userID int32, key string, statusCode int, body []byte) error {
+    if statusCode >= 500 {
+        return s.idempotencyRepo.Release(userID, key)
+    }
+
+    return s.idempotencyRepo.Complete(userID, key, statusCode, body)
+}
+
+func (s *IdempotencyService) Abort(userID int32, key string) error {
+    return s.idempotencyRepo.Release(userID, key)
+}
+
+// Borra periódicamente las claves vencidas; se ejecuta en su propia goroutine
+func (s *IdempotencyService) Run() {
+    prune := time.NewTicker(time.Hour)
+    defer prune.Stop()
+
+    for range prune.C {
+        if _, err := s.idempotencyRepo.DeleteExpired(idempotencyLease); err != nil {
+            log.Printf("Failed to prune idempotency keys: %v", err)
+        }
+    }
+}
+
+func requestHash(method, path string, body []byte) string {
+    hash := sha256.New()
+    hash.Write([]byte(method + " " + path + "\n"))
+    hash.Write(body)
+    return hex.EncodeToString(hash.Sum(nil))
+}
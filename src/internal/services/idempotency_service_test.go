@@ -0,0 +1,37 @@
+package services
+
+import "testing"
+
+func TestRequestHash(t *testing.T) {
+    const body = `{"table_id":1}`
+    base := requestHash("POST", "/api/orders", []byte(body))
+
+    // El hash se guarda con la llave: si cambia el formato, las llaves vigentes dejan de coincidir
+    if want := "c42a53150deb1c44d40dab57b3275c083d2e83b81f81eaa6fa51d129d08f5778"; base != want {
+        t.Fatalf("requestHash() = %s, want %s", base, want)
+    }
+
+    tests := []struct {
+        name   string
+        method string
+        path   string
+        body   string
+        same   bool
+    }{
+        {"same request", "POST", "/api/orders", body, true},
+        {"different method", "PUT", "/api/orders", body, false},
+        {"different path", "POST", "/api/orders/1", body, false},
+        {"different body", "POST", "/api/orders", `{"table_id":2}`, false},
+        {"path and body do not run together", "POST", "/api/orders{", `"table_id":1}`, false},
+        {"empty body", "POST", "/api/orders", "", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := requestHash(tt.method, tt.path, []byte(tt.body))
+            if (got == base) != tt.same {
+                t.Errorf("requestHash() = %s, base %s, want same = %v", got, base, tt.same)
+            }
+        })
+    }
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type IngredientService struct {
+    ingredientRepo *repository.IngredientRepository
+    productRepo    *repository.ProductRepository
+    unitService    *UnitService
+}
+
+func NewIngredientService(ingredientRepo *repository.IngredientRepository, productRepo *repository.ProductRepository, unitService *UnitService) *IngredientService {
+    return &IngredientService{
+        ingredientRepo: ingredientRepo,
+        productRepo:    productRepo,
+        unitService:    unitService,
+    }
+}
+
+func (s *IngredientService) ListIngredients(filter repository.IngredientFilter) ([]data.Ingredient, error) {
+    filter.Name = strings.TrimSpace(filter.Name)
+    return s.ingredientRepo.Find(filter)
+}
+
+func (s *IngredientService) GetIngredient(id int32) (*data.Ingredient, error) {
+    ingredient, err := s.ingredientRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if ingredient == nil {
+        return nil, errors.New("ingredient not found")
+    }
+
+    return ingredient, nil
+}
+
+func (s *IngredientService) CreateIngredient(ingredient *data.Ingredient) (*data.Ingredient, error) {
+    if err := s.validateIngredient(ingredient, ""); err != nil {
+        return nil, err
+    }
+
+    if err := s.ingredientRepo.Create(ingredient); err != nil {
+        return nil, err
+    }
+
+    return ingredient, nil
+}
+
+func (s *IngredientService) UpdateIngredient(id int32, ingredient *data.Ingredient) (*data.Ingredient, error) {
+    existing, err := s.GetIngredient(id)
+    if err != nil {
+        return nil, err
+    }
+
+    ingredient.ID = existing.ID
+    ingredient.CreatedAt = existing.CreatedAt
+    if err := s.validateIngredient(ingredient, existing.Unit); err != nil {
+        return nil, err
+    }
+
+    catalog, err := s.unitService.IsCatalogUnit(existing.Unit)
+    if err != nil {
+        return nil, err
+    }
+
+    // Stock, recetas y compras están expresados en la unidad actual. Una unidad
+    // en texto libre previa al catálogo sí se puede cambiar por la del
+    // catálogo que corresponda: las cantidades se conservan tal cual.
+    if !strings.EqualFold(ingredient.Unit, existing.Unit) && catalog {
+        referenced, err := s.ingredientRepo.IsReferenced(id)
+        if err != nil {
+            return nil, err
+        }
+        if referenced {
+            return nil, errors.New("ingredient unit cannot change once it has movements, recipes or purchases")
+        }
+    }
+
+    if err := s.ingredientRepo.Update(ingredient); err != nil {
+        return nil, err
+    }
+
+    return ingredient, nil
+}
+
+func (s *IngredientService) DeleteIngredient(id int32) error {
+    return s.ingredientRepo.Delete(id)
+}
+
+func (s *IngredientService) GetRecipe(productID int32) ([]data.RecipeItem, error) {
+    return s.ingredientRepo.GetRecipe(productID)
+}
+
+// Reemplaza la receta de un producto; una lista vacía la elimina. Si un item
+// trae unidad, la cantidad se convierte a la unidad del ingrediente.
+func (s *IngredientService) SetRecipe(productID int32, items []data.RecipeItem) ([]data.RecipeItem, error) {
+    seen := make(map[int32]bool, len(items))
+    for i := range items {
+        item := &items[i]
+        if item.Quantity <= 0 {
+            return nil, errors.New("recipe quantity must be greater than zero")
+        }
+
+        if seen[item.IngredientID] {
+            return nil, fmt.Errorf("ingredient %d is repeated in the recipe", item.IngredientID)
+        }
+        seen[item.IngredientID] = true
+
+        ingredient, err := s.GetIngredient(item.IngredientID)
+        if err != nil {
+            return nil, err
+        }
+
+        item.Quantity, err = s.unitService.ToIngredientUnit(ingredient, item.Quantity, item.Unit)
+        if err != nil {
+            return nil, err
+        }
+        item.Unit = ingredient.Unit
+    }
+
+    if err := s.ingredientRepo.SetRecipe(productID, items); err != nil {
+        return nil, err
+    }
+
+    return s.ingredientRepo.GetRecipe(productID)
+}
+
+// Convierte una cantidad en cualquier unidad compatible a la unidad del ingrediente
+func (s *IngredientService) ToIngredientUnit(ingredientID int32, quantity float64, unit string) (float64, error) {
+    ingredient, err := s.GetIngredient(ingredientID)
+    if err != nil {
+        return 0, err
+    }
+
+    return s.unitService.ToIngredientUnit(ingredient, quantity, unit)
+}
+
+func (s *IngredientService) GetPacks(ingredientID int32) ([]data.PackUnit, error) {
+    if _, err := s.GetIngredient(ingredientID); err != nil {
+        return nil, err
+    }
+
+    return s.unitService.GetPacks(ingredientID)
+}
+
+func (s *IngredientService) CreatePack(ingredientID int32, pack *data.PackUnit) (*data.PackUnit, error) {
+    ingredient, err := s.GetIngredient(ingredientID)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.unitService.CreatePack(ingredient, pack)
+}
+
+func (s *IngredientService) DeletePack(ingredientID, packID int32) error {
+    return s.unitService.DeletePack(ingredientID, packID)
+}
+
+func (s *IngredientService) GetBundleComponents(bundleID int32) ([]data.BundleComponent, error) {
+    return s.ingredientRepo.GetBundleComponents(bundleID)
+}
+
+// Reemplaza los productos que incluye un combo; una lista vacía lo deja como producto simple
+func (s *IngredientService) SetBundleComponents(bundleID int32, components []data.BundleComponent) ([]data.BundleComponent, error) {
+    seen := make(map[int32]bool, len(components))
+    for _, component := range components {
+        if component.Quantity <= 0 {
+            return nil, errors.New("component quantity must be greater than zero")
+        }
+
+        if component.ProductID == bundleID {
+            return nil, errors.New("a bundle cannot contain itself")
+        }
+
+        if seen[component.ProductID] {
+            return nil, fmt.Errorf("product %d is repeated in the bundle", component.ProductID)
+        }
+        seen[component.ProductID] = true
+
+        product, err := s.productRepo.GetByID(component.ProductID)
+        if err != nil {
+            return nil, err
+        }
+        if product == nil {
+            return nil, fmt.Errorf("product %d not found", component.ProductID)
+        }
+    }
+
+    if err := s.ingredientRepo.SetBundleComponents(bundleID, components); err != nil {
+        return nil, err
+    }
+
+    return s.ingredientRepo.GetBundleComponents(bundleID)
+}
+
+func (s *IngredientService) GetModifiers(productID int32) ([]data.ProductModifier, error) {
+    return s.productRepo.GetModifiers(productID)
+}
+
+// Reemplaza los modificadores de un producto; una lista vacía los elimina. La
+// cantidad de ingrediente se convierte a la unidad del ingrediente si trae unidad.
+func (s *IngredientService) SetModifiers(productID int32, modifiers []data.ProductModifier) ([]data.ProductModifier, error) {
+    seen := make(map[string]bool, len(modifiers))
+    for i := range modifiers {
+        modifier := &modifiers[i]
+        modifier.Name = strings.TrimSpace(modifier.Name)
+        if modifier.Name == "" {
+            return nil, errors.New("modifier name is required")
+        }
+
+        key := strings.ToLower(modifier.Name)
+        if seen[key] {
+            return nil, fmt.Errorf("modifier %s is repeated", modifier.Name)
+        }
+        seen[key] = true
+
+        if modifier.IngredientID == nil {
+            if modifier.Quantity != 0 {
+                return nil, fmt.Errorf("modifier %s has a quantity but no ingredient", modifier.Name)
+            }
+            modifier.Unit = ""
+            continue
+        }
+
+        if modifier.Quantity == 0 {
+            return nil, fmt.Errorf("modifier %s needs a quantity for its ingredient", modifier.Name)
+        }
+
+        ingredient, err := s.GetIngredient(*modifier.IngredientID)
+        if err != nil {
+            return nil, err
+        }
+
+        // Se convierte la magnitud y se conserva el signo (negativo quita ingrediente)
+        quantity, err := s.unitService.ToIngredientUnit(ingredient, math.Abs(modifier.Quantity), modifier.Unit)
+        if err != nil {
+            return nil, err
+        }
+        modifier.Quantity = math.Copysign(quantity, modifier.Quantity)
+        modifier.Unit = ingredient.Unit
+    }
+
+    if err := s.productRepo.SetModifiers(productID, modifiers); err != nil {
+        return nil, err
+    }
+
+    return s.productRepo.GetModifiers(productID)
+}
+
+// legacyUnit es la unidad actual del ingrediente: se acepta aunque no esté en
+// el catálogo para poder editar ingredientes anteriores a él
+func (s *IngredientService) validateIngredient(ingredient *data.Ingredient, legacyUnit string) error {
+    ingredient.Name = strings.TrimSpace(ingredient.Name)
+    if ingredient.Name == "" {
+        return errors.New("ingredient name is required")
+    }
+
+    ingredient.Unit = strings.TrimSpace(ingredient.Unit)
+    if ingredient.Unit == "" {
+        return errors.New("ingredient unit is required")
+    }
+
+    if legacyUnit == "" || ingredient.Unit != legacyUnit {
+        unit, err := s.unitService.GetUnitByCode(ingredient.Unit)
+        if err != nil {
+            return err
+        }
+        ingredient.Unit = unit.Code
+    }
+
+    if ingredient.MinStock < 0 {
+        return errors.New("min stock cannot be negative")
+    }
+
+    if ingredient.CostPerUnit < 0 {
+        return errors.New("cost per unit cannot be negative")
+    }
+
+    existing, err := s.ingredientRepo.GetByName(ingredient.Name)
+    if err != nil {
+        return err
+    }
+
+    if existing != nil && existing.ID != ingredient.ID {
+        return errors.New("ingredient already exists")
+    }
+
+    return nil
+}
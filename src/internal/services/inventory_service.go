@@ -0,0 +1,149 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Qué hacer con un movimiento que deja el stock en negativo
+const (
+    NegativeStockBlock = "block" // se rechaza
+    NegativeStockAllow = "allow" // se registra igual; el conteo físico lo corrige después
+)
+
+// Tamaño de página del historial de movimientos
+const (
+    defaultMovementsLimit = 50
+    maxMovementsLimit     = 200
+)
+
+type InventoryService struct {
+    inventoryRepo     *repository.InventoryRepository
+    ingredientService *IngredientService
+    allowNegative     bool
+}
+
+func NewInventoryService(inventoryRepo *repository.InventoryRepository, ingredientService *IngredientService, negativeStockPolicy string) *InventoryService {
+    return &InventoryService{
+        inventoryRepo:     inventoryRepo,
+        ingredientService: ingredientService,
+        allowNegative:     negativeStockPolicy == NegativeStockAllow,
+    }
+}
+
+func IsValidNegativeStockPolicy(policy string) bool {
+    return policy == NegativeStockBlock || policy == NegativeStockAllow
+}
+
+type MovementPage struct {
+    Movements []data.InventoryMovement `json:"movements"`
+    Total     int                      `json:"total"`
+    Limit     int                      `json:"limit"`
+    Offset    int                      `json:"offset"`
+}
+
+type MovementInput struct {
+    MovementType string  `json:"movement_type"`
+    Quantity     float64 `json:"quantity"`
+    Unit         string  `json:"unit"` // opcional; por defecto la unidad del ingrediente
+    Reason       string  `json:"reason"`
+    // Solo para entradas: identifican el lote que ingresa
+    LotNumber string     `json:"lot_number"`
+    ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Registra una entrada, salida o ajuste de un ingrediente; la cantidad se
+// convierte a la unidad del ingrediente
+func (s *InventoryService) RecordMovement(ingredientID, userID int32, input MovementInput) (*data.InventoryMovement, error) {
+    quantity, err := s.ingredientService.ToIngredientUnit(ingredientID, input.Quantity, input.Unit)
+    if err != nil {
+        return nil, err
+    }
+
+    movement := &data.InventoryMovement{
+        IngredientID: ingredientID,
+        MovementType: input.MovementType,
+        Quantity:     quantity,
+        Reason:       strings.TrimSpace(input.Reason),
+        UserID:       &userID,
+        LotNumber:    strings.TrimSpace(input.LotNumber),
+        ExpiresAt:    input.ExpiresAt,
+    }
+
+    if err := validateMovement(movement); err != nil {
+        return nil, err
+    }
+
+    if err := s.inventoryRepo.RecordMovement(movement, s.allowNegative); err != nil {
+        return nil, err
+    }
+
+    return movement, nil
+}
+
+// Historial paginado de un ingrediente; movementType vacío trae todos los tipos
+func (s *InventoryService) ListMovements(ingredientID int32, movementType string, limit, offset int) (*MovementPage, error) {
+    if _, err := s.ingredientService.GetIngredient(ingredientID); err != nil {
+        return nil, err
+    }
+
+    if movementType != "" && !isValidMovementType(movementType) {
+        return nil, fmt.Errorf("invalid movement type %s", movementType)
+    }
+
+    if limit <= 0 {
+        limit = defaultMovementsLimit
+    }
+    if limit > maxMovementsLimit {
+        limit = maxMovementsLimit
+    }
+    if offset < 0 {
+        return nil, errors.New("offset cannot be negative")
+    }
+
+    movements, total, err := s.inventoryRepo.GetMovements(ingredientID, movementType, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+
+    return &MovementPage{
+        Movements: movements,
+        Total:     total,
+        Limit:     limit,
+        Offset:    offset,
+    }, nil
+}
+
+func validateMovement(movement *data.InventoryMovement) error {
+    switch movement.MovementType {
+    case data.MovementTypeEntrada, data.MovementTypeSalida:
+        if movement.Quantity <= 0 {
+            return errors.New("movement quantity must be greater than zero")
+        }
+    case data.MovementTypeAjuste:
+        if movement.Quantity == 0 {
+            return errors.New("adjustment quantity cannot be zero")
+        }
+        // Un ajuste sin explicación no se puede auditar
+        if movement.Reason == "" {
+            return errors.New("adjustment reason is required")
+        }
+    default:
+        return fmt.Errorf("invalid movement type %s", movement.MovementType)
+    }
+
+    return nil
+}
+
+func isValidMovementType(movementType string) bool {
+    switch movementType {
+    case data.MovementTypeEntrada, data.MovementTypeSalida, data.MovementTypeAjuste:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+    "errors"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type LotService struct {
+    lotRepo           *repository.LotRepository
+    wasteRepo         *repository.WasteRepository
+    ingredientService *IngredientService
+}
+
+func NewLotService(lotRepo *repository.LotRepository, wasteRepo *repository.WasteRepository, ingredientService *IngredientService) *LotService {
+    return &LotService{
+        lotRepo:           lotRepo,
+        wasteRepo:         wasteRepo,
+        ingredientService: ingredientService,
+    }
+}
+
+// Sin Quantity se da de baja todo lo que queda del lote
+type WriteOffInput struct {
+    Quantity float64 `json:"quantity"`
+    Unit     string  `json:"unit"`
+    Reason   string  `json:"reason"`
+}
+
+func (s *LotService) ListLots(filter repository.LotFilter) ([]data.InventoryLot, error) {
+    if filter.ExpiringWithinDays != nil && *filter.ExpiringWithinDays < 0 {
+        return nil, errors.New("days cannot be negative")
+    }
+
+    return s.lotRepo.Find(filter)
+}
+
+func (s *LotService) GetLot(id int32) (*data.InventoryLot, error) {
+    lot, err := s.lotRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if lot == nil {
+        return nil, errors.New("lot not found")
+    }
+
+    return lot, nil
+}
+
+// Da de baja un lote como merma vencida; el motivo queda en las notas
+func (s *LotService) WriteOff(id, userID int32, input WriteOffInput) (*data.WasteRecord, error) {
+    reason := strings.TrimSpace(input.Reason)
+    if reason == "" {
+        return nil, errors.New("write-off reason is required")
+    }
+
+    if input.Quantity < 0 {
+        return nil, errors.New("write-off quantity cannot be negative")
+    }
+
+    lot, err := s.GetLot(id)
+    if err != nil {
+        return nil, err
+    }
+
+    quantity, err := s.ingredientService.ToIngredientUnit(lot.IngredientID, input.Quantity, input.Unit)
+    if err != nil {
+        return nil, err
+    }
+
+    if quantity == 0 {
+        quantity = lot.QuantityRemaining
+    }
+    if quantity <= 0 {
+        return nil, errors.New("lot has nothing left to write off")
+    }
+
+    record := repository.NewLotWriteOff(lot, quantity, userID, reason)
+    if err := s.wasteRepo.Create(record); err != nil {
+        return nil, err
+    }
+
+    return record, nil
+}
+
+// Da de baja todos los lotes vencidos que aún tienen existencias
+func (s *LotService) WriteOffExpired(userID int32, reason string) ([]data.WasteRecord, error) {
+    reason = strings.TrimSpace(reason)
+    if reason == "" {
+        return nil, errors.New("write-off reason is required")
+    }
+
+    return s.lotRepo.WriteOffExpired(userID, reason)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+    "log"
+    "sync"
+    "time"
+
+    "github.com/lib/pq"
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Máximo de eventos leídos por consulta al reproducir o despachar
+const orderFeedBatchSize = 500
+
+// Reparte los avisos de LISTEN/NOTIFY entre las conexiones SSE abiertas.
+// Las notificaciones solo despiertan a los suscriptores: cada uno lee de la
+// tabla order_events desde su cursor, así no se pierden eventos si la
+// conexión a Postgres se cae y se reconecta.
+type OrderFeedService struct {
+    eventRepo   *repository.OrderEventRepository
+    listener    *pq.Listener
+    retention   time.Duration
+    mu          sync.Mutex
+    subscribers map[chan struct{}]struct{}
+}
+
+func NewOrderFeedService(eventRepo *repository.OrderEventRepository, listener *pq.Listener, retention time.Duration) *OrderFeedService {
+    return &OrderFeedService{
+        eventRepo:   eventRepo,
+        listener:    listener,
+        retention:   retention,
+        subscribers: make(map[chan struct{}]struct{}),
+    }
+}
+
+// Bucle principal; se ejecuta en su propia goroutine durante toda la vida del servidor
+func (s *OrderFeedService) Run() {
+    ping := time.NewTicker(90 * time.Second)
+    defer ping.Stop()
+    prune := time.NewTicker(time.Hour)
+    defer prune.Stop()
+
+    for {
+        select {
+        case _, ok := <-s.listener.Notify:
+            if !ok {
+                return
+            }
+            // Una notificación nil indica reconexión: igual se despierta a todos para que relean
+            s.broadcast()
+
+        case <-ping.C:
+            go func() {
+                if err := s.listener.Ping(); err != nil {
+                    log.Printf("Order feed listener ping failed: %v", err)
+                }
+            }()
+
+        case <-prune.C:
+            if _, err := s.eventRepo.DeleteBefore(time.Now().Add(-s.retention)); err != nil {
+                log.Printf("Failed to prune order events: %v", err)
+            }
+        }
+    }
+}
+
+// Registra un suscriptor; la función devuelta lo da de baja
+func (s *OrderFeedService) Subscribe() (<-chan struct{}, func()) {
+    ch := make(chan struct{}, 1)
+
+    s.mu.Lock()
+    s.subscribers[ch] = struct{}{}
+    s.mu.Unlock()
+
+    return ch, func() {
+        s.mu.Lock()
+        delete(s.subscribers, ch)
+        s.mu.Unlock()
+    }
+}
+
+func (s *OrderFeedService) broadcast() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for ch := range s.subscribers {
+        // Si ya tiene un aviso pendiente no hace falta otro
+        select {
+        case ch <- struct{}{}:
+        default:
+        }
+    }
+}
+
+func (s *OrderFeedService) EventsSince(after repository.OrderEventCursor) ([]data.OrderEvent, bool, error) {
+    return s.eventRepo.GetSince(after, orderFeedBatchSize)
+}
+
+func (s *OrderFeedService) CurrentCursor() (repository.OrderEventCursor, error) {
+    return s.eventRepo.GetCurrentCursor()
+}
+
+func (s *OrderFeedService) BatchSize() int {
+    return orderFeedBatchSize
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+    "log"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Libera las pre-órdenes a cocina cuando ya no queda más tiempo que el
+// necesario para prepararlas: su propio tiempo de preparación más la cola
+// actual de las estaciones que usan, más un margen configurable.
+type OrderSchedulerService struct {
+    orderRepo   *repository.OrderRepository
+    stationRepo *repository.StationRepository
+    lead        time.Duration
+    interval    time.Duration
+}
+
+func NewOrderSchedulerService(orderRepo *repository.OrderRepository, stationRepo *repository.StationRepository, lead, interval time.Duration) *OrderSchedulerService {
+    return &OrderSchedulerService{
+        orderRepo:   orderRepo,
+        stationRepo: stationRepo,
+        lead:        lead,
+        interval:    interval,
+    }
+}
+
+// Bucle del programador; se ejecuta en su propia goroutine durante toda la vida del servidor
+func (s *OrderSchedulerService) Run() {
+    ticker := time.NewTicker(s.interval)
+    defer ticker.Stop()
+
+    for {
+        if released, err := s.ReleaseDue(time.Now()); err != nil {
+            log.Printf("Failed to release scheduled orders: %v", err)
+        } else if released > 0 {
+            log.Printf("Released %d scheduled orders to the kitchen", released)
+        }
+        <-ticker.C
+    }
+}
+
+// Libera las pre-órdenes que deben empezar a prepararse a la hora now
+func (s *OrderSchedulerService) ReleaseDue(now time.Time) (int, error) {
+    orders, err := s.orderRepo.GetScheduled(repository.OrderFilter{})
+    if err != nil || len(orders) == 0 {
+        return 0, err
+    }
+
+    queue, err := s.stationRepo.GetKitchenQueue()
+    if err != nil {
+        return 0, err
+    }
+
+    scheduledItems, err := s.stationRepo.GetScheduledItems()
+    if err != nil {
+        return 0, err
+    }
+
+    // Minutos ya comprometidos en cada estación
+    load := make(map[int32]int32)
+    for _, item := range queue {
+        load[stationKey(item.StationID)] += remainingPrepMinutes(item, now)
+    }
+
+    // Minutos que cada pre-orden agrega a cada estación
+    own := make(map[int32]map[int32]int32)
+    for _, item := range scheduledItems {
+        if own[item.OrderID] == nil {
+            own[item.OrderID] = make(map[int32]int32)
+        }
+        own[item.OrderID][stationKey(item.StationID)] += item.PrepMinutes
+    }
+
+    released := 0
+    // Las órdenes vienen por hora programada: las liberadas cargan la cola de las siguientes
+    for _, order := range orders {
+        if order.ScheduledFor == nil {
+            continue
+        }
+
+        var needed int32
+        for station, minutes := range own[order.ID] {
+            if load[station]+minutes > needed {
+                needed = load[station] + minutes
+            }
+        }
+
+        releaseAt := order.ScheduledFor.Add(-time.Duration(needed)*time.Minute - s.lead)
+        if now.Before(releaseAt) {
+            continue
+        }
+
+        ok, err := s.orderRepo.ReleaseScheduled(order.ID)
+        if err != nil {
+            return released, err
+        }
+        if !ok {
+            continue
+        }
+        released++
+
+        for station, minutes := range own[order.ID] {
+            load[station] += minutes
+        }
+    }
+
+    return released, nil
+}
+
@@ -1 +1,586 @@
-package services
\ No newline at end of file
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkgzx/liliApi/src/pkg/data"
+	"github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type OrderService struct {
+	orderRepo    *repository.OrderRepository
+	productRepo  *repository.ProductRepository
+	discountRepo *repository.DiscountRepository
+	stationRepo  *repository.StationRepository
+	settings     OrderSettings
+}
+
+// Parámetros de negocio con los que se crean las órdenes
+type OrderSettings struct {
+	TaxMode                string  // inclusive o exclusive
+	ServiceChargeRate      float64 // porcentaje; 0 lo desactiva
+	ServiceChargePartySize int32   // se cobra a mesas de más comensales que este
+}
+
+func NewOrderService(orderRepo *repository.OrderRepository, productRepo *repository.ProductRepository, discountRepo *repository.DiscountRepository, stationRepo *repository.StationRepository, settings OrderSettings) *OrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		productRepo:  productRepo,
+		discountRepo: discountRepo,
+		stationRepo:  stationRepo,
+		settings:     settings,
+	}
+}
+
+// Límites de las indicaciones por item y de los tiempos del servicio
+const (
+	maxItemNotesLength = 200
+	maxCourse          = 9
+)
+
+type OrderItemInput struct {
+	ProductID int32  `json:"product_id"`
+	Quantity  int32  `json:"quantity"`
+	Notes     string `json:"notes"`
+	// Tiempo del servicio (1 entradas, 2 fuertes, 3 postres...); 0 sale con la orden
+	Course int32 `json:"course"`
+	// Modificadores del producto ("extra queso", "sin cebolla")
+	ModifierIDs []int32 `json:"modifier_ids,omitempty"`
+}
+
+type CreateOrderInput struct {
+	Notes           string     `json:"notes"`
+	TableID         *int32     `json:"table_id,omitempty"`
+	OrderType       string     `json:"order_type"`
+	CustomerAddress string     `json:"customer_address"`
+	CustomerPhone   string     `json:"customer_phone"`
+	DeliveryFee     float64    `json:"delivery_fee"`
+	PickupTime      *time.Time `json:"pickup_time,omitempty"`
+	PartySize       int32      `json:"party_size,omitempty"`
+	// Pre-orden: hora a la que debe estar lista; queda programada hasta entonces
+	ScheduledFor *time.Time       `json:"scheduled_for,omitempty"`
+	Items        []OrderItemInput `json:"items"`
+}
+
+// Desglose del total de la orden
+type OrderTotals struct {
+	ItemsGross    float64 `json:"items_gross"`
+	ItemDiscounts float64 `json:"item_discounts"`
+	ItemsSubtotal float64 `json:"items_subtotal"`
+	OrderDiscount float64 `json:"order_discount"`
+	TaxMode       string  `json:"tax_mode"`
+	Tax           float64 `json:"tax"`
+	ServiceCharge float64 `json:"service_charge"`
+	DeliveryFee   float64 `json:"delivery_fee"`
+	Total         float64 `json:"total"`
+}
+
+type OrderDetail struct {
+	Order     *data.Order          `json:"order"`
+	Items     []data.OrderItem     `json:"items"`
+	Discounts []data.OrderDiscount `json:"discounts,omitempty"`
+	Taxes     []data.OrderTax      `json:"taxes"`
+	Totals    OrderTotals          `json:"totals"`
+}
+
+func (s *OrderService) CreateOrder(input CreateOrderInput) (*OrderDetail, error) {
+	if input.OrderType == "" {
+		input.OrderType = data.OrderTypeDineIn
+	}
+
+	if err := validateOrderType(&input); err != nil {
+		return nil, err
+	}
+
+	orderItems, err := s.buildItems(input.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	order := s.newOrder(input)
+	if err := s.orderRepo.CreateWithItems(order, orderItems); err != nil {
+		return nil, err
+	}
+
+	return s.GetOrder(order.ID)
+}
+
+// Arma la orden con la configuración vigente (modo de impuestos, cargo por servicio)
+func (s *OrderService) newOrder(input CreateOrderInput) *data.Order {
+	order := &data.Order{
+		Status:          data.OrderStatusPending,
+		Notes:           input.Notes,
+		TableID:         input.TableID,
+		OrderType:       input.OrderType,
+		CustomerAddress: input.CustomerAddress,
+		CustomerPhone:   input.CustomerPhone,
+		DeliveryFee:     input.DeliveryFee,
+		PickupTime:      input.PickupTime,
+		PaymentStatus:   data.PaymentStatusUnpaid,
+		TaxMode:         s.settings.TaxMode,
+		PartySize:       input.PartySize,
+		ScheduledFor:    input.ScheduledFor,
+	}
+
+	if input.ScheduledFor != nil {
+		order.Status = data.OrderStatusScheduled
+	}
+
+	// Cargo por servicio automático para mesas grandes
+	if input.OrderType == data.OrderTypeDineIn && s.settings.ServiceChargeRate > 0 &&
+		input.PartySize > s.settings.ServiceChargePartySize {
+		order.ServiceChargeRate = s.settings.ServiceChargeRate
+	}
+
+	return order
+}
+
+func (s *OrderService) GetOrder(id int32) (*OrderDetail, error) {
+	order, err := s.orderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+
+	items, err := s.orderRepo.GetItems(id)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts, err := s.discountRepo.GetByOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	taxes, err := s.orderRepo.GetTaxes(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyETAs(order); err != nil {
+		return nil, err
+	}
+
+	return newOrderDetail(order, items, discounts, taxes), nil
+}
+
+// Lista órdenes filtrando por estado y tipo (vista de cocina y reportes)
+func (s *OrderService) ListOrders(filter repository.OrderFilter) ([]data.Order, error) {
+	if filter.OrderType != "" && !isValidOrderType(filter.OrderType) {
+		return nil, errors.New("invalid order type")
+	}
+
+	var orders []data.Order
+	var err error
+	if filter == (repository.OrderFilter{}) {
+		orders, err = s.orderRepo.GetAll()
+	} else {
+		orders, err = s.orderRepo.Find(filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*data.Order, 0, len(orders))
+	for i := range orders {
+		refs = append(refs, &orders[i])
+	}
+
+	if err := s.applyETAs(refs...); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Completa la hora estimada de las órdenes que siguen en cocina. Se calcula
+// en cada lectura, así refleja los cambios de estado de items y órdenes.
+func (s *OrderService) applyETAs(orders ...*data.Order) error {
+	inKitchen := false
+	for _, order := range orders {
+		if order.Status == data.OrderStatusPending || order.Status == data.OrderStatusPreparing {
+			inKitchen = true
+			break
+		}
+	}
+
+	if !inKitchen {
+		return nil
+	}
+
+	queue, err := s.stationRepo.GetKitchenQueue()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	minutes := estimateQueueMinutes(queue, now)
+	for _, order := range orders {
+		if order.Status != data.OrderStatusPending && order.Status != data.OrderStatusPreparing {
+			continue
+		}
+
+		// Sin items pendientes la orden está por marcarse lista
+		eta := minutes[order.ID]
+		readyAt := now.Add(time.Duration(eta) * time.Minute)
+		order.EstimatedMinutes = &eta
+		order.EstimatedReadyAt = &readyAt
+	}
+
+	return nil
+}
+
+// Pre-órdenes pendientes de liberar, filtradas por hora programada
+func (s *OrderService) ListScheduled(filter repository.OrderFilter) ([]data.Order, error) {
+	if filter.OrderType != "" && !isValidOrderType(filter.OrderType) {
+		return nil, errors.New("invalid order type")
+	}
+
+	return s.orderRepo.GetScheduled(filter)
+}
+
+func (s *OrderService) GetSalesByType(filter repository.OrderFilter) ([]data.SalesByType, error) {
+	if filter.OrderType != "" && !isValidOrderType(filter.OrderType) {
+		return nil, errors.New("invalid order type")
+	}
+
+	return s.orderRepo.GetSalesByType(filter)
+}
+
+func (s *OrderService) AddItems(orderID int32, items []OrderItemInput) (*OrderDetail, error) {
+	order, err := s.getOpenOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	orderItems, err := s.buildItems(items)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orderItems) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+
+	if err := s.orderRepo.AddItems(order.ID, orderItems); err != nil {
+		return nil, err
+	}
+
+	return s.GetOrder(order.ID)
+}
+
+// Envía a cocina el siguiente tiempo retenido de una orden en mesa, o el
+// indicado en course (con los anteriores que sigan retenidos)
+func (s *OrderService) FireCourse(orderID int32, course int32) (*OrderDetail, error) {
+	order, err := s.getOpenOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.OrderType != data.OrderTypeDineIn {
+		return nil, errors.New("courses are only fired for dine-in orders")
+	}
+
+	if course < 0 || course > maxCourse {
+		return nil, fmt.Errorf("course must be between 0 and %d", maxCourse)
+	}
+
+	if _, err := s.orderRepo.FireCourse(order.ID, course); err != nil {
+		return nil, err
+	}
+
+	return s.GetOrder(order.ID)
+}
+
+func (s *OrderService) UpdateStatus(id int32, status string) error {
+	order, err := s.orderRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if order == nil {
+		return errors.New("order not found")
+	}
+
+	// La transición y el saldo se validan en el repositorio con la orden bloqueada
+	return s.orderRepo.UpdateStatus(id, status)
+}
+
+func (s *OrderService) getOpenOrder(id int32) (*data.Order, error) {
+	order, err := s.orderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+
+	if !isOpenStatus(order.Status) {
+		return nil, errors.New("order is already closed")
+	}
+
+	return order, nil
+}
+
+// Convierte los items solicitados en items de orden con el precio vigente del producto
+func (s *OrderService) buildItems(items []OrderItemInput) ([]data.OrderItem, error) {
+	orderItems := make([]data.OrderItem, 0, len(items))
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than zero")
+		}
+
+		notes, err := validateItemKitchenInfo(item.Notes, item.Course)
+		if err != nil {
+			return nil, err
+		}
+
+		product, err := s.productRepo.GetByID(item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		if product == nil {
+			return nil, fmt.Errorf("product %d not found", item.ProductID)
+		}
+
+		if !product.IsAvailable {
+			return nil, fmt.Errorf("product %s is not available", product.Name)
+		}
+
+		modifiers, err := s.resolveModifiers(product.ID, item.ModifierIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		unitPrice := product.Price
+		for _, modifier := range modifiers {
+			unitPrice += modifier.PriceDelta
+		}
+		if unitPrice < 0 {
+			return nil, fmt.Errorf("modifiers cannot make %s cost less than zero", product.Name)
+		}
+
+		orderItems = append(orderItems, data.OrderItem{
+			ProductID: product.ID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+			Subtotal:  unitPrice * float64(item.Quantity),
+			Notes:     notes,
+			Course:    item.Course,
+			Modifiers: modifiers,
+		})
+	}
+
+	return orderItems, nil
+}
+
+// Copia los modificadores pedidos, que deben pertenecer al producto
+func (s *OrderService) resolveModifiers(productID int32, modifierIDs []int32) ([]data.OrderItemModifier, error) {
+	if len(modifierIDs) == 0 {
+		return nil, nil
+	}
+
+	available, err := s.productRepo.GetModifiers(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int32]data.ProductModifier, len(available))
+	for _, modifier := range available {
+		byID[modifier.ID] = modifier
+	}
+
+	modifiers := make([]data.OrderItemModifier, 0, len(modifierIDs))
+	seen := make(map[int32]bool, len(modifierIDs))
+	for _, id := range modifierIDs {
+		modifier, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("modifier %d does not belong to product %d", id, productID)
+		}
+
+		if seen[id] {
+			return nil, fmt.Errorf("modifier %d is repeated", id)
+		}
+		seen[id] = true
+
+		modifiers = append(modifiers, data.OrderItemModifier{
+			ModifierID:   modifier.ID,
+			Name:         modifier.Name,
+			PriceDelta:   modifier.PriceDelta,
+			IngredientID: modifier.IngredientID,
+			Quantity:     modifier.Quantity,
+		})
+	}
+
+	return modifiers, nil
+}
+
+// Valida las indicaciones y el tiempo de un item; devuelve las notas limpias
+func validateItemKitchenInfo(notes string, course int32) (string, error) {
+	notes = strings.TrimSpace(notes)
+	if len([]rune(notes)) > maxItemNotesLength {
+		return "", fmt.Errorf("item notes cannot exceed %d characters", maxItemNotesLength)
+	}
+
+	if course < 0 || course > maxCourse {
+		return "", fmt.Errorf("course must be between 0 and %d", maxCourse)
+	}
+
+	return notes, nil
+}
+
+// Minutos estimados por orden: cada estación prepara sus líneas en orden de
+// llegada y las estaciones trabajan en paralelo, así que una orden está lista
+// cuando termina la más cargada de las estaciones que usa. Las líneas se
+// preparan completas (la cantidad no multiplica el tiempo) y los items sin
+// estación forman su propia cola. Las que ya están en preparación solo
+// cuentan lo que les falta a la hora now.
+func estimateQueueMinutes(queue []data.KitchenQueueItem, now time.Time) map[int32]int32 {
+	load := make(map[int32]int32)
+	minutes := make(map[int32]int32)
+
+	for i := 0; i < len(queue); {
+		orderID := queue[i].OrderID
+		var stations []int32
+
+		for ; i < len(queue) && queue[i].OrderID == orderID; i++ {
+			station := stationKey(queue[i].StationID)
+			load[station] += remainingPrepMinutes(queue[i], now)
+			stations = append(stations, station)
+		}
+
+		for _, station := range stations {
+			if load[station] > minutes[orderID] {
+				minutes[orderID] = load[station]
+			}
+		}
+	}
+
+	return minutes
+}
+
+// Minutos que le faltan a un item; a los que ya están en preparación se les
+// descuenta el tiempo transcurrido desde que empezaron, sin bajar de 0
+func remainingPrepMinutes(item data.KitchenQueueItem, now time.Time) int32 {
+	if item.StartedAt == nil {
+		return item.PrepMinutes
+	}
+
+	elapsed := int32(now.Sub(*item.StartedAt) / time.Minute)
+	return max(item.PrepMinutes-elapsed, 0)
+}
+
+// Clave de la cola de una estación; 0 agrupa los items sin estación
+func stationKey(stationID *int32) int32 {
+	if stationID == nil {
+		return 0
+	}
+	return *stationID
+}
+
+func newOrderDetail(order *data.Order, items []data.OrderItem, discounts []data.OrderDiscount, taxes []data.OrderTax) *OrderDetail {
+	totals := OrderTotals{
+		OrderDiscount: order.DiscountAmount,
+		TaxMode:       order.TaxMode,
+		Tax:           order.TaxAmount,
+		ServiceCharge: order.ServiceCharge,
+		DeliveryFee:   order.DeliveryFee,
+		Total:         order.TotalAmount,
+	}
+
+	for _, item := range items {
+		totals.ItemsGross += item.UnitPrice * float64(item.Quantity)
+		totals.ItemDiscounts += item.DiscountAmount
+		totals.ItemsSubtotal += item.Subtotal
+	}
+
+	totals.ItemsGross = roundMoney(totals.ItemsGross)
+	totals.ItemDiscounts = roundMoney(totals.ItemDiscounts)
+	totals.ItemsSubtotal = roundMoney(totals.ItemsSubtotal)
+
+	return &OrderDetail{
+		Order:     order,
+		Items:     items,
+		Discounts: discounts,
+		Taxes:     taxes,
+		Totals:    totals,
+	}
+}
+
+// Valida los datos requeridos según el tipo de orden
+func validateOrderType(input *CreateOrderInput) error {
+	if input.PartySize < 0 {
+		return errors.New("party size cannot be negative")
+	}
+
+	if input.ScheduledFor != nil {
+		if !input.ScheduledFor.After(time.Now()) {
+			return errors.New("scheduled time must be in the future")
+		}
+		// La mesa se asigna cuando llega el cliente, no al reservar el pedido
+		if input.TableID != nil {
+			return errors.New("scheduled orders cannot be assigned to a table")
+		}
+		if input.OrderType == data.OrderTypeTakeaway && input.PickupTime == nil {
+			input.PickupTime = input.ScheduledFor
+		}
+	}
+
+	switch input.OrderType {
+	case data.OrderTypeDineIn:
+		if input.DeliveryFee != 0 {
+			return errors.New("delivery fee only applies to delivery orders")
+		}
+	case data.OrderTypeTakeaway:
+		if input.TableID != nil {
+			return errors.New("takeaway orders cannot be assigned to a table")
+		}
+		if input.DeliveryFee != 0 {
+			return errors.New("delivery fee only applies to delivery orders")
+		}
+		if input.PickupTime != nil && input.PickupTime.Before(time.Now()) {
+			return errors.New("pickup time cannot be in the past")
+		}
+	case data.OrderTypeDelivery:
+		if input.TableID != nil {
+			return errors.New("delivery orders cannot be assigned to a table")
+		}
+		input.CustomerAddress = strings.TrimSpace(input.CustomerAddress)
+		input.CustomerPhone = strings.TrimSpace(input.CustomerPhone)
+		if input.CustomerAddress == "" || input.CustomerPhone == "" {
+			return errors.New("delivery orders require customer address and phone")
+		}
+		if input.DeliveryFee < 0 {
+			return errors.New("delivery fee cannot be negative")
+		}
+	default:
+		return errors.New("invalid order type")
+	}
+
+	return nil
+}
+
+func isValidOrderType(orderType string) bool {
+	switch orderType {
+	case data.OrderTypeDineIn, data.OrderTypeTakeaway, data.OrderTypeDelivery:
+		return true
+	}
+	return false
+}
+
+func isOpenStatus(status string) bool {
+	switch status {
+	case data.OrderStatusClosed, data.OrderStatusCancelled, data.OrderStatusMerged:
+		return false
+	}
+	return true
+}
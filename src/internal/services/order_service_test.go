@@ -0,0 +1,109 @@
+package services
+
+import (
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestEstimateQueueMinutes(t *testing.T) {
+    now := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+    grill, bar := int32(1), int32(2)
+    startedAgo := func(d time.Duration) *time.Time {
+        started := now.Add(-d)
+        return &started
+    }
+
+    tests := []struct {
+        name  string
+        queue []data.KitchenQueueItem
+        want  map[int32]int32
+    }{
+        {
+            name:  "empty queue",
+            queue: nil,
+            want:  map[int32]int32{},
+        },
+        {
+            name: "order waits for its busiest station",
+            queue: []data.KitchenQueueItem{
+                {OrderID: 1, StationID: &grill, PrepMinutes: 10},
+                {OrderID: 1, StationID: &bar, PrepMinutes: 5},
+            },
+            want: map[int32]int32{1: 10},
+        },
+        {
+            name: "later orders queue behind earlier ones",
+            queue: []data.KitchenQueueItem{
+                {OrderID: 1, StationID: &grill, PrepMinutes: 10},
+                {OrderID: 2, StationID: &grill, PrepMinutes: 5},
+            },
+            want: map[int32]int32{1: 10, 2: 15},
+        },
+        {
+            name: "stations work in parallel",
+            queue: []data.KitchenQueueItem{
+                {OrderID: 1, StationID: &grill, PrepMinutes: 10},
+                {OrderID: 2, StationID: &bar, PrepMinutes: 5},
+            },
+            want: map[int32]int32{1: 10, 2: 5},
+        },
+        {
+            name: "items without station share a queue",
+            queue: []data.KitchenQueueItem{
+                {OrderID: 1, PrepMinutes: 7},
+                {OrderID: 2, PrepMinutes: 3},
+            },
+            want: map[int32]int32{1: 7, 2: 10},
+        },
+        {
+            name: "time already spent preparing is subtracted",
+            queue: []data.KitchenQueueItem{
+                {OrderID: 1, StationID: &grill, PrepMinutes: 10, StartedAt: startedAgo(4*time.Minute + 30*time.Second)},
+                {OrderID: 2, StationID: &grill, PrepMinutes: 5},
+            },
+            want: map[int32]int32{1: 6, 2: 11},
+        },
+        {
+            name: "overdue items count as done",
+            queue: []data.KitchenQueueItem{
+                {OrderID: 1, StationID: &grill, PrepMinutes: 10, StartedAt: startedAgo(15 * time.Minute)},
+                {OrderID: 2, StationID: &grill, PrepMinutes: 5},
+            },
+            want: map[int32]int32{2: 5},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := estimateQueueMinutes(tt.queue, now)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("estimateQueueMinutes() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestServiceChargeAppliesAbovePartySize(t *testing.T) {
+    s := &OrderService{settings: OrderSettings{ServiceChargeRate: 10, ServiceChargePartySize: 6}}
+
+    tests := []struct {
+        orderType string
+        partySize int32
+        want      float64
+    }{
+        {data.OrderTypeDineIn, 5, 0},
+        {data.OrderTypeDineIn, 6, 0},
+        {data.OrderTypeDineIn, 7, 10},
+        {data.OrderTypeTakeaway, 8, 0},
+    }
+
+    for _, tt := range tests {
+        order := s.newOrder(CreateOrderInput{OrderType: tt.orderType, PartySize: tt.partySize})
+        if order.ServiceChargeRate != tt.want {
+            t.Errorf("%s party of %d: service charge rate = %v, want %v", tt.orderType, tt.partySize, order.ServiceChargeRate, tt.want)
+        }
+    }
+}
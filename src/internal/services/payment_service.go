@@ -0,0 +1,257 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type PaymentService struct {
+    paymentRepo  *repository.PaymentRepository
+    splitRepo    *repository.SplitRepository
+    orderService *OrderService
+    shiftService *ShiftService
+}
+
+func NewPaymentService(paymentRepo *repository.PaymentRepository, splitRepo *repository.SplitRepository, orderService *OrderService, shiftService *ShiftService) *PaymentService {
+    return &PaymentService{
+        paymentRepo:  paymentRepo,
+        splitRepo:    splitRepo,
+        orderService: orderService,
+        shiftService: shiftService,
+    }
+}
+
+// Un medio de pago dentro de un cobro (un cobro puede mezclar varios).
+// La propina se indica como monto (Tip) o como porcentaje del monto (TipPercentage).
+type TenderInput struct {
+    Method        string  `json:"method"`
+    Amount        float64 `json:"amount"`
+    Tendered      float64 `json:"tendered,omitempty"`
+    Reference     string  `json:"reference,omitempty"`
+    Tip           float64 `json:"tip,omitempty"`
+    TipPercentage float64 `json:"tip_percentage,omitempty"`
+}
+
+type PaymentReceipt struct {
+    Payments    []data.Payment `json:"payments"`
+    TotalChange float64        `json:"total_change"`
+    TotalTips   float64        `json:"total_tips"`
+    *repository.PaymentResult
+}
+
+type OrderPayments struct {
+    Payments      []data.Payment `json:"payments"`
+    PaymentStatus string         `json:"payment_status"`
+    AmountPaid    float64        `json:"amount_paid"`
+    Balance       float64        `json:"balance"`
+}
+
+func (s *PaymentService) GetPayments(orderID int32) (*OrderPayments, error) {
+    detail, err := s.orderService.GetOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    payments, err := s.paymentRepo.GetByOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    var paid float64
+    for _, payment := range payments {
+        if payment.Status == data.PaymentCompleted {
+            paid += payment.Amount
+        }
+    }
+
+    return &OrderPayments{
+        Payments:      payments,
+        PaymentStatus: detail.Order.PaymentStatus,
+        AmountPaid:    roundMoney(paid),
+        Balance:       roundMoney(detail.Order.TotalAmount - paid),
+    }, nil
+}
+
+// Registra el cobro de una orden (o de una subcuenta) con uno o varios medios de pago.
+// Las propinas se asignan a tipStaffID o, si no se indica, al usuario que cobra.
+func (s *PaymentService) RegisterPayments(orderID int32, splitID *int32, tenders []TenderInput, tipStaffID *int32, userID int32) (*PaymentReceipt, error) {
+    if len(tenders) == 0 {
+        return nil, errors.New("at least one tender is required")
+    }
+
+    // Todo cobro queda asociado al turno de caja abierto del usuario
+    shift, err := s.shiftService.GetCurrentShift(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    splits, err := s.splitRepo.GetByOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(splits) > 0 && splitID == nil {
+        return nil, errors.New("order is split, split_id is required")
+    }
+
+    if len(splits) == 0 && splitID != nil {
+        return nil, errors.New("split not found")
+    }
+
+    if tipStaffID == nil {
+        tipStaffID = &userID
+    }
+
+    payments := make([]data.Payment, 0, len(tenders))
+    var totalChange, totalTips float64
+
+    for _, tender := range tenders {
+        payment, err := buildPayment(tender)
+        if err != nil {
+            return nil, err
+        }
+
+        payment.SplitID = splitID
+        payment.ShiftID = &shift.ID
+        payment.UserID = userID
+        if payment.TipAmount > 0 {
+            payment.TipStaffID = tipStaffID
+        }
+        totalChange += payment.Change
+        totalTips += payment.TipAmount
+        payments = append(payments, payment)
+    }
+
+    result, err := s.paymentRepo.CreateBatch(orderID, payments)
+    if err != nil {
+        return nil, err
+    }
+
+    return &PaymentReceipt{
+        Payments:      payments,
+        TotalChange:   roundMoney(totalChange),
+        TotalTips:     roundMoney(totalTips),
+        PaymentResult: result,
+    }, nil
+}
+
+func (s *PaymentService) ReversePayment(paymentID int32, reason string, userID int32) (*repository.PaymentResult, error) {
+    reason = strings.TrimSpace(reason)
+    if reason == "" {
+        return nil, errors.New("reversal reason is required")
+    }
+
+    payment, err := s.paymentRepo.GetByID(paymentID)
+    if err != nil {
+        return nil, err
+    }
+
+    if payment == nil {
+        return nil, errors.New("payment not found")
+    }
+
+    if payment.Status == data.PaymentReversed {
+        return nil, errors.New("payment is already reversed")
+    }
+
+    // Si el turno original ya cerró, el efectivo sale del turno actual del usuario
+    var cashOut *data.CashMovement
+    if payment.Method == data.PaymentMethodCash {
+        originalOpen := false
+        if payment.ShiftID != nil {
+            original, err := s.shiftService.GetShift(*payment.ShiftID)
+            if err != nil {
+                return nil, err
+            }
+            originalOpen = original.Status == data.ShiftStatusOpen
+        }
+
+        if !originalOpen {
+            current, err := s.shiftService.GetCurrentShift(userID)
+            if err != nil {
+                return nil, err
+            }
+
+            cashOut = &data.CashMovement{
+                ShiftID: current.ID,
+                UserID:  userID,
+                Type:    data.CashMovementOut,
+                Amount:  roundMoney(payment.Amount + payment.TipAmount),
+                Reason:  fmt.Sprintf("Reversal of payment #%d: %s", payment.ID, reason),
+            }
+        }
+    }
+
+    return s.paymentRepo.Reverse(paymentID, reason, cashOut)
+}
+
+// Valida el medio de pago y calcula propina y cambio para efectivo
+func buildPayment(tender TenderInput) (data.Payment, error) {
+    amount := roundMoney(tender.Amount)
+    if amount <= 0 {
+        return data.Payment{}, errors.New("payment amount must be greater than zero")
+    }
+
+    tip, err := tipAmount(tender, amount)
+    if err != nil {
+        return data.Payment{}, err
+    }
+
+    // Lo que entrega el cliente cubre el pago más la propina
+    charged := roundMoney(amount + tip)
+    payment := data.Payment{
+        Method:    tender.Method,
+        Amount:    amount,
+        Tendered:  charged,
+        Reference: strings.TrimSpace(tender.Reference),
+        TipAmount: tip,
+    }
+
+    switch tender.Method {
+    case data.PaymentMethodCash:
+        if tender.Tendered != 0 {
+            tendered := roundMoney(tender.Tendered)
+            if tendered < charged {
+                return data.Payment{}, errors.New("cash tendered is less than the amount")
+            }
+            payment.Tendered = tendered
+            payment.Change = roundMoney(tendered - charged)
+        }
+    case data.PaymentMethodCard, data.PaymentMethodTransfer:
+        if tender.Tendered != 0 && roundMoney(tender.Tendered) != charged {
+            return data.Payment{}, errors.New("only cash payments can return change")
+        }
+    default:
+        return data.Payment{}, errors.New("invalid payment method")
+    }
+
+    return payment, nil
+}
+
+func tipAmount(tender TenderInput, amount float64) (float64, error) {
+    if tender.Tip < 0 || tender.TipPercentage < 0 {
+        return 0, errors.New("tip cannot be negative")
+    }
+
+    if tender.Tip > 0 && tender.TipPercentage > 0 {
+        return 0, errors.New("tip must be an amount or a percentage, not both")
+    }
+
+    if tender.TipPercentage > 0 {
+        if tender.TipPercentage > 100 {
+            return 0, errors.New("tip percentage cannot exceed 100")
+        }
+        return roundMoney(amount * tender.TipPercentage / 100), nil
+    }
+
+    return roundMoney(tender.Tip), nil
+}
+
+func (s *PaymentService) GetTipsByStaff(from, to *time.Time) ([]data.TipSummary, error) {
+    return s.paymentRepo.GetTipsByStaff(from, to)
+}
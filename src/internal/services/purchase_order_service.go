@@ -0,0 +1,286 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type PurchaseOrderService struct {
+    purchaseRepo      *repository.PurchaseOrderRepository
+    supplierService   *SupplierService
+    ingredientService *IngredientService
+}
+
+func NewPurchaseOrderService(purchaseRepo *repository.PurchaseOrderRepository, supplierService *SupplierService, ingredientService *IngredientService) *PurchaseOrderService {
+    return &PurchaseOrderService{
+        purchaseRepo:      purchaseRepo,
+        supplierService:   supplierService,
+        ingredientService: ingredientService,
+    }
+}
+
+// Quantity y UnitCost van en Unit (código del catálogo o presentación del
+// ingrediente); sin Unit se usa la unidad del ingrediente
+type PurchaseOrderItemInput struct {
+    IngredientID int32   `json:"ingredient_id"`
+    Quantity     float64 `json:"quantity"`
+    Unit         string  `json:"unit"`
+    UnitCost     float64 `json:"unit_cost"`
+}
+
+type PurchaseOrderInput struct {
+    SupplierID int32                    `json:"supplier_id"`
+    Notes      string                   `json:"notes"`
+    ExpectedAt *time.Time               `json:"expected_at,omitempty"`
+    Items      []PurchaseOrderItemInput `json:"items"`
+}
+
+type ReceiveLineInput struct {
+    ItemID   int32   `json:"item_id"`
+    Quantity float64 `json:"quantity"`
+    // Unidad de Quantity y UnitCost; sin ella se usa la unidad en que se pidió
+    Unit string `json:"unit"`
+    // Costo facturado por unidad; sin él se toma el acordado
+    UnitCost *float64 `json:"unit_cost,omitempty"`
+    // Lote y vencimiento de la mercancía recibida
+    LotNumber string     `json:"lot_number"`
+    ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ReceivePurchaseInput struct {
+    Lines []ReceiveLineInput `json:"lines"`
+    // Da la orden por recibida aunque falte mercancía
+    Close bool   `json:"close"`
+    Notes string `json:"notes"`
+}
+
+type PurchaseOrderDetail struct {
+    Order         *data.PurchaseOrder        `json:"order"`
+    Items         []data.PurchaseOrderItem   `json:"items"`
+    Discrepancies []data.PurchaseDiscrepancy `json:"discrepancies"`
+}
+
+func (s *PurchaseOrderService) ListPurchaseOrders(filter repository.PurchaseOrderFilter) ([]data.PurchaseOrder, error) {
+    switch filter.Status {
+    case "", data.PurchaseOrderDraft, data.PurchaseOrderSent, data.PurchaseOrderPartiallyReceived,
+        data.PurchaseOrderReceived, data.PurchaseOrderCancelled:
+    default:
+        return nil, fmt.Errorf("invalid purchase order status %s", filter.Status)
+    }
+
+    return s.purchaseRepo.Find(filter)
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(id int32) (*PurchaseOrderDetail, error) {
+    order, err := s.purchaseRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if order == nil {
+        return nil, errors.New("purchase order not found")
+    }
+
+    items, err := s.purchaseRepo.GetItems(id)
+    if err != nil {
+        return nil, err
+    }
+
+    discrepancies, err := s.purchaseRepo.GetDiscrepancies(id)
+    if err != nil {
+        return nil, err
+    }
+
+    return &PurchaseOrderDetail{
+        Order:         order,
+        Items:         items,
+        Discrepancies: discrepancies,
+    }, nil
+}
+
+// Crea la orden de compra en borrador
+func (s *PurchaseOrderService) CreatePurchaseOrder(input PurchaseOrderInput, userID int32) (*PurchaseOrderDetail, error) {
+    order, items, err := s.buildPurchaseOrder(input)
+    if err != nil {
+        return nil, err
+    }
+    order.CreatedBy = &userID
+
+    if err := s.purchaseRepo.Create(order, items); err != nil {
+        return nil, err
+    }
+
+    return s.GetPurchaseOrder(order.ID)
+}
+
+// Reemplaza proveedor, notas e items de un borrador
+func (s *PurchaseOrderService) UpdatePurchaseOrder(id int32, input PurchaseOrderInput) (*PurchaseOrderDetail, error) {
+    order, items, err := s.buildPurchaseOrder(input)
+    if err != nil {
+        return nil, err
+    }
+    order.ID = id
+
+    if err := s.purchaseRepo.UpdateDraft(order, items); err != nil {
+        return nil, err
+    }
+
+    return s.GetPurchaseOrder(id)
+}
+
+// Marca el borrador como enviado al proveedor; desde aquí ya no se edita
+func (s *PurchaseOrderService) SendPurchaseOrder(id int32) (*PurchaseOrderDetail, error) {
+    if err := s.purchaseRepo.UpdateStatus(id, []string{data.PurchaseOrderDraft}, data.PurchaseOrderSent); err != nil {
+        return nil, err
+    }
+
+    return s.GetPurchaseOrder(id)
+}
+
+// Solo se cancelan órdenes de las que no se ha recibido nada
+func (s *PurchaseOrderService) CancelPurchaseOrder(id int32) (*PurchaseOrderDetail, error) {
+    from := []string{data.PurchaseOrderDraft, data.PurchaseOrderSent}
+    if err := s.purchaseRepo.UpdateStatus(id, from, data.PurchaseOrderCancelled); err != nil {
+        return nil, err
+    }
+
+    return s.GetPurchaseOrder(id)
+}
+
+// Registra una recepción (total o parcial) de la orden de compra
+func (s *PurchaseOrderService) ReceivePurchaseOrder(id, userID int32, input ReceivePurchaseInput) (*PurchaseOrderDetail, error) {
+    detail, err := s.GetPurchaseOrder(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(input.Lines) == 0 && !input.Close {
+        return nil, errors.New("at least one received line is required")
+    }
+
+    items := make(map[int32]data.PurchaseOrderItem, len(detail.Items))
+    for _, item := range detail.Items {
+        items[item.ID] = item
+    }
+
+    seen := make(map[int32]bool, len(input.Lines))
+    lines := make([]repository.ReceiptLine, 0, len(input.Lines))
+    for _, line := range input.Lines {
+        item, ok := items[line.ItemID]
+        if !ok {
+            return nil, fmt.Errorf("purchase order item %d not found", line.ItemID)
+        }
+
+        if seen[line.ItemID] {
+            return nil, fmt.Errorf("purchase order item %d is repeated", line.ItemID)
+        }
+        seen[line.ItemID] = true
+
+        if line.Quantity <= 0 {
+            return nil, errors.New("received quantity must be greater than zero")
+        }
+
+        unit := strings.TrimSpace(line.Unit)
+        if unit == "" {
+            unit = item.PurchaseUnit
+        }
+
+        quantity, err := s.ingredientService.ToIngredientUnit(item.IngredientID, line.Quantity, unit)
+        if err != nil {
+            return nil, err
+        }
+
+        cost := item.UnitCost
+        if line.UnitCost != nil {
+            if *line.UnitCost < 0 {
+                return nil, errors.New("unit cost cannot be negative")
+            }
+            // El costo facturado viene por unidad de compra; se lleva a la del ingrediente
+            cost = *line.UnitCost * line.Quantity / quantity
+        }
+
+        lines = append(lines, repository.ReceiptLine{
+            ItemID:    line.ItemID,
+            Quantity:  quantity,
+            UnitCost:  cost,
+            LotNumber: strings.TrimSpace(line.LotNumber),
+            ExpiresAt: line.ExpiresAt,
+        })
+    }
+
+    if err := s.purchaseRepo.Receive(id, userID, lines, input.Close, strings.TrimSpace(input.Notes)); err != nil {
+        return nil, err
+    }
+
+    return s.GetPurchaseOrder(id)
+}
+
+func (s *PurchaseOrderService) buildPurchaseOrder(input PurchaseOrderInput) (*data.PurchaseOrder, []data.PurchaseOrderItem, error) {
+    supplier, err := s.supplierService.GetSupplier(input.SupplierID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if !supplier.IsActive {
+        return nil, nil, errors.New("supplier is not active")
+    }
+
+    if len(input.Items) == 0 {
+        return nil, nil, errors.New("at least one item is required")
+    }
+
+    seen := make(map[int32]bool, len(input.Items))
+    items := make([]data.PurchaseOrderItem, 0, len(input.Items))
+    for _, item := range input.Items {
+        if item.Quantity <= 0 {
+            return nil, nil, errors.New("quantity must be greater than zero")
+        }
+
+        if item.UnitCost < 0 {
+            return nil, nil, errors.New("unit cost cannot be negative")
+        }
+
+        if seen[item.IngredientID] {
+            return nil, nil, fmt.Errorf("ingredient %d is repeated in the purchase order", item.IngredientID)
+        }
+        seen[item.IngredientID] = true
+
+        ingredient, err := s.ingredientService.GetIngredient(item.IngredientID)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        unit := strings.TrimSpace(item.Unit)
+        if unit == "" {
+            unit = ingredient.Unit
+        }
+
+        quantity, err := s.ingredientService.ToIngredientUnit(ingredient.ID, item.Quantity, unit)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        // Cantidad y costo se guardan en la unidad del ingrediente para que la
+        // recepción y el costo promedio no dependan de cómo se pidió
+        items = append(items, data.PurchaseOrderItem{
+            IngredientID:     item.IngredientID,
+            QuantityOrdered:  quantity,
+            UnitCost:         item.UnitCost * item.Quantity / quantity,
+            PurchaseQuantity: item.Quantity,
+            PurchaseUnit:     unit,
+        })
+    }
+
+    order := &data.PurchaseOrder{
+        SupplierID: supplier.ID,
+        Notes:      strings.TrimSpace(input.Notes),
+        ExpectedAt: input.ExpectedAt,
+    }
+
+    return order, items, nil
+}
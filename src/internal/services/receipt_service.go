@@ -0,0 +1,575 @@
+package services
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "html/template"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/pkgzx/liliApi/src/pkg/config"
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Formatos de recibo soportados
+const (
+    ReceiptFormatText   = "text"
+    ReceiptFormatHTML   = "html"
+    ReceiptFormatESCPOS = "escpos"
+)
+
+// Anchos de papel térmico soportados (columnas de fuente A en 58/80 mm)
+var receiptWidths = map[int]bool{42: true, 48: true}
+
+type ReceiptService struct {
+    orderService *OrderService
+    paymentRepo  *repository.PaymentRepository
+    productRepo  *repository.ProductRepository
+    tableRepo    *repository.TableRepository
+    settings     config.ReceiptConfig
+}
+
+func NewReceiptService(orderService *OrderService, paymentRepo *repository.PaymentRepository, productRepo *repository.ProductRepository, tableRepo *repository.TableRepository, settings config.ReceiptConfig) *ReceiptService {
+    return &ReceiptService{
+        orderService: orderService,
+        paymentRepo:  paymentRepo,
+        productRepo:  productRepo,
+        tableRepo:    tableRepo,
+        settings:     settings,
+    }
+}
+
+type ReceiptLine struct {
+    Quantity  int32
+    Name      string
+    UnitPrice float64
+    Discount  float64
+    Amount    float64
+}
+
+// Contenido del recibo, independiente del formato de salida
+type Receipt struct {
+    Header      config.ReceiptConfig
+    Title       string
+    OrderNumber string
+    Date        time.Time
+    OrderType   string
+    TableNumber *int32
+    Lines       []ReceiptLine
+    Totals      OrderTotals
+    Discounts   []data.OrderDiscount
+    Taxes       []data.OrderTax
+    Payments    []data.Payment
+    Tips        float64
+    Change      float64
+    Balance     float64
+}
+
+// Resultado listo para enviar al cliente o a la impresora
+type RenderedReceipt struct {
+    ContentType string
+    Body        []byte
+}
+
+func (s *ReceiptService) RenderReceipt(orderID int32, format string, width int) (*RenderedReceipt, error) {
+    if format == "" {
+        format = ReceiptFormatText
+    }
+
+    if width == 0 {
+        width = 42
+    }
+
+    if !receiptWidths[width] {
+        return nil, errors.New("receipt width must be 42 or 48 columns")
+    }
+
+    receipt, err := s.BuildReceipt(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    switch format {
+    case ReceiptFormatText:
+        return &RenderedReceipt{
+            ContentType: "text/plain; charset=utf-8",
+            Body:        []byte(renderReceiptText(receipt, width)),
+        }, nil
+    case ReceiptFormatHTML:
+        body, err := renderReceiptHTML(receipt)
+        if err != nil {
+            return nil, err
+        }
+        return &RenderedReceipt{ContentType: "text/html; charset=utf-8", Body: body}, nil
+    case ReceiptFormatESCPOS:
+        return &RenderedReceipt{
+            ContentType: "application/octet-stream",
+            Body:        renderReceiptESCPOS(receipt, width),
+        }, nil
+    default:
+        return nil, errors.New("invalid receipt format")
+    }
+}
+
+// Reúne la orden, sus items con nombre de producto y los pagos vigentes
+func (s *ReceiptService) BuildReceipt(orderID int32) (*Receipt, error) {
+    detail, err := s.orderService.GetOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    order := detail.Order
+    if order.Status == data.OrderStatusCancelled || order.Status == data.OrderStatusMerged {
+        return nil, errors.New("order has no receipt")
+    }
+
+    receipt := &Receipt{
+        Header:      s.settings,
+        Title:       "PRE-CUENTA",
+        OrderNumber: order.OrderNumber,
+        Date:        order.CreatedAt,
+        OrderType:   order.OrderType,
+        Totals:      detail.Totals,
+        Discounts:   detail.Discounts,
+        Taxes:       detail.Taxes,
+    }
+
+    if order.PaymentStatus == data.PaymentStatusPaid {
+        receipt.Title = "RECIBO DE PAGO"
+    }
+
+    if order.TableID != nil {
+        table, err := s.tableRepo.GetByID(*order.TableID)
+        if err != nil {
+            return nil, err
+        }
+        if table != nil {
+            receipt.TableNumber = &table.Number
+        }
+    }
+
+    names := make(map[int32]string)
+    for _, item := range detail.Items {
+        if _, ok := names[item.ProductID]; !ok {
+            product, err := s.productRepo.GetByID(item.ProductID)
+            if err != nil {
+                return nil, err
+            }
+            names[item.ProductID] = fmt.Sprintf("Producto #%d", item.ProductID)
+            if product != nil {
+                names[item.ProductID] = product.Name
+            }
+        }
+
+        receipt.Lines = append(receipt.Lines, ReceiptLine{
+            Quantity:  item.Quantity,
+            Name:      names[item.ProductID],
+            UnitPrice: item.UnitPrice,
+            Discount:  item.DiscountAmount,
+            Amount:    item.Subtotal,
+        })
+    }
+
+    payments, err := s.paymentRepo.GetByOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    var paid float64
+    for _, payment := range payments {
+        if payment.Status != data.PaymentCompleted {
+            continue
+        }
+        receipt.Payments = append(receipt.Payments, payment)
+        receipt.Tips += payment.TipAmount
+        receipt.Change += payment.Change
+        paid += payment.Amount
+    }
+
+    receipt.Tips = roundMoney(receipt.Tips)
+    receipt.Change = roundMoney(receipt.Change)
+    receipt.Balance = roundMoney(order.TotalAmount - paid)
+
+    return receipt, nil
+}
+
+// ---- Presentación ----
+
+const (
+    alignLeft = iota
+    alignCenter
+)
+
+// Renglón del recibo ya ajustado al ancho del papel
+type receiptRow struct {
+    text  string
+    align int
+    bold  bool
+    large bool
+}
+
+func receiptRows(r *Receipt, width int) []receiptRow {
+    rows := make([]receiptRow, 0, 32)
+    center := func(text string, bold, large bool) {
+        for _, line := range wrapText(text, width) {
+            rows = append(rows, receiptRow{text: line, align: alignCenter, bold: bold, large: large})
+        }
+    }
+    left := func(text string) {
+        rows = append(rows, receiptRow{text: text})
+    }
+    pair := func(label string, amount float64, bold bool) {
+        rows = append(rows, receiptRow{text: padBetween(label, formatMoney(amount), width), bold: bold})
+    }
+    separator := func() {
+        left(strings.Repeat("-", width))
+    }
+
+    center(r.Header.BusinessName, true, true)
+    if r.Header.TaxID != "" {
+        center("NIT: "+r.Header.TaxID, false, false)
+    }
+    if r.Header.Address != "" {
+        center(r.Header.Address, false, false)
+    }
+    if r.Header.Phone != "" {
+        center("Tel: "+r.Header.Phone, false, false)
+    }
+    separator()
+
+    center(r.Title, true, false)
+    left(padBetween("Orden: "+r.OrderNumber, r.Date.Format("2006-01-02 15:04"), width))
+    service := orderTypeLabel(r.OrderType)
+    if r.TableNumber != nil {
+        service = fmt.Sprintf("%s %d", service, *r.TableNumber)
+    }
+    left(service)
+    separator()
+
+    for _, line := range r.Lines {
+        label := fmt.Sprintf("%dx %s", line.Quantity, line.Name)
+        amount := formatMoney(line.Amount + line.Discount)
+        wrapped := wrapText(label, width-utf8.RuneCountInString(amount)-1)
+        left(padBetween(wrapped[0], amount, width))
+        for _, extra := range wrapped[1:] {
+            left("   " + extra)
+        }
+        if line.Quantity > 1 {
+            left(fmt.Sprintf("   @ %s", formatMoney(line.UnitPrice)))
+        }
+        if line.Discount > 0 {
+            left(padBetween("   Descuento", formatMoney(-line.Discount), width))
+        }
+    }
+    separator()
+
+    pair("Subtotal", r.Totals.ItemsSubtotal, false)
+    for _, discount := range r.Discounts {
+        if discount.OrderItemID == nil && discount.Amount > 0 {
+            pair("Desc. "+discount.Name, -discount.Amount, false)
+        }
+    }
+    if r.Totals.ServiceCharge > 0 {
+        pair("Cargo por servicio", r.Totals.ServiceCharge, false)
+    }
+    if r.Totals.DeliveryFee > 0 {
+        pair("Domicilio", r.Totals.DeliveryFee, false)
+    }
+    if r.Totals.TaxMode == data.TaxModeExclusive {
+        pair("Impuestos", r.Totals.Tax, false)
+    }
+    pair("TOTAL", r.Totals.Total, true)
+
+    if len(r.Taxes) > 0 {
+        separator()
+        left(padBetween("Impuesto", "Base      Valor", width))
+        for _, tax := range r.Taxes {
+            values := fmt.Sprintf("%s %s", formatMoney(tax.Base), formatMoney(tax.Amount))
+            left(padBetween(fmt.Sprintf("%s (%.2f%%)", tax.Name, tax.Rate), values, width))
+        }
+        if r.Totals.TaxMode == data.TaxModeInclusive {
+            left("Precios con impuestos incluidos")
+        }
+    }
+
+    if len(r.Payments) > 0 {
+        separator()
+        for _, payment := range r.Payments {
+            pair(paymentMethodLabel(payment.Method), payment.Amount, false)
+            if payment.Reference != "" {
+                left("   Ref: " + payment.Reference)
+            }
+        }
+        if r.Tips > 0 {
+            pair("Propina", r.Tips, false)
+        }
+        if r.Change > 0 {
+            pair("Cambio", r.Change, false)
+        }
+    }
+
+    if r.Balance > 0 {
+        pair("SALDO PENDIENTE", r.Balance, true)
+    }
+
+    if r.Header.Footer != "" {
+        separator()
+        center(r.Header.Footer, false, false)
+    }
+
+    return rows
+}
+
+func renderReceiptText(r *Receipt, width int) string {
+    var b strings.Builder
+    for _, row := range receiptRows(r, width) {
+        if row.align == alignCenter {
+            b.WriteString(centerText(row.text, width))
+        } else {
+            b.WriteString(row.text)
+        }
+        b.WriteByte('\n')
+    }
+    return b.String()
+}
+
+// Comandos ESC/POS usados en impresoras térmicas
+var (
+    escposInit        = []byte{0x1B, 0x40}       // ESC @
+    escposCodePage850 = []byte{0x1B, 0x74, 0x02} // ESC t 2 (PC850, acentos en español)
+    escposAlignLeft   = []byte{0x1B, 0x61, 0x00}
+    escposAlignCenter = []byte{0x1B, 0x61, 0x01}
+    escposBoldOn      = []byte{0x1B, 0x45, 0x01}
+    escposBoldOff     = []byte{0x1B, 0x45, 0x00}
+    escposDoubleOn    = []byte{0x1D, 0x21, 0x11} // GS ! doble alto y ancho
+    escposDoubleOff   = []byte{0x1D, 0x21, 0x00}
+    escposFeedAndCut  = []byte{0x1D, 0x56, 0x42, 0x03} // GS V B: avanza y corta
+)
+
+func renderReceiptESCPOS(r *Receipt, width int) []byte {
+    var b bytes.Buffer
+    b.Write(escposInit)
+    b.Write(escposCodePage850)
+
+    for _, row := range receiptRows(r, width) {
+        text := row.text
+        if row.align == alignCenter {
+            b.Write(escposAlignCenter)
+        } else {
+            b.Write(escposAlignLeft)
+        }
+        if row.bold {
+            b.Write(escposBoldOn)
+        }
+        if row.large {
+            b.Write(escposDoubleOn)
+        }
+
+        b.Write(encodeCP850(text))
+        b.WriteByte('\n')
+
+        if row.large {
+            b.Write(escposDoubleOff)
+        }
+        if row.bold {
+            b.Write(escposBoldOff)
+        }
+    }
+
+    b.Write(escposFeedAndCut)
+    return b.Bytes()
+}
+
+var receiptHTMLTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+    "money":       formatMoney,
+    "neg":         func(v float64) float64 { return -v },
+    "gross":       func(l ReceiptLine) float64 { return l.Amount + l.Discount },
+    "orderType":   orderTypeLabel,
+    "method":      paymentMethodLabel,
+    "isExclusive": func(mode string) bool { return mode == data.TaxModeExclusive },
+}).Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} {{.OrderNumber}}</title>
+<style>
+body { font-family: monospace; max-width: 380px; margin: 0 auto; }
+header, footer, h2 { text-align: center; }
+table { width: 100%; border-collapse: collapse; }
+td.amount { text-align: right; white-space: nowrap; }
+tr.total td { font-weight: bold; border-top: 1px dashed #000; }
+section { border-top: 1px dashed #000; padding: 4px 0; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Header.BusinessName}}</h1>
+{{if .Header.TaxID}}<div>NIT: {{.Header.TaxID}}</div>{{end}}
+{{if .Header.Address}}<div>{{.Header.Address}}</div>{{end}}
+{{if .Header.Phone}}<div>Tel: {{.Header.Phone}}</div>{{end}}
+</header>
+<section>
+<h2>{{.Title}}</h2>
+<div>Orden: {{.OrderNumber}} &middot; {{.Date.Format "2006-01-02 15:04"}}</div>
+<div>{{orderType .OrderType}}{{if .TableNumber}} {{.TableNumber}}{{end}}</div>
+</section>
+<section>
+<table>
+{{range .Lines}}<tr><td>{{.Quantity}}x {{.Name}}{{if gt .Quantity 1}} <small>@ {{money .UnitPrice}}</small>{{end}}</td><td class="amount">{{money (gross .)}}</td></tr>
+{{if gt .Discount 0.0}}<tr><td>&nbsp;&nbsp;Descuento</td><td class="amount">{{money (neg .Discount)}}</td></tr>
+{{end}}{{end}}
+</table>
+</section>
+<section>
+<table>
+<tr><td>Subtotal</td><td class="amount">{{money .Totals.ItemsSubtotal}}</td></tr>
+{{range .Discounts}}{{if and (not .OrderItemID) (gt .Amount 0.0)}}<tr><td>Desc. {{.Name}}</td><td class="amount">{{money (neg .Amount)}}</td></tr>
+{{end}}{{end}}{{if gt .Totals.ServiceCharge 0.0}}<tr><td>Cargo por servicio</td><td class="amount">{{money .Totals.ServiceCharge}}</td></tr>
+{{end}}{{if gt .Totals.DeliveryFee 0.0}}<tr><td>Domicilio</td><td class="amount">{{money .Totals.DeliveryFee}}</td></tr>
+{{end}}{{if isExclusive .Totals.TaxMode}}<tr><td>Impuestos</td><td class="amount">{{money .Totals.Tax}}</td></tr>
+{{end}}<tr class="total"><td>TOTAL</td><td class="amount">{{money .Totals.Total}}</td></tr>
+</table>
+</section>
+{{if .Taxes}}<section>
+<table>
+<tr><th align="left">Impuesto</th><th align="right">Base</th><th align="right">Valor</th></tr>
+{{range .Taxes}}<tr><td>{{.Name}} ({{printf "%.2f" .Rate}}%)</td><td class="amount">{{money .Base}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}</table>
+{{if not (isExclusive .Totals.TaxMode)}}<div>Precios con impuestos incluidos</div>{{end}}
+</section>
+{{end}}{{if .Payments}}<section>
+<table>
+{{range .Payments}}<tr><td>{{method .Method}}{{if .Reference}} <small>Ref: {{.Reference}}</small>{{end}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}{{if gt .Tips 0.0}}<tr><td>Propina</td><td class="amount">{{money .Tips}}</td></tr>
+{{end}}{{if gt .Change 0.0}}<tr><td>Cambio</td><td class="amount">{{money .Change}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if gt .Balance 0.0}}<section><strong>SALDO PENDIENTE: {{money .Balance}}</strong></section>
+{{end}}{{if .Header.Footer}}<footer>{{.Header.Footer}}</footer>
+{{end}}</body>
+</html>
+`))
+
+func renderReceiptHTML(r *Receipt) ([]byte, error) {
+    var b bytes.Buffer
+    if err := receiptHTMLTemplate.Execute(&b, r); err != nil {
+        return nil, fmt.Errorf("error rendering receipt: %w", err)
+    }
+    return b.Bytes(), nil
+}
+
+func formatMoney(amount float64) string {
+    if amount < 0 {
+        return fmt.Sprintf("-$%.2f", -amount)
+    }
+    return fmt.Sprintf("$%.2f", amount)
+}
+
+func orderTypeLabel(orderType string) string {
+    switch orderType {
+    case data.OrderTypeDineIn:
+        return "Mesa"
+    case data.OrderTypeTakeaway:
+        return "Para llevar"
+    case data.OrderTypeDelivery:
+        return "Domicilio"
+    }
+    return orderType
+}
+
+func paymentMethodLabel(method string) string {
+    switch method {
+    case data.PaymentMethodCash:
+        return "Efectivo"
+    case data.PaymentMethodCard:
+        return "Tarjeta"
+    case data.PaymentMethodTransfer:
+        return "Transferencia"
+    }
+    return method
+}
+
+// Texto a la izquierda y a la derecha separados por espacios hasta completar el ancho
+func padBetween(left, right string, width int) string {
+    space := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+    if space < 1 {
+        runes := []rune(left)
+        keep := len(runes) + space - 1
+        if keep < 0 {
+            keep = 0
+        }
+        left = string(runes[:keep])
+        space = 1
+    }
+    return left + strings.Repeat(" ", space) + right
+}
+
+func centerText(text string, width int) string {
+    space := (width - utf8.RuneCountInString(text)) / 2
+    if space <= 0 {
+        return text
+    }
+    return strings.Repeat(" ", space) + text
+}
+
+// Parte el texto en renglones de como máximo width columnas, cortando por palabras
+func wrapText(text string, width int) []string {
+    words := strings.Fields(text)
+    if len(words) == 0 {
+        return []string{""}
+    }
+
+    lines := make([]string, 0, 1)
+    current := ""
+    for _, word := range words {
+        for utf8.RuneCountInString(word) > width {
+            runes := []rune(word)
+            if current != "" {
+                lines = append(lines, current)
+                current = ""
+            }
+            lines = append(lines, string(runes[:width]))
+            word = string(runes[width:])
+        }
+
+        switch {
+        case current == "":
+            current = word
+        case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+            current += " " + word
+        default:
+            lines = append(lines, current)
+            current = word
+        }
+    }
+    if current != "" {
+        lines = append(lines, current)
+    }
+
+    return lines
+}
+
+// Caracteres del español en la página de códigos PC850; el resto se reemplaza por '?'
+var cp850 = map[rune]byte{
+    'á': 0xA0, 'é': 0x82, 'í': 0xA1, 'ó': 0xA2, 'ú': 0xA3, 'ñ': 0xA4, 'Ñ': 0xA5,
+    'ü': 0x81, 'Ü': 0x9A, 'Á': 0xB5, 'É': 0x90, 'Í': 0xD6, 'Ó': 0xE0, 'Ú': 0xE9,
+    '¿': 0xA8, '¡': 0xAD, '°': 0xF8,
+}
+
+func encodeCP850(text string) []byte {
+    out := make([]byte, 0, len(text))
+    for _, r := range text {
+        switch {
+        case r < 0x80:
+            out = append(out, byte(r))
+        case cp850[r] != 0:
+            out = append(out, cp850[r])
+        default:
+            out = append(out, '?')
+        }
+    }
+    return out
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestWrapText(t *testing.T) {
+    tests := []struct {
+        name  string
+        text  string
+        width int
+        want  []string
+    }{
+        {"empty text", "", 10, []string{""}},
+        {"only spaces", "   ", 10, []string{""}},
+        {"fits in one line", "Hola mundo", 20, []string{"Hola mundo"}},
+        {"wraps by words", "Tacos al pastor con piña", 10, []string{"Tacos al", "pastor con", "piña"}},
+        {"collapses repeated spaces", "Sin   cebolla", 20, []string{"Sin cebolla"}},
+        {"cuts words longer than the width", "Supercalifragilistico", 8, []string{"Supercal", "ifragili", "stico"}},
+        {"long word after a short one", "Te Supercalifragilistico", 8, []string{"Te", "Supercal", "ifragili", "stico"}},
+        {"counts runes, not bytes", "ñañañaña ño", 8, []string{"ñañañaña", "ño"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := wrapText(tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestPadBetween(t *testing.T) {
+    tests := []struct {
+        name  string
+        left  string
+        right string
+        width int
+        want  string
+    }{
+        {"fills the width", "Total", "$10.00", 20, "Total         $10.00"},
+        {"single space when exact", "ab", "cd", 5, "ab cd"},
+        {"truncates the left text", "Hamburguesa doble", "$120.00", 16, "Hamburgu $120.00"},
+        {"right text wider than the line", "Item", "$1234567", 6, " $1234567"},
+        {"counts runes, not bytes", "Piña", "$5", 8, "Piña  $5"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := padBetween(tt.left, tt.right, tt.width); got != tt.want {
+                t.Errorf("padBetween(%q, %q, %d) = %q, want %q", tt.left, tt.right, tt.width, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type ShiftService struct {
+    shiftRepo *repository.ShiftRepository
+}
+
+func NewShiftService(shiftRepo *repository.ShiftRepository) *ShiftService {
+    return &ShiftService{
+        shiftRepo: shiftRepo,
+    }
+}
+
+func (s *ShiftService) ListShifts(status string) ([]data.CashShift, error) {
+    if status != "" && status != data.ShiftStatusOpen && status != data.ShiftStatusClosed {
+        return nil, errors.New("invalid shift status")
+    }
+
+    return s.shiftRepo.GetAll(status)
+}
+
+func (s *ShiftService) GetShift(id int32) (*data.CashShift, error) {
+    shift, err := s.shiftRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if shift == nil {
+        return nil, errors.New("cash shift not found")
+    }
+
+    return shift, nil
+}
+
+// Turno abierto del cajero; los pagos no se pueden registrar sin él
+func (s *ShiftService) GetCurrentShift(userID int32) (*data.CashShift, error) {
+    shift, err := s.shiftRepo.GetOpenByUser(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if shift == nil {
+        return nil, errors.New("no open cash shift for this user")
+    }
+
+    return shift, nil
+}
+
+func (s *ShiftService) OpenShift(userID int32, register string, openingFloat float64, notes string) (*data.CashShift, error) {
+    register = strings.TrimSpace(register)
+    if register == "" {
+        return nil, errors.New("register is required")
+    }
+
+    if openingFloat < 0 {
+        return nil, errors.New("opening float cannot be negative")
+    }
+
+    current, err := s.shiftRepo.GetOpenByUser(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if current != nil {
+        return nil, errors.New("user already has an open cash shift")
+    }
+
+    current, err = s.shiftRepo.GetOpenByRegister(register)
+    if err != nil {
+        return nil, err
+    }
+
+    if current != nil {
+        return nil, errors.New("register already has an open cash shift")
+    }
+
+    shift := &data.CashShift{
+        Register:     register,
+        UserID:       userID,
+        OpeningFloat: roundMoney(openingFloat),
+        Status:       data.ShiftStatusOpen,
+        Notes:        notes,
+    }
+
+    if err := s.shiftRepo.Create(shift); err != nil {
+        return nil, err
+    }
+
+    return shift, nil
+}
+
+func (s *ShiftService) GetMovements(shiftID int32) ([]data.CashMovement, error) {
+    if _, err := s.GetShift(shiftID); err != nil {
+        return nil, err
+    }
+
+    return s.shiftRepo.GetMovements(shiftID)
+}
+
+// Solo el cajero del turno, o un administrador o gerente, puede operarlo
+func (s *ShiftService) checkShiftAccess(shiftID, userID int32, role string) error {
+    shift, err := s.GetShift(shiftID)
+    if err != nil {
+        return err
+    }
+
+    if shift.UserID != userID && !isManagerRole(role) {
+        return fmt.Errorf("%w: cash shift belongs to another user", ErrForbidden)
+    }
+
+    return nil
+}
+
+// Registra una entrada o salida de efectivo (ej. cambio, pago a proveedor)
+func (s *ShiftService) AddMovement(shiftID, userID int32, role, movementType string, amount float64, reason string) (*data.CashMovement, error) {
+    if err := s.checkShiftAccess(shiftID, userID, role); err != nil {
+        return nil, err
+    }
+
+    if movementType != data.CashMovementIn && movementType != data.CashMovementOut {
+        return nil, errors.New("movement type must be 'in' or 'out'")
+    }
+
+    if amount <= 0 {
+        return nil, errors.New("amount must be greater than zero")
+    }
+
+    reason = strings.TrimSpace(reason)
+    if reason == "" {
+        return nil, errors.New("reason is required")
+    }
+
+    if movementType == data.CashMovementOut {
+        expected, err := s.shiftRepo.GetExpectedCash(shiftID)
+        if err != nil {
+            return nil, err
+        }
+
+        if roundMoney(amount) > expected {
+            return nil, errors.New("not enough cash in the register")
+        }
+    }
+
+    movement := &data.CashMovement{
+        ShiftID: shiftID,
+        UserID:  userID,
+        Type:    movementType,
+        Amount:  roundMoney(amount),
+        Reason:  reason,
+    }
+
+    if err := s.shiftRepo.AddMovement(movement); err != nil {
+        return nil, err
+    }
+
+    return movement, nil
+}
+
+// Cierra el turno con el efectivo contado y devuelve el reporte Z
+func (s *ShiftService) CloseShift(shiftID, userID int32, role string, countedCash float64, notes string) (*data.ShiftReport, error) {
+    if err := s.checkShiftAccess(shiftID, userID, role); err != nil {
+        return nil, err
+    }
+
+    if countedCash < 0 {
+        return nil, errors.New("counted cash cannot be negative")
+    }
+
+    if err := s.shiftRepo.Close(shiftID, roundMoney(countedCash), notes); err != nil {
+        return nil, err
+    }
+
+    return s.GetReport(shiftID)
+}
+
+// Reporte del turno: parcial (X) si está abierto, de cierre (Z) si está cerrado
+func (s *ShiftService) GetReport(shiftID int32) (*data.ShiftReport, error) {
+    shift, err := s.GetShift(shiftID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Un turno cerrado usa los totales guardados al cierre, igual que el efectivo esperado
+    var tenders []data.TenderTotal
+    if shift.Status == data.ShiftStatusClosed {
+        tenders, err = s.shiftRepo.GetClosedTenderTotals(shiftID)
+    } else {
+        tenders, err = s.shiftRepo.GetTenderTotals(shiftID)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    cashIn, cashOut, err := s.shiftRepo.GetCashMovementTotals(shiftID)
+    if err != nil {
+        return nil, err
+    }
+
+    report := &data.ShiftReport{
+        Shift:       *shift,
+        Tenders:     tenders,
+        CashIn:      roundMoney(cashIn),
+        CashOut:     roundMoney(cashOut),
+        CountedCash: shift.CountedCash,
+        Discrepancy: shift.Discrepancy,
+    }
+
+    for _, tender := range tenders {
+        report.Tips += tender.Tips
+        if tender.Method == data.PaymentMethodCash {
+            report.CashSales = roundMoney(tender.Amount)
+            report.CashTips = roundMoney(tender.Tips)
+        }
+    }
+    report.Tips = roundMoney(report.Tips)
+
+    // Las propinas en efectivo quedan en caja hasta repartirse
+    if shift.ExpectedCash != nil {
+        report.ExpectedCash = *shift.ExpectedCash
+    } else {
+        report.ExpectedCash = roundMoney(shift.OpeningFloat + report.CashSales + report.CashTips + report.CashIn - report.CashOut)
+    }
+
+    return report, nil
+}
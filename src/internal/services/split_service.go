@@ -0,0 +1,199 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "math"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type SplitService struct {
+    orderService *OrderService
+    splitRepo    *repository.SplitRepository
+}
+
+func NewSplitService(orderService *OrderService, splitRepo *repository.SplitRepository) *SplitService {
+    return &SplitService{
+        orderService: orderService,
+        splitRepo:    splitRepo,
+    }
+}
+
+type SplitItemInput struct {
+    OrderItemID int32   `json:"order_item_id"`
+    Quantity    float64 `json:"quantity"`
+}
+
+// Items asignados a una subcuenta
+type SplitCheckInput struct {
+    Items []SplitItemInput `json:"items"`
+}
+
+func (s *SplitService) GetSplits(orderID int32) ([]data.OrderSplit, error) {
+    if _, err := s.orderService.GetOrder(orderID); err != nil {
+        return nil, err
+    }
+
+    return s.splitRepo.GetByOrder(orderID)
+}
+
+// Divide la orden asignando items (o parte de ellos) a cada subcuenta.
+// Todos los items deben quedar asignados por completo.
+func (s *SplitService) SplitByItems(orderID int32, checks []SplitCheckInput) ([]data.OrderSplit, error) {
+    if len(checks) < 2 {
+        return nil, errors.New("at least two checks are required")
+    }
+
+    detail, err := s.getSplittableOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    items := make(map[int32]data.OrderItem, len(detail.Items))
+    for _, item := range detail.Items {
+        items[item.ID] = item
+    }
+
+    assigned := make(map[int32]float64, len(detail.Items))
+    splits := make([]data.OrderSplit, 0, len(checks))
+
+    for i, check := range checks {
+        if len(check.Items) == 0 {
+            return nil, fmt.Errorf("check %d has no items", i+1)
+        }
+
+        split := data.OrderSplit{
+            SplitNumber: int32(i + 1),
+            Status:      data.SplitStatusOpen,
+        }
+
+        for _, input := range check.Items {
+            item, ok := items[input.OrderItemID]
+            if !ok {
+                return nil, fmt.Errorf("order item %d not found in order", input.OrderItemID)
+            }
+
+            if input.Quantity <= 0 {
+                return nil, errors.New("quantity must be greater than zero")
+            }
+
+            assigned[item.ID] += input.Quantity
+            if assigned[item.ID] > float64(item.Quantity)+quantityTolerance {
+                return nil, fmt.Errorf("order item %d is assigned more than its quantity", item.ID)
+            }
+
+            amount := roundMoney(item.Subtotal * input.Quantity / float64(item.Quantity))
+            split.Items = append(split.Items, data.OrderSplitItem{
+                OrderItemID: item.ID,
+                Quantity:    input.Quantity,
+                Amount:      amount,
+            })
+            split.Amount += amount
+        }
+
+        splits = append(splits, split)
+    }
+
+    for _, item := range detail.Items {
+        if math.Abs(assigned[item.ID]-float64(item.Quantity)) > quantityTolerance {
+            return nil, fmt.Errorf("order item %d is not fully assigned", item.ID)
+        }
+    }
+
+    // Cargos fuera de los items (ej. domicilio) se reparten en proporción a cada subcuenta
+    distributeRemainder(splits, detail.Totals.ItemsSubtotal, detail.Order.TotalAmount)
+
+    if err := s.splitRepo.ReplaceForOrder(orderID, splits); err != nil {
+        return nil, err
+    }
+
+    return splits, nil
+}
+
+// Divide el total de la orden en partes iguales
+func (s *SplitService) SplitEqually(orderID int32, parts int) ([]data.OrderSplit, error) {
+    if parts < 2 {
+        return nil, errors.New("parts must be at least two")
+    }
+
+    detail, err := s.getSplittableOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    total := detail.Order.TotalAmount
+    share := math.Floor(total/float64(parts)*100) / 100
+
+    splits := make([]data.OrderSplit, parts)
+    for i := range splits {
+        splits[i] = data.OrderSplit{
+            SplitNumber: int32(i + 1),
+            Amount:      share,
+            Status:      data.SplitStatusOpen,
+        }
+    }
+    // Los centavos sobrantes van a la última subcuenta
+    splits[parts-1].Amount = roundMoney(total - share*float64(parts-1))
+
+    if err := s.splitRepo.ReplaceForOrder(orderID, splits); err != nil {
+        return nil, err
+    }
+
+    return splits, nil
+}
+
+func (s *SplitService) CancelSplits(orderID int32) error {
+    if _, err := s.orderService.GetOrder(orderID); err != nil {
+        return err
+    }
+
+    return s.splitRepo.DeleteByOrder(orderID)
+}
+
+func (s *SplitService) getSplittableOrder(orderID int32) (*OrderDetail, error) {
+    detail, err := s.orderService.GetOrder(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    if !isOpenStatus(detail.Order.Status) {
+        return nil, errors.New("order is already closed")
+    }
+
+    // Las subcuentas suman el total: con pagos previos la última nunca se saldaría
+    if detail.Order.PaymentStatus != data.PaymentStatusUnpaid {
+        return nil, errors.New("order has payments, it cannot be split")
+    }
+
+    if len(detail.Items) == 0 {
+        return nil, errors.New("order has no items")
+    }
+
+    return detail, nil
+}
+
+// Tolerancia para comparar cantidades parciales (ej. 1/3 de un item)
+const quantityTolerance = 0.0001
+
+// Reparte la diferencia entre el total y los items en proporción al monto de
+// cada subcuenta, dejando el redondeo en la última
+func distributeRemainder(splits []data.OrderSplit, itemsSubtotal, total float64) {
+    var assigned float64
+    for i := range splits {
+        amount := splits[i].Amount
+        if itemsSubtotal > 0 && i < len(splits)-1 {
+            amount = roundMoney(amount * total / itemsSubtotal)
+        }
+        splits[i].Amount = amount
+        assigned += amount
+    }
+
+    last := len(splits) - 1
+    splits[last].Amount = roundMoney(total - (assigned - splits[last].Amount))
+}
+
+func roundMoney(amount float64) float64 {
+    return math.Round(amount*100) / 100
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestDistributeRemainder(t *testing.T) {
+    tests := []struct {
+        name          string
+        amounts       []float64
+        itemsSubtotal float64
+        total         float64
+        want          []float64
+    }{
+        {"proportional to each split", []float64{60, 40}, 100, 116, []float64{69.6, 46.4}},
+        {"rounding goes to the last split", []float64{10, 10, 10}, 30, 35, []float64{11.67, 11.67, 11.66}},
+        {"discount lowers every split", []float64{50, 50}, 100, 90, []float64{45, 45}},
+        {"single split takes the total", []float64{100}, 100, 90, []float64{90}},
+        {"no items subtotal leaves the rest to the last", []float64{0, 0}, 0, 5, []float64{0, 5}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            splits := make([]data.OrderSplit, len(tt.amounts))
+            for i, amount := range tt.amounts {
+                splits[i].Amount = amount
+            }
+
+            distributeRemainder(splits, tt.itemsSubtotal, tt.total)
+
+            for i, split := range splits {
+                if split.Amount != tt.want[i] {
+                    t.Errorf("split %d amount = %v, want %v", i, split.Amount, tt.want[i])
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Tope de tiempo de preparación por producto (minutos)
+const maxPrepMinutes = 240
+
+// Transiciones permitidas para el estado de preparación de un item
+var orderItemTransitions = map[string][]string{
+    data.OrderItemStatusPending:   {data.OrderItemStatusPreparing, data.OrderItemStatusReady},
+    data.OrderItemStatusPreparing: {data.OrderItemStatusReady},
+}
+
+type StationService struct {
+    stationRepo *repository.StationRepository
+    orderRepo   *repository.OrderRepository
+}
+
+func NewStationService(stationRepo *repository.StationRepository, orderRepo *repository.OrderRepository) *StationService {
+    return &StationService{
+        stationRepo: stationRepo,
+        orderRepo:   orderRepo,
+    }
+}
+
+func (s *StationService) ListStations() ([]data.KitchenStation, error) {
+    return s.stationRepo.GetAll()
+}
+
+func (s *StationService) GetStation(id int32) (*data.KitchenStation, error) {
+    station, err := s.stationRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if station == nil {
+        return nil, errors.New("kitchen station not found")
+    }
+
+    return station, nil
+}
+
+func (s *StationService) CreateStation(station *data.KitchenStation) (*data.KitchenStation, error) {
+    if err := s.validateStation(station); err != nil {
+        return nil, err
+    }
+
+    if err := s.stationRepo.Create(station); err != nil {
+        return nil, err
+    }
+
+    return station, nil
+}
+
+func (s *StationService) UpdateStation(id int32, station *data.KitchenStation) (*data.KitchenStation, error) {
+    existing, err := s.GetStation(id)
+    if err != nil {
+        return nil, err
+    }
+
+    station.ID = existing.ID
+    station.CreatedAt = existing.CreatedAt
+    if err := s.validateStation(station); err != nil {
+        return nil, err
+    }
+
+    if err := s.stationRepo.Update(station); err != nil {
+        return nil, err
+    }
+
+    return station, nil
+}
+
+func (s *StationService) DeleteStation(id int32) error {
+    return s.stationRepo.Delete(id)
+}
+
+// Asigna la estación de un producto; nil lo deja sin estación
+func (s *StationService) AssignToProduct(productID int32, stationID *int32) error {
+    if stationID != nil {
+        station, err := s.GetStation(*stationID)
+        if err != nil {
+            return err
+        }
+
+        if !station.IsActive {
+            return errors.New("kitchen station is not active")
+        }
+    }
+
+    return s.stationRepo.AssignProduct(productID, stationID)
+}
+
+// Minutos de preparación de un producto; 0 para lo que no pasa por cocina
+func (s *StationService) SetPrepTime(productID int32, minutes int32) error {
+    if minutes < 0 || minutes > maxPrepMinutes {
+        return fmt.Errorf("prep time must be between 0 and %d minutes", maxPrepMinutes)
+    }
+
+    return s.stationRepo.SetProductPrepTime(productID, minutes)
+}
+
+// Comandas pendientes de una estación; includeReady agrega las ya listas
+// que siguen en órdenes abiertas
+func (s *StationService) GetStationTickets(stationID int32, includeReady bool) ([]data.StationTicket, error) {
+    if _, err := s.GetStation(stationID); err != nil {
+        return nil, err
+    }
+
+    return s.stationRepo.GetTickets(stationID, includeReady)
+}
+
+func (s *StationService) GetOrderTickets(orderID int32) ([]data.StationTicket, error) {
+    order, err := s.orderRepo.GetByID(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    if order == nil {
+        return nil, errors.New("order not found")
+    }
+
+    return s.stationRepo.GetOrderTickets(orderID)
+}
+
+// Cambia el estado de preparación de un item; el estado de la orden se
+// recalcula a partir de todos sus items
+func (s *StationService) UpdateItemStatus(orderID, itemID int32, status string) error {
+    items, err := s.orderRepo.GetItems(orderID)
+    if err != nil {
+        return err
+    }
+
+    var current *data.OrderItem
+    for i := range items {
+        if items[i].ID == itemID {
+            current = &items[i]
+            break
+        }
+    }
+
+    if current == nil {
+        return errors.New("order item not found")
+    }
+
+    if current.FiredAt == nil {
+        return errors.New("order item course has not been fired")
+    }
+
+    if !canTransitionItem(current.Status, status) {
+        return errors.New("invalid item status transition from " + current.Status + " to " + status)
+    }
+
+    return s.stationRepo.UpdateItemStatus(orderID, itemID, current.Status, status)
+}
+
+// Avanza de una vez la comanda de una estación en una orden.
+// stationID nil apunta a los items sin estación asignada.
+func (s *StationService) UpdateTicketStatus(orderID int32, stationID *int32, status string) (int64, error) {
+    if status != data.OrderItemStatusPreparing && status != data.OrderItemStatusReady {
+        return 0, errors.New("invalid ticket status")
+    }
+
+    return s.stationRepo.UpdateTicketStatus(orderID, stationID, status)
+}
+
+func (s *StationService) validateStation(station *data.KitchenStation) error {
+    station.Name = strings.TrimSpace(station.Name)
+    if station.Name == "" {
+        return errors.New("kitchen station name is required")
+    }
+
+    existing, err := s.stationRepo.GetByName(station.Name)
+    if err != nil {
+        return err
+    }
+
+    if existing != nil && existing.ID != station.ID {
+        return errors.New("kitchen station already exists")
+    }
+
+    return nil
+}
+
+func canTransitionItem(from, to string) bool {
+    for _, allowed := range orderItemTransitions[from] {
+        if allowed == to {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+    "bytes"
+    "crypto/tls"
+    "encoding/json"
+    "fmt"
+    "log"
+    "mime"
+    "net"
+    "net/http"
+    "net/smtp"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+// Canales por los que se avisa de una alerta de stock bajo
+const (
+    StockNotifierLog     = "log"
+    StockNotifierWebhook = "webhook"
+    StockNotifierEmail   = "email"
+)
+
+// Envía el aviso de una alerta; un error deja la alerta pendiente para el
+// siguiente ciclo
+type StockAlertNotifier interface {
+    Notify(alert data.StockAlert) error
+}
+
+func stockAlertMessage(alert data.StockAlert) string {
+    return fmt.Sprintf("Stock bajo: %s tiene %.2f %s (mínimo %.2f %s)",
+        alert.IngredientName, alert.StockQuantity, alert.Unit, alert.MinStock, alert.Unit)
+}
+
+// Solo deja el aviso en el log del servidor
+type LogStockNotifier struct{}
+
+func (LogStockNotifier) Notify(alert data.StockAlert) error {
+    log.Print(stockAlertMessage(alert))
+    return nil
+}
+
+// Publica la alerta como JSON en una URL (Slack, n8n, un bot propio...)
+type WebhookStockNotifier struct {
+    url    string
+    client *http.Client
+}
+
+func NewWebhookStockNotifier(url string) *WebhookStockNotifier {
+    return &WebhookStockNotifier{
+        url:    url,
+        client: &http.Client{Timeout: 10 * time.Second},
+    }
+}
+
+func (n *WebhookStockNotifier) Notify(alert data.StockAlert) error {
+    body, err := json.Marshal(map[string]any{
+        "text":  stockAlertMessage(alert),
+        "alert": alert,
+    })
+    if err != nil {
+        return fmt.Errorf("error encoding stock alert: %w", err)
+    }
+
+    resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+    if err != nil {
+        return fmt.Errorf("error calling stock alert webhook: %w", err)
+    }
+    resp.Body.Close()
+
+    if resp.StatusCode >= 300 {
+        return fmt.Errorf("stock alert webhook responded %d", resp.StatusCode)
+    }
+
+    return nil
+}
+
+// Tiempo máximo para conectar y entregar un correo; un servidor SMTP colgado
+// no debe detener el envío de las demás alertas
+const smtpTimeout = 30 * time.Second
+
+// Envía un correo por el servidor SMTP local (sin autenticación)
+type EmailStockNotifier struct {
+    addr string
+    from string
+    to   []string
+}
+
+func NewEmailStockNotifier(addr, from string, to []string) *EmailStockNotifier {
+    return &EmailStockNotifier{
+        addr: addr,
+        from: from,
+        to:   to,
+    }
+}
+
+func (n *EmailStockNotifier) Notify(alert data.StockAlert) error {
+    text := stockAlertMessage(alert)
+
+    var msg strings.Builder
+    msg.WriteString("From: " + n.from + "\r\n")
+    msg.WriteString("To: " + strings.Join(n.to, ", ") + "\r\n")
+    msg.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", text) + "\r\n")
+    msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+    msg.WriteString("\r\n")
+    msg.WriteString(text + "\r\n")
+
+    if err := n.send([]byte(msg.String())); err != nil {
+        return fmt.Errorf("error sending stock alert email: %w", err)
+    }
+
+    return nil
+}
+
+// Como smtp.SendMail (con STARTTLS si el servidor lo ofrece), pero con un
+// plazo para toda la conversación
+func (n *EmailStockNotifier) send(msg []byte) error {
+    conn, err := net.DialTimeout("tcp", n.addr, smtpTimeout)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+        return err
+    }
+
+    host, _, err := net.SplitHostPort(n.addr)
+    if err != nil {
+        return err
+    }
+
+    client, err := smtp.NewClient(conn, host)
+    if err != nil {
+        return err
+    }
+    defer client.Close()
+
+    if ok, _ := client.Extension("STARTTLS"); ok {
+        if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+            return err
+        }
+    }
+
+    if err := client.Mail(n.from); err != nil {
+        return err
+    }
+    for _, to := range n.to {
+        if err := client.Rcpt(to); err != nil {
+            return err
+        }
+    }
+
+    w, err := client.Data()
+    if err != nil {
+        return err
+    }
+    if _, err := w.Write(msg); err != nil {
+        return err
+    }
+    if err := w.Close(); err != nil {
+        return err
+    }
+
+    return client.Quit()
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Las alertas se levantan en la misma transacción de cada movimiento de
+// inventario; este servicio además revisa todo el catálogo periódicamente y
+// envía al notificador las alertas nuevas.
+type StockAlertService struct {
+    alertRepo *repository.StockAlertRepository
+    notifier  StockAlertNotifier
+    interval  time.Duration
+}
+
+func NewStockAlertService(alertRepo *repository.StockAlertRepository, notifier StockAlertNotifier, interval time.Duration) *StockAlertService {
+    return &StockAlertService{
+        alertRepo: alertRepo,
+        notifier:  notifier,
+        interval:  interval,
+    }
+}
+
+// Bucle de revisión; se ejecuta en su propia goroutine durante toda la vida del servidor
+func (s *StockAlertService) Run() {
+    ticker := time.NewTicker(s.interval)
+    defer ticker.Stop()
+
+    // Los avisos salen en otra goroutine: un canal lento no retrasa la revisión
+    pending := make(chan struct{}, 1)
+    go s.notifyLoop(pending)
+
+    for {
+        if raised, err := s.alertRepo.CheckAll(); err != nil {
+            log.Printf("Failed to check stock levels: %v", err)
+        } else if raised > 0 {
+            log.Printf("Raised %d low stock alerts", raised)
+        }
+
+        // Si ya hay un envío en cola, ese tomará también las alertas nuevas
+        select {
+        case pending <- struct{}{}:
+        default:
+        }
+        <-ticker.C
+    }
+}
+
+func (s *StockAlertService) notifyLoop(pending <-chan struct{}) {
+    for range pending {
+        if err := s.NotifyPending(); err != nil {
+            log.Printf("Failed to send stock alerts: %v", err)
+        }
+    }
+}
+
+// Envía las alertas abiertas que aún no se notificaron; las que fallan se
+// reintentan en el siguiente ciclo
+func (s *StockAlertService) NotifyPending() error {
+    alerts, err := s.alertRepo.GetUnnotified()
+    if err != nil {
+        return err
+    }
+
+    for _, alert := range alerts {
+        if err := s.notifier.Notify(alert); err != nil {
+            log.Printf("Failed to notify stock alert %d: %v", alert.ID, err)
+            continue
+        }
+
+        if err := s.alertRepo.MarkNotified(alert.ID); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// Alertas por estado; sin estado devuelve las activas (abiertas y vistas)
+func (s *StockAlertService) ListAlerts(status string) ([]data.StockAlert, error) {
+    switch status {
+    case "", data.StockAlertOpen, data.StockAlertAcknowledged, data.StockAlertResolved:
+    default:
+        return nil, fmt.Errorf("invalid stock alert status %s", status)
+    }
+
+    return s.alertRepo.Find(status)
+}
+
+func (s *StockAlertService) Acknowledge(id, userID int32) (*data.StockAlert, error) {
+    if err := s.alertRepo.Acknowledge(id, userID); err != nil {
+        return nil, err
+    }
+
+    return s.getAlert(id)
+}
+
+// Cierra la alerta a mano (por ejemplo, el mínimo ya no aplica); si el stock
+// sigue bajo, la próxima revisión levanta una nueva
+func (s *StockAlertService) Resolve(id, userID int32) (*data.StockAlert, error) {
+    if err := s.alertRepo.Resolve(id, userID); err != nil {
+        return nil, err
+    }
+
+    return s.getAlert(id)
+}
+
+func (s *StockAlertService) getAlert(id int32) (*data.StockAlert, error) {
+    alert, err := s.alertRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if alert == nil {
+        return nil, errors.New("stock alert not found")
+    }
+
+    return alert, nil
+}
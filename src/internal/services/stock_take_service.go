@@ -0,0 +1,143 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type StockTakeService struct {
+    stockTakeRepo     *repository.StockTakeRepository
+    ingredientService *IngredientService
+}
+
+func NewStockTakeService(stockTakeRepo *repository.StockTakeRepository, ingredientService *IngredientService) *StockTakeService {
+    return &StockTakeService{
+        stockTakeRepo:     stockTakeRepo,
+        ingredientService: ingredientService,
+    }
+}
+
+// Sin ingredientes se cuenta todo el almacén
+type StockTakeInput struct {
+    Notes         string  `json:"notes"`
+    IngredientIDs []int32 `json:"ingredient_ids"`
+}
+
+type StockCountInput struct {
+    IngredientID int32   `json:"ingredient_id"`
+    Quantity     float64 `json:"quantity"`
+    Unit         string  `json:"unit"` // opcional; por defecto la unidad del ingrediente
+}
+
+type StockTakeDetail struct {
+    StockTake *data.StockTake      `json:"stock_take"`
+    Lines     []data.StockTakeLine `json:"lines"`
+}
+
+func (s *StockTakeService) ListStockTakes(status string) ([]data.StockTake, error) {
+    switch status {
+    case "", data.StockTakeOpen, data.StockTakeApproved, data.StockTakeCancelled:
+    default:
+        return nil, fmt.Errorf("invalid stock take status %s", status)
+    }
+
+    return s.stockTakeRepo.Find(status)
+}
+
+func (s *StockTakeService) GetStockTake(id int32) (*StockTakeDetail, error) {
+    take, err := s.stockTakeRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if take == nil {
+        return nil, errors.New("stock take not found")
+    }
+
+    lines, err := s.stockTakeRepo.GetLines(id)
+    if err != nil {
+        return nil, err
+    }
+
+    return &StockTakeDetail{StockTake: take, Lines: lines}, nil
+}
+
+// Abre un conteo y toma la foto del stock actual
+func (s *StockTakeService) OpenStockTake(input StockTakeInput, userID int32) (*StockTakeDetail, error) {
+    seen := make(map[int32]bool, len(input.IngredientIDs))
+    ingredientIDs := make([]int32, 0, len(input.IngredientIDs))
+    for _, id := range input.IngredientIDs {
+        if !seen[id] {
+            seen[id] = true
+            ingredientIDs = append(ingredientIDs, id)
+        }
+    }
+
+    take := &data.StockTake{
+        Notes:     strings.TrimSpace(input.Notes),
+        CreatedBy: &userID,
+    }
+
+    if err := s.stockTakeRepo.Create(take, ingredientIDs); err != nil {
+        return nil, err
+    }
+
+    return s.GetStockTake(take.ID)
+}
+
+// Registra cantidades contadas, convertidas a la unidad de cada ingrediente
+func (s *StockTakeService) RecordCounts(id, userID int32, counts []StockCountInput) (*StockTakeDetail, error) {
+    if len(counts) == 0 {
+        return nil, errors.New("at least one count is required")
+    }
+
+    seen := make(map[int32]bool, len(counts))
+    converted := make([]repository.StockCount, 0, len(counts))
+    for _, count := range counts {
+        if count.Quantity < 0 {
+            return nil, errors.New("counted quantity cannot be negative")
+        }
+
+        if seen[count.IngredientID] {
+            return nil, fmt.Errorf("ingredient %d is repeated in the count", count.IngredientID)
+        }
+        seen[count.IngredientID] = true
+
+        quantity, err := s.ingredientService.ToIngredientUnit(count.IngredientID, count.Quantity, count.Unit)
+        if err != nil {
+            return nil, err
+        }
+
+        converted = append(converted, repository.StockCount{
+            IngredientID: count.IngredientID,
+            Quantity:     quantity,
+        })
+    }
+
+    if err := s.stockTakeRepo.RecordCounts(id, userID, converted); err != nil {
+        return nil, err
+    }
+
+    return s.GetStockTake(id)
+}
+
+// Aprueba el conteo y ajusta el inventario por las diferencias
+func (s *StockTakeService) ApproveStockTake(id, userID int32) (*StockTakeDetail, error) {
+    if err := s.stockTakeRepo.Approve(id, userID); err != nil {
+        return nil, err
+    }
+
+    return s.GetStockTake(id)
+}
+
+func (s *StockTakeService) CancelStockTake(id int32) (*StockTakeDetail, error) {
+    if err := s.stockTakeRepo.Cancel(id); err != nil {
+        return nil, err
+    }
+
+    return s.GetStockTake(id)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+    "errors"
+    "net/mail"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type SupplierService struct {
+    supplierRepo *repository.SupplierRepository
+}
+
+func NewSupplierService(supplierRepo *repository.SupplierRepository) *SupplierService {
+    return &SupplierService{
+        supplierRepo: supplierRepo,
+    }
+}
+
+func (s *SupplierService) ListSuppliers() ([]data.Supplier, error) {
+    return s.supplierRepo.GetAll()
+}
+
+func (s *SupplierService) GetSupplier(id int32) (*data.Supplier, error) {
+    supplier, err := s.supplierRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if supplier == nil {
+        return nil, errors.New("supplier not found")
+    }
+
+    return supplier, nil
+}
+
+func (s *SupplierService) CreateSupplier(supplier *data.Supplier) (*data.Supplier, error) {
+    if err := s.validateSupplier(supplier); err != nil {
+        return nil, err
+    }
+
+    if err := s.supplierRepo.Create(supplier); err != nil {
+        return nil, err
+    }
+
+    return supplier, nil
+}
+
+func (s *SupplierService) UpdateSupplier(id int32, supplier *data.Supplier) (*data.Supplier, error) {
+    existing, err := s.GetSupplier(id)
+    if err != nil {
+        return nil, err
+    }
+
+    supplier.ID = existing.ID
+    supplier.CreatedAt = existing.CreatedAt
+    if err := s.validateSupplier(supplier); err != nil {
+        return nil, err
+    }
+
+    if err := s.supplierRepo.Update(supplier); err != nil {
+        return nil, err
+    }
+
+    return supplier, nil
+}
+
+func (s *SupplierService) DeleteSupplier(id int32) error {
+    return s.supplierRepo.Delete(id)
+}
+
+func (s *SupplierService) validateSupplier(supplier *data.Supplier) error {
+    supplier.Name = strings.TrimSpace(supplier.Name)
+    if supplier.Name == "" {
+        return errors.New("supplier name is required")
+    }
+
+    supplier.ContactName = strings.TrimSpace(supplier.ContactName)
+    supplier.Phone = strings.TrimSpace(supplier.Phone)
+    supplier.Email = strings.TrimSpace(supplier.Email)
+    if supplier.Email != "" {
+        if _, err := mail.ParseAddress(supplier.Email); err != nil {
+            return errors.New("supplier email is not valid")
+        }
+    }
+
+    existing, err := s.supplierRepo.GetByName(supplier.Name)
+    if err != nil {
+        return err
+    }
+
+    if existing != nil && existing.ID != supplier.ID {
+        return errors.New("supplier already exists")
+    }
+
+    return nil
+}
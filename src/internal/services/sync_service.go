@@ -0,0 +1,384 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+// Precio que se respeta cuando el producto cambió de precio mientras el POS estaba sin conexión
+const (
+    // El que vio el cliente al pedir, solo si fue un precio real del producto
+    // durante el periodo sin conexión
+    SyncPricePolicyClient = "client"
+    SyncPricePolicyServer = "server" // el vigente en el catálogo
+)
+
+// Resultado de sincronizar cada orden
+const (
+    SyncResultCreated   = "created"
+    SyncResultDuplicate = "duplicate"
+    SyncResultRejected  = "rejected"
+    SyncResultFailed    = "failed"
+)
+
+// Tipos de conflicto detectados en los items
+const (
+    SyncConflictPriceChanged = "price_changed"
+    SyncConflictUnavailable  = "unavailable"
+    SyncConflictNotFound     = "not_found"
+)
+
+// Máximo de órdenes por lote
+const maxSyncBatchSize = 100
+
+// Tolerancia para relojes de tablets adelantados
+const syncClockSkew = 5 * time.Minute
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type SyncService struct {
+    syncRepo     *repository.SyncRepository
+    productRepo  *repository.ProductRepository
+    orderService *OrderService
+    pricePolicy  string
+}
+
+func NewSyncService(syncRepo *repository.SyncRepository, productRepo *repository.ProductRepository, orderService *OrderService, pricePolicy string) *SyncService {
+    return &SyncService{
+        syncRepo:     syncRepo,
+        productRepo:  productRepo,
+        orderService: orderService,
+        pricePolicy:  pricePolicy,
+    }
+}
+
+func IsValidSyncPricePolicy(policy string) bool {
+    return policy == SyncPricePolicyClient || policy == SyncPricePolicyServer
+}
+
+type SyncItemInput struct {
+    ProductID int32   `json:"product_id"`
+    Quantity  int32   `json:"quantity"`
+    UnitPrice float64 `json:"unit_price"`
+    Notes     string  `json:"notes"`
+    Course    int32   `json:"course"`
+}
+
+// Orden tomada sin conexión. Items reemplaza al de CreateOrderInput porque
+// trae el precio que mostraba el POS.
+type SyncOrderInput struct {
+    CreateOrderInput
+    ClientUUID      string          `json:"client_uuid"`
+    ClientCreatedAt time.Time       `json:"client_created_at"`
+    Items           []SyncItemInput `json:"items"`
+}
+
+type SyncBatchInput struct {
+    DeviceID  string           `json:"device_id"`
+    SyncToken string           `json:"sync_token"`
+    Orders    []SyncOrderInput `json:"orders"`
+}
+
+type SyncConflict struct {
+    ProductID   int32   `json:"product_id"`
+    Type        string  `json:"type"`
+    ClientPrice float64 `json:"client_price,omitempty"`
+    ServerPrice float64 `json:"server_price,omitempty"`
+    // Qué se hizo: kept_client_price, applied_server_price o removed
+    Resolution string `json:"resolution"`
+}
+
+type SyncOrderResult struct {
+    ClientUUID  string         `json:"client_uuid"`
+    Status      string         `json:"status"`
+    OrderID     int32          `json:"order_id,omitempty"`
+    OrderNumber string         `json:"order_number,omitempty"`
+    Total       float64        `json:"total,omitempty"`
+    Conflicts   []SyncConflict `json:"conflicts,omitempty"`
+    Error       string         `json:"error,omitempty"`
+}
+
+type SyncCatalog struct {
+    SyncToken string `json:"sync_token"`
+    *data.CatalogDelta
+}
+
+type SyncBatchResult struct {
+    Orders  []SyncOrderResult `json:"orders"`
+    Catalog SyncCatalog       `json:"catalog"`
+}
+
+// Sube las órdenes tomadas sin conexión y devuelve los cambios del catálogo.
+// Cada orden se procesa por separado: un rechazo no afecta a las demás y
+// reenviar el lote completo es seguro.
+func (s *SyncService) SyncBatch(input SyncBatchInput) (*SyncBatchResult, error) {
+    input.DeviceID = strings.TrimSpace(input.DeviceID)
+    if input.DeviceID == "" {
+        return nil, errors.New("device id is required")
+    }
+
+    if len(input.Orders) > maxSyncBatchSize {
+        return nil, fmt.Errorf("a sync batch cannot have more than %d orders", maxSyncBatchSize)
+    }
+
+    since, err := parseSyncToken(input.SyncToken)
+    if err != nil {
+        return nil, err
+    }
+
+    result := &SyncBatchResult{Orders: make([]SyncOrderResult, 0, len(input.Orders))}
+    for _, order := range input.Orders {
+        result.Orders = append(result.Orders, s.syncOrder(input.DeviceID, order))
+    }
+
+    // El catálogo se lee después de las órdenes para incluir lo que haya cambiado mientras tanto
+    catalog, err := s.catalogChanges(since)
+    if err != nil {
+        return nil, err
+    }
+    result.Catalog = *catalog
+
+    return result, nil
+}
+
+// Cambios del catálogo desde el token de la última sincronización ("" = completo)
+func (s *SyncService) GetCatalogChanges(syncToken string) (*SyncCatalog, error) {
+    since, err := parseSyncToken(syncToken)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.catalogChanges(since)
+}
+
+func (s *SyncService) catalogChanges(since int64) (*SyncCatalog, error) {
+    delta, err := s.syncRepo.GetCatalogDelta(since)
+    if err != nil {
+        return nil, err
+    }
+
+    return &SyncCatalog{
+        SyncToken:    strconv.FormatInt(delta.Version, 10),
+        CatalogDelta: delta,
+    }, nil
+}
+
+func (s *SyncService) syncOrder(deviceID string, input SyncOrderInput) SyncOrderResult {
+    result := SyncOrderResult{ClientUUID: input.ClientUUID}
+
+    reject := func(err error) SyncOrderResult {
+        result.Status = SyncResultRejected
+        // Los errores de base de datos son transitorios: el POS debe reintentar
+        if repository.IsDatabaseError(err) {
+            result.Status = SyncResultFailed
+        }
+        result.Error = err.Error()
+        return result
+    }
+
+    if err := validateSyncOrder(&input); err != nil {
+        return reject(err)
+    }
+
+    // Reenvío de una orden ya sincronizada
+    existing, err := s.syncRepo.GetSyncedOrder(input.ClientUUID)
+    if err != nil {
+        return reject(err)
+    }
+    if existing != nil {
+        return s.duplicateResult(result.ClientUUID, existing)
+    }
+
+    items, conflicts, err := s.resolveItems(input.Items, input.ClientCreatedAt)
+    if err != nil {
+        return reject(err)
+    }
+    result.Conflicts = conflicts
+
+    if len(items) == 0 {
+        return reject(errors.New("none of the order items are available"))
+    }
+
+    order := s.orderService.newOrder(input.CreateOrderInput)
+    order.OrderNumber = offlineOrderNumber(input.ClientUUID)
+
+    synced := &data.SyncedOrder{
+        ClientUUID:      input.ClientUUID,
+        DeviceID:        deviceID,
+        ClientCreatedAt: input.ClientCreatedAt,
+    }
+
+    created, err := s.syncRepo.CreateOfflineOrder(synced, order, items)
+    if err != nil {
+        return reject(err)
+    }
+    if !created {
+        // Un envío concurrente de la misma orden se adelantó
+        if existing, err = s.syncRepo.GetSyncedOrder(input.ClientUUID); err != nil || existing == nil {
+            return SyncOrderResult{ClientUUID: result.ClientUUID, Status: SyncResultDuplicate}
+        }
+        return s.duplicateResult(result.ClientUUID, existing)
+    }
+
+    result.Status = SyncResultCreated
+    result.OrderID = order.ID
+    result.OrderNumber = order.OrderNumber
+    result.Total = order.TotalAmount
+    return result
+}
+
+// El resultado conserva el UUID tal como lo envió el POS
+func (s *SyncService) duplicateResult(clientUUID string, synced *data.SyncedOrder) SyncOrderResult {
+    result := SyncOrderResult{
+        ClientUUID: clientUUID,
+        Status:     SyncResultDuplicate,
+        OrderID:    synced.OrderID,
+    }
+
+    if detail, err := s.orderService.GetOrder(synced.OrderID); err == nil {
+        result.OrderNumber = detail.Order.OrderNumber
+        result.Total = detail.Order.TotalAmount
+    }
+
+    return result
+}
+
+// Compara los items con el catálogo actual. Los productos eliminados o no
+// disponibles se quitan de la orden; los cambios de precio se resuelven
+// según la política configurada. Todo queda reportado como conflicto.
+func (s *SyncService) resolveItems(items []SyncItemInput, orderedAt time.Time) ([]data.OrderItem, []SyncConflict, error) {
+    orderItems := make([]data.OrderItem, 0, len(items))
+    var conflicts []SyncConflict
+
+    for _, item := range items {
+        if item.Quantity <= 0 {
+            return nil, nil, errors.New("quantity must be greater than zero")
+        }
+
+        if item.UnitPrice < 0 {
+            return nil, nil, errors.New("unit price cannot be negative")
+        }
+
+        notes, err := validateItemKitchenInfo(item.Notes, item.Course)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        product, err := s.productRepo.GetByID(item.ProductID)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        if product == nil {
+            conflicts = append(conflicts, SyncConflict{
+                ProductID:  item.ProductID,
+                Type:       SyncConflictNotFound,
+                Resolution: "removed",
+            })
+            continue
+        }
+
+        if !product.IsAvailable {
+            conflicts = append(conflicts, SyncConflict{
+                ProductID:  item.ProductID,
+                Type:       SyncConflictUnavailable,
+                Resolution: "removed",
+            })
+            continue
+        }
+
+        price := product.Price
+        if roundMoney(item.UnitPrice) != roundMoney(product.Price) {
+            conflict := SyncConflict{
+                ProductID:   item.ProductID,
+                Type:        SyncConflictPriceChanged,
+                ClientPrice: item.UnitPrice,
+                ServerPrice: product.Price,
+                Resolution:  "applied_server_price",
+            }
+            if s.pricePolicy == SyncPricePolicyClient {
+                valid, err := s.productRepo.WasPriceValidSince(product.ID, item.UnitPrice, orderedAt)
+                if err != nil {
+                    return nil, nil, err
+                }
+                if valid {
+                    price = item.UnitPrice
+                    conflict.Resolution = "kept_client_price"
+                }
+            }
+            conflicts = append(conflicts, conflict)
+        }
+
+        orderItems = append(orderItems, data.OrderItem{
+            ProductID: product.ID,
+            Quantity:  item.Quantity,
+            UnitPrice: price,
+            Subtotal:  price * float64(item.Quantity),
+            Notes:     notes,
+            Course:    item.Course,
+        })
+    }
+
+    return orderItems, conflicts, nil
+}
+
+func validateSyncOrder(input *SyncOrderInput) error {
+    input.ClientUUID = strings.ToLower(strings.TrimSpace(input.ClientUUID))
+    if !uuidPattern.MatchString(input.ClientUUID) {
+        return errors.New("client uuid must be a valid UUID")
+    }
+
+    if input.ClientCreatedAt.IsZero() {
+        return errors.New("client created at is required")
+    }
+
+    if input.ClientCreatedAt.After(time.Now().Add(syncClockSkew)) {
+        return errors.New("client created at cannot be in the future")
+    }
+
+    if len(input.Items) == 0 {
+        return errors.New("at least one item is required")
+    }
+
+    if input.OrderType == "" {
+        input.OrderType = data.OrderTypeDineIn
+    }
+
+    // Una pre-orden cuya hora ya pasó entra como orden normal
+    if input.ScheduledFor != nil && !input.ScheduledFor.After(time.Now()) {
+        input.ScheduledFor = nil
+    }
+
+    // La hora de recogida pudo pasar mientras el POS estaba sin conexión
+    pickupTime := input.PickupTime
+    input.PickupTime = nil
+    err := validateOrderType(&input.CreateOrderInput)
+    input.PickupTime = pickupTime
+
+    return err
+}
+
+func parseSyncToken(token string) (int64, error) {
+    if token == "" {
+        return 0, nil
+    }
+
+    version, err := strconv.ParseInt(token, 10, 64)
+    if err != nil || version < 0 {
+        return 0, errors.New("invalid sync token")
+    }
+
+    return version, nil
+}
+
+// Número visible de las órdenes sincronizadas, derivado del UUID del POS
+func offlineOrderNumber(clientUUID string) string {
+    return "OFF-" + strings.ToUpper(clientUUID[:8])
+}
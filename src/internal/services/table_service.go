@@ -0,0 +1,207 @@
+package services
+
+import (
+    "errors"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type TableService struct {
+    tableRepo    *repository.TableRepository
+    orderRepo    *repository.OrderRepository
+    orderService *OrderService
+}
+
+func NewTableService(tableRepo *repository.TableRepository, orderRepo *repository.OrderRepository, orderService *OrderService) *TableService {
+    return &TableService{
+        tableRepo:    tableRepo,
+        orderRepo:    orderRepo,
+        orderService: orderService,
+    }
+}
+
+func (s *TableService) ListTables() ([]data.Table, error) {
+    return s.tableRepo.GetAll()
+}
+
+func (s *TableService) GetTable(id int32) (*data.Table, error) {
+    table, err := s.tableRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if table == nil {
+        return nil, errors.New("table not found")
+    }
+
+    return table, nil
+}
+
+func (s *TableService) CreateTable(number int32, zone string, capacity int32) (*data.Table, error) {
+    if err := validateTable(number, capacity); err != nil {
+        return nil, err
+    }
+
+    existing, err := s.tableRepo.GetByNumber(number)
+    if err != nil {
+        return nil, err
+    }
+
+    if existing != nil {
+        return nil, errors.New("table number already exists")
+    }
+
+    table := &data.Table{
+        Number:   number,
+        Zone:     zone,
+        Capacity: capacity,
+        Status:   data.TableStatusAvailable,
+    }
+
+    if err := s.tableRepo.Create(table); err != nil {
+        return nil, err
+    }
+
+    return table, nil
+}
+
+func (s *TableService) UpdateTable(table *data.Table) error {
+    if err := validateTable(table.Number, table.Capacity); err != nil {
+        return err
+    }
+
+    if !isValidTableStatus(table.Status) {
+        return errors.New("invalid table status")
+    }
+
+    existing, err := s.tableRepo.GetByNumber(table.Number)
+    if err != nil {
+        return err
+    }
+
+    if existing != nil && existing.ID != table.ID {
+        return errors.New("table number already exists")
+    }
+
+    return s.tableRepo.Update(table)
+}
+
+func (s *TableService) DeleteTable(id int32) error {
+    orders, err := s.orderRepo.GetOpenByTable(id)
+    if err != nil {
+        return err
+    }
+
+    if len(orders) > 0 {
+        return errors.New("table has open orders")
+    }
+
+    return s.tableRepo.Delete(id)
+}
+
+func (s *TableService) GetOpenOrders(tableID int32) ([]data.Order, error) {
+    if _, err := s.GetTable(tableID); err != nil {
+        return nil, err
+    }
+
+    return s.orderRepo.GetOpenByTable(tableID)
+}
+
+// Abre una cuenta (orden) sobre la mesa
+func (s *TableService) OpenOrder(tableID int32, notes string, partySize int32, items []OrderItemInput) (*OrderDetail, error) {
+    table, err := s.GetTable(tableID)
+    if err != nil {
+        return nil, err
+    }
+
+    if table.Status == data.TableStatusOutOfService {
+        return nil, errors.New("table is out of service")
+    }
+
+    return s.orderService.CreateOrder(CreateOrderInput{
+        Notes:     notes,
+        TableID:   &table.ID,
+        OrderType: data.OrderTypeDineIn,
+        PartySize: partySize,
+        Items:     items,
+    })
+}
+
+func (s *TableService) MoveOrder(orderID, tableID int32) error {
+    order, err := s.orderRepo.GetByID(orderID)
+    if err != nil {
+        return err
+    }
+
+    if order == nil {
+        return errors.New("order not found")
+    }
+
+    if order.OrderType != data.OrderTypeDineIn {
+        return errors.New("only dine-in orders can be moved between tables")
+    }
+
+    table, err := s.GetTable(tableID)
+    if err != nil {
+        return err
+    }
+
+    if table.Status == data.TableStatusOutOfService {
+        return errors.New("table is out of service")
+    }
+
+    return s.orderRepo.MoveToTable(orderID, tableID)
+}
+
+// Une las cuentas de la mesa origen en la cuenta abierta de la mesa destino.
+// Si la mesa destino no tiene cuenta, las órdenes de la mesa origen se mueven tal cual.
+func (s *TableService) MergeTables(sourceTableID, targetTableID int32) (*OrderDetail, error) {
+    if sourceTableID == targetTableID {
+        return nil, errors.New("cannot merge a table with itself")
+    }
+
+    if _, err := s.GetTable(sourceTableID); err != nil {
+        return nil, err
+    }
+
+    target, err := s.GetTable(targetTableID)
+    if err != nil {
+        return nil, err
+    }
+
+    if target.Status == data.TableStatusOutOfService {
+        return nil, errors.New("table is out of service")
+    }
+
+    orderID, err := s.orderRepo.MergeTables(sourceTableID, targetTableID)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.orderService.GetOrder(orderID)
+}
+
+func (s *TableService) GetFloorPlan() ([]data.TableOccupancy, error) {
+    return s.tableRepo.GetFloorPlan()
+}
+
+func validateTable(number, capacity int32) error {
+    if number <= 0 {
+        return errors.New("table number must be greater than zero")
+    }
+
+    if capacity <= 0 {
+        return errors.New("capacity must be greater than zero")
+    }
+
+    return nil
+}
+
+func isValidTableStatus(status string) bool {
+    switch status {
+    case data.TableStatusAvailable, data.TableStatusOccupied, data.TableStatusReserved, data.TableStatusOutOfService:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+    "errors"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type TaxService struct {
+    taxRepo *repository.TaxRepository
+}
+
+func NewTaxService(taxRepo *repository.TaxRepository) *TaxService {
+    return &TaxService{
+        taxRepo: taxRepo,
+    }
+}
+
+func (s *TaxService) ListTaxRates() ([]data.TaxRate, error) {
+    return s.taxRepo.GetAll()
+}
+
+func (s *TaxService) GetTaxRate(id int32) (*data.TaxRate, error) {
+    rate, err := s.taxRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if rate == nil {
+        return nil, errors.New("tax rate not found")
+    }
+
+    return rate, nil
+}
+
+func (s *TaxService) CreateTaxRate(rate *data.TaxRate) (*data.TaxRate, error) {
+    if err := validateTaxRate(rate); err != nil {
+        return nil, err
+    }
+
+    if err := s.taxRepo.Create(rate); err != nil {
+        return nil, err
+    }
+
+    return rate, nil
+}
+
+func (s *TaxService) UpdateTaxRate(id int32, rate *data.TaxRate) (*data.TaxRate, error) {
+    existing, err := s.GetTaxRate(id)
+    if err != nil {
+        return nil, err
+    }
+
+    rate.ID = existing.ID
+    rate.CreatedAt = existing.CreatedAt
+    if err := validateTaxRate(rate); err != nil {
+        return nil, err
+    }
+
+    if err := s.taxRepo.Update(rate); err != nil {
+        return nil, err
+    }
+
+    return rate, nil
+}
+
+// Asigna la tarifa de un producto; nil hace que use la de su categoría
+func (s *TaxService) AssignToProduct(productID int32, taxRateID *int32) error {
+    if err := s.checkAssignable(taxRateID); err != nil {
+        return err
+    }
+
+    return s.taxRepo.AssignToProduct(productID, taxRateID)
+}
+
+// Asigna la tarifa por defecto de una categoría; nil la deja exenta
+func (s *TaxService) AssignToCategory(categoryID int32, taxRateID *int32) error {
+    if err := s.checkAssignable(taxRateID); err != nil {
+        return err
+    }
+
+    return s.taxRepo.AssignToCategory(categoryID, taxRateID)
+}
+
+func (s *TaxService) GetTaxReport(filter repository.OrderFilter) ([]data.TaxSummary, error) {
+    if filter.OrderType != "" && !isValidOrderType(filter.OrderType) {
+        return nil, errors.New("invalid order type")
+    }
+
+    return s.taxRepo.GetReport(filter)
+}
+
+func (s *TaxService) checkAssignable(taxRateID *int32) error {
+    if taxRateID == nil {
+        return nil
+    }
+
+    rate, err := s.GetTaxRate(*taxRateID)
+    if err != nil {
+        return err
+    }
+
+    if !rate.IsActive {
+        return errors.New("tax rate is not active")
+    }
+
+    return nil
+}
+
+func validateTaxRate(rate *data.TaxRate) error {
+    rate.Name = strings.TrimSpace(rate.Name)
+    if rate.Name == "" {
+        return errors.New("tax rate name is required")
+    }
+
+    if rate.Rate < 0 || rate.Rate > 100 {
+        return errors.New("tax rate must be between 0 and 100")
+    }
+
+    return nil
+}
+
+// Valida el modo de precios configurado
+func IsValidTaxMode(mode string) bool {
+    return mode == data.TaxModeInclusive || mode == data.TaxModeExclusive
+}
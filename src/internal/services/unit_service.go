@@ -0,0 +1,253 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type UnitService struct {
+    unitRepo *repository.UnitRepository
+}
+
+func NewUnitService(unitRepo *repository.UnitRepository) *UnitService {
+    return &UnitService{
+        unitRepo: unitRepo,
+    }
+}
+
+func (s *UnitService) ListUnits() ([]data.MeasurementUnit, error) {
+    return s.unitRepo.GetAll()
+}
+
+func (s *UnitService) GetUnit(id int32) (*data.MeasurementUnit, error) {
+    unit, err := s.unitRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if unit == nil {
+        return nil, errors.New("unit not found")
+    }
+
+    return unit, nil
+}
+
+// Busca una unidad del catálogo por su código (kg, ml, un...)
+func (s *UnitService) GetUnitByCode(code string) (*data.MeasurementUnit, error) {
+    unit, err := s.unitRepo.GetByCode(strings.TrimSpace(code))
+    if err != nil {
+        return nil, err
+    }
+
+    if unit == nil {
+        return nil, fmt.Errorf("unit %s not found", code)
+    }
+
+    return unit, nil
+}
+
+// Indica si el código es una unidad del catálogo (y no texto libre heredado)
+func (s *UnitService) IsCatalogUnit(code string) (bool, error) {
+    unit, err := s.unitRepo.GetByCode(strings.TrimSpace(code))
+    if err != nil {
+        return false, err
+    }
+
+    return unit != nil, nil
+}
+
+func (s *UnitService) CreateUnit(unit *data.MeasurementUnit) (*data.MeasurementUnit, error) {
+    if err := s.validateUnit(unit); err != nil {
+        return nil, err
+    }
+
+    if err := s.unitRepo.Create(unit); err != nil {
+        return nil, err
+    }
+
+    return unit, nil
+}
+
+// Actualiza una unidad; el código, la dimensión y el factor no cambian si ya
+// está en uso (ingredientes, presentaciones u órdenes de compra)
+func (s *UnitService) UpdateUnit(id int32, unit *data.MeasurementUnit) (*data.MeasurementUnit, error) {
+    existing, err := s.GetUnit(id)
+    if err != nil {
+        return nil, err
+    }
+
+    unit.ID = existing.ID
+    if err := s.validateUnit(unit); err != nil {
+        return nil, err
+    }
+
+    if !strings.EqualFold(unit.Code, existing.Code) || unit.Dimension != existing.Dimension || unit.Factor != existing.Factor {
+        used, err := s.unitRepo.IsUsed(existing.Code)
+        if err != nil {
+            return nil, err
+        }
+        if used {
+            return nil, errors.New("unit code, dimension and factor cannot change while it is in use")
+        }
+    }
+
+    if err := s.unitRepo.Update(unit); err != nil {
+        return nil, err
+    }
+
+    return unit, nil
+}
+
+func (s *UnitService) DeleteUnit(id int32) error {
+    return s.unitRepo.Delete(id)
+}
+
+// Convierte una cantidad expresada en unit a la unidad del ingrediente.
+// unit puede ser un código del catálogo o el nombre de una presentación propia
+// del ingrediente; vacío significa que ya viene en la unidad del ingrediente.
+func (s *UnitService) ToIngredientUnit(ingredient *data.Ingredient, quantity float64, unit string) (float64, error) {
+    unit = strings.TrimSpace(unit)
+    if unit == "" || strings.EqualFold(unit, ingredient.Unit) {
+        return quantity, nil
+    }
+
+    pack, err := s.unitRepo.GetPackByName(ingredient.ID, unit)
+    if err != nil {
+        return 0, err
+    }
+    if pack != nil {
+        quantity *= pack.Quantity
+        unit = pack.Unit
+        if strings.EqualFold(unit, ingredient.Unit) {
+            return quantity, nil
+        }
+    }
+
+    from, err := s.GetUnitByCode(unit)
+    if err != nil {
+        return 0, err
+    }
+
+    to, err := s.GetUnitByCode(ingredient.Unit)
+    if err != nil {
+        return 0, err
+    }
+
+    return convertUnits(quantity, from, to)
+}
+
+func (s *UnitService) GetPacks(ingredientID int32) ([]data.PackUnit, error) {
+    return s.unitRepo.GetPacks(ingredientID)
+}
+
+// Agrega una presentación de compra al ingrediente; su unidad debe ser
+// compatible con la del ingrediente
+func (s *UnitService) CreatePack(ingredient *data.Ingredient, pack *data.PackUnit) (*data.PackUnit, error) {
+    pack.IngredientID = ingredient.ID
+    pack.Name = strings.TrimSpace(pack.Name)
+    if pack.Name == "" {
+        return nil, errors.New("pack name is required")
+    }
+
+    if pack.Quantity <= 0 {
+        return nil, errors.New("pack quantity must be greater than zero")
+    }
+
+    pack.Unit = strings.TrimSpace(pack.Unit)
+    if pack.Unit == "" {
+        pack.Unit = ingredient.Unit
+    }
+
+    from, err := s.GetUnitByCode(pack.Unit)
+    if err != nil {
+        return nil, err
+    }
+    pack.Unit = from.Code
+
+    to, err := s.GetUnitByCode(ingredient.Unit)
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err := convertUnits(pack.Quantity, from, to); err != nil {
+        return nil, err
+    }
+
+    if unit, err := s.unitRepo.GetByCode(pack.Name); err != nil {
+        return nil, err
+    } else if unit != nil {
+        return nil, errors.New("pack name clashes with a catalog unit")
+    }
+
+    existing, err := s.unitRepo.GetPackByName(ingredient.ID, pack.Name)
+    if err != nil {
+        return nil, err
+    }
+    if existing != nil {
+        return nil, errors.New("pack unit already exists")
+    }
+
+    if err := s.unitRepo.CreatePack(pack); err != nil {
+        return nil, err
+    }
+
+    return pack, nil
+}
+
+func (s *UnitService) DeletePack(ingredientID, packID int32) error {
+    return s.unitRepo.DeletePack(ingredientID, packID)
+}
+
+func (s *UnitService) validateUnit(unit *data.MeasurementUnit) error {
+    unit.Code = strings.TrimSpace(unit.Code)
+    if unit.Code == "" {
+        return errors.New("unit code is required")
+    }
+
+    unit.Name = strings.TrimSpace(unit.Name)
+    if unit.Name == "" {
+        return errors.New("unit name is required")
+    }
+
+    if !isValidDimension(unit.Dimension) {
+        return fmt.Errorf("invalid unit dimension %s", unit.Dimension)
+    }
+
+    if unit.Factor <= 0 {
+        return errors.New("unit factor must be greater than zero")
+    }
+
+    existing, err := s.unitRepo.GetByCode(unit.Code)
+    if err != nil {
+        return err
+    }
+
+    if existing != nil && existing.ID != unit.ID {
+        return errors.New("unit already exists")
+    }
+
+    return nil
+}
+
+func convertUnits(quantity float64, from, to *data.MeasurementUnit) (float64, error) {
+    if from.Dimension != to.Dimension {
+        return 0, fmt.Errorf("incompatible units %s and %s", from.Code, to.Code)
+    }
+
+    // Se redondea para que 0.1 kg no termine en 100.00000000000001 g
+    converted := quantity * from.Factor / to.Factor
+    return math.Round(converted*1e6) / 1e6, nil
+}
+
+func isValidDimension(dimension string) bool {
+    switch dimension {
+    case data.DimensionMass, data.DimensionVolume, data.DimensionCount:
+        return true
+    }
+    return false
+}
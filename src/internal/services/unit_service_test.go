@@ -0,0 +1,44 @@
+package services
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestConvertUnits(t *testing.T) {
+    kg := &data.MeasurementUnit{Code: "kg", Dimension: data.DimensionMass, Factor: 1000}
+    g := &data.MeasurementUnit{Code: "g", Dimension: data.DimensionMass, Factor: 1}
+    lb := &data.MeasurementUnit{Code: "lb", Dimension: data.DimensionMass, Factor: 453.59237}
+    l := &data.MeasurementUnit{Code: "l", Dimension: data.DimensionVolume, Factor: 1000}
+    ml := &data.MeasurementUnit{Code: "ml", Dimension: data.DimensionVolume, Factor: 1}
+
+    tests := []struct {
+        name     string
+        quantity float64
+        from     *data.MeasurementUnit
+        to       *data.MeasurementUnit
+        want     float64
+        wantErr  bool
+    }{
+        {name: "kilograms to grams", quantity: 0.1, from: kg, to: g, want: 100},
+        {name: "grams to kilograms", quantity: 250, from: g, to: kg, want: 0.25},
+        {name: "same unit", quantity: 3.5, from: ml, to: ml, want: 3.5},
+        {name: "milliliters to liters", quantity: 1, from: ml, to: l, want: 0.001},
+        {name: "rounds to six decimals", quantity: 1, from: lb, to: kg, want: 0.453592},
+        {name: "negative quantities keep the sign", quantity: -2, from: kg, to: g, want: -2000},
+        {name: "different dimensions", quantity: 1, from: kg, to: l, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := convertUnits(tt.quantity, tt.from, tt.to)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("convertUnits() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("convertUnits() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
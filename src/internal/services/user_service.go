@@ -60,6 +60,7 @@ func (s *UserService) CreateUser(username, password, fullName string) (*data.Use
         Username:     username,
         PasswordHash: passwordHash,
         FullName:     fullName,
+        Role:         data.UserRoleStaff,
     }
 
     if err := s.userRepo.Create(user); err != nil {
@@ -69,6 +70,22 @@ func (s *UserService) CreateUser(username, password, fullName string) (*data.Use
     return user, nil
 }
 
+// Asigna el rol de un usuario; solo un administrador puede hacerlo. El cambio
+// aplica desde el siguiente inicio de sesión o refresco del token.
+func (s *UserService) UpdateRole(actorRole string, id int32, role string) error {
+    if actorRole != data.UserRoleAdmin {
+        return fmt.Errorf("%w: only an admin can change user roles", ErrForbidden)
+    }
+
+    switch role {
+    case data.UserRoleStaff, data.UserRoleManager, data.UserRoleAdmin:
+    default:
+        return errors.New("role must be 'staff', 'manager' or 'admin'")
+    }
+
+    return s.userRepo.UpdateRole(id, role)
+}
+
 // Hash password usando Argon2
 func (s *UserService) hashPassword(password string) (string, error) {
     salt := make([]byte, 16)
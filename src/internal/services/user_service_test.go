@@ -0,0 +1,22 @@
+package services
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestUpdateRoleRequiresAdmin(t *testing.T) {
+    users := NewUserService(nil)
+
+    for _, actor := range []string{data.UserRoleStaff, data.UserRoleManager} {
+        if err := users.UpdateRole(actor, 1, data.UserRoleManager); !errors.Is(err, ErrForbidden) {
+            t.Errorf("UpdateRole() by %s error = %v, want ErrForbidden", actor, err)
+        }
+    }
+
+    if err := users.UpdateRole(data.UserRoleAdmin, 1, "owner"); err == nil {
+        t.Error("UpdateRole() error = nil, want an error for an unknown role")
+    }
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+    "github.com/pkgzx/liliApi/src/pkg/repository"
+)
+
+type WasteService struct {
+    wasteRepo         *repository.WasteRepository
+    productRepo       *repository.ProductRepository
+    ingredientService *IngredientService
+}
+
+func NewWasteService(wasteRepo *repository.WasteRepository, productRepo *repository.ProductRepository, ingredientService *IngredientService) *WasteService {
+    return &WasteService{
+        wasteRepo:         wasteRepo,
+        productRepo:       productRepo,
+        ingredientService: ingredientService,
+    }
+}
+
+// Merma de un ingrediente (Quantity en Unit, por defecto la del ingrediente)
+// o de un producto terminado (Quantity en unidades del producto)
+type WasteInput struct {
+    IngredientID *int32  `json:"ingredient_id,omitempty"`
+    ProductID    *int32  `json:"product_id,omitempty"`
+    Quantity     float64 `json:"quantity"`
+    Unit         string  `json:"unit"`
+    Reason       string  `json:"reason"`
+    Notes        string  `json:"notes"`
+    // Lote del que sale el ingrediente; sin él se consume por vencimiento
+    LotID *int32 `json:"lot_id,omitempty"`
+}
+
+type WasteDetail struct {
+    Record *data.WasteRecord `json:"record"`
+    Lines  []data.WasteLine  `json:"lines"`
+}
+
+func (s *WasteService) RecordWaste(input WasteInput, userID int32) (*WasteDetail, error) {
+    if (input.IngredientID == nil) == (input.ProductID == nil) {
+        return nil, errors.New("waste must be for either an ingredient or a product")
+    }
+
+    if input.Quantity <= 0 {
+        return nil, errors.New("waste quantity must be greater than zero")
+    }
+
+    if !isValidWasteReason(input.Reason) {
+        return nil, fmt.Errorf("invalid waste reason %s", input.Reason)
+    }
+
+    notes := strings.TrimSpace(input.Notes)
+    if input.Reason == data.WasteReasonOther && notes == "" {
+        return nil, errors.New("notes are required when the reason is other")
+    }
+
+    record := &data.WasteRecord{
+        IngredientID: input.IngredientID,
+        ProductID:    input.ProductID,
+        Quantity:     input.Quantity,
+        Reason:       input.Reason,
+        Notes:        notes,
+        LotID:        input.LotID,
+        UserID:       &userID,
+    }
+
+    if input.IngredientID != nil {
+        quantity, err := s.ingredientService.ToIngredientUnit(*input.IngredientID, input.Quantity, input.Unit)
+        if err != nil {
+            return nil, err
+        }
+        record.Quantity = quantity
+    } else {
+        if strings.TrimSpace(input.Unit) != "" {
+            return nil, errors.New("product waste is counted in product units")
+        }
+        if input.LotID != nil {
+            return nil, errors.New("a lot can only be given for ingredient waste")
+        }
+
+        product, err := s.productRepo.GetByID(*input.ProductID)
+        if err != nil {
+            return nil, err
+        }
+        if product == nil {
+            return nil, errors.New("product not found")
+        }
+    }
+
+    if err := s.wasteRepo.Create(record); err != nil {
+        return nil, err
+    }
+
+    return s.GetWaste(record.ID)
+}
+
+func (s *WasteService) ListWaste(filter repository.WasteFilter) ([]data.WasteRecord, error) {
+    if filter.Reason != "" && !isValidWasteReason(filter.Reason) {
+        return nil, fmt.Errorf("invalid waste reason %s", filter.Reason)
+    }
+
+    return s.wasteRepo.Find(filter)
+}
+
+func (s *WasteService) GetWaste(id int32) (*WasteDetail, error) {
+    record, err := s.wasteRepo.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if record == nil {
+        return nil, errors.New("waste record not found")
+    }
+
+    lines, err := s.wasteRepo.GetLines(id)
+    if err != nil {
+        return nil, err
+    }
+
+    return &WasteDetail{Record: record, Lines: lines}, nil
+}
+
+func (s *WasteService) GetWasteReport(from, to *time.Time) (*data.WasteReport, error) {
+    return s.wasteRepo.GetReport(from, to)
+}
+
+func isValidWasteReason(reason string) bool {
+    switch reason {
+    case data.WasteReasonBurnt, data.WasteReasonExpired, data.WasteReasonDropped, data.WasteReasonSpoiled, data.WasteReasonOther:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestIsValidWasteReason(t *testing.T) {
+    tests := []struct {
+        reason string
+        want   bool
+    }{
+        {data.WasteReasonBurnt, true},
+        {data.WasteReasonExpired, true},
+        {data.WasteReasonDropped, true},
+        {data.WasteReasonSpoiled, true},
+        {data.WasteReasonOther, true},
+        {"", false},
+        {"stolen", false},
+        {"Burnt", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.reason, func(t *testing.T) {
+            if got := isValidWasteReason(tt.reason); got != tt.want {
+                t.Errorf("isValidWasteReason(%q) = %v, want %v", tt.reason, got, tt.want)
+            }
+        })
+    }
+}
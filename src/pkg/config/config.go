@@ -3,12 +3,23 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
+	Database    DatabaseConfig
+	Server      ServerConfig
+	JWT         JWTConfig
+	Tax         TaxConfig
+	Service     ServiceChargeConfig
+	Receipt     ReceiptConfig
+	Feed        FeedConfig
+	Idempotency IdempotencyConfig
+	Sync        SyncConfig
+	Scheduler   SchedulerConfig
+	Inventory   InventoryConfig
+	StockAlerts StockAlertConfig
 }
 
 type DatabaseConfig struct {
@@ -29,6 +40,67 @@ type JWTConfig struct {
 	Secret string
 }
 
+type TaxConfig struct {
+	// "inclusive" si los precios del catálogo ya incluyen el impuesto, "exclusive" si no
+	PricingMode string
+}
+
+type FeedConfig struct {
+	// Horas que se conservan los eventos de órdenes para reproducirlos al reconectar
+	RetentionHours int
+}
+
+type IdempotencyConfig struct {
+	// Horas durante las que se reconoce una Idempotency-Key y se repite su respuesta
+	TTLHours int
+}
+
+type SchedulerConfig struct {
+	// Margen, además del tiempo de preparación, con el que se libera una pre-orden a cocina
+	LeadMinutes int
+	// Cada cuántos segundos se revisan las pre-órdenes
+	IntervalSeconds int
+}
+
+type InventoryConfig struct {
+	// Qué hacer con una salida que deja el stock en negativo: "block" la rechaza, "allow" la registra
+	NegativeStockPolicy string
+	// Estado de la orden en el que se descuentan los ingredientes de sus recetas
+	DeductOnStatus string
+}
+
+type StockAlertConfig struct {
+	// Cada cuántos segundos se revisa el stock de todos los ingredientes
+	IntervalSeconds int
+	// Canal de aviso: "log", "webhook" o "email"
+	Notifier   string
+	WebhookURL string
+	// Servidor SMTP local (host:puerto) y direcciones para el aviso por correo
+	SMTPAddr  string
+	EmailFrom string
+	EmailTo   []string
+}
+
+type SyncConfig struct {
+	// Precio que se respeta si un producto cambió mientras el POS estaba sin conexión: "client" o "server"
+	PricePolicy string
+}
+
+// Encabezado y pie impresos en los recibos
+type ReceiptConfig struct {
+	BusinessName string
+	TaxID        string
+	Address      string
+	Phone        string
+	Footer       string
+}
+
+type ServiceChargeConfig struct {
+	// Porcentaje aplicado a órdenes en mesa de más de PartySize comensales (0 = desactivado)
+	Rate      float64
+	PartySize int32
+}
+
 func Load() *Config {
 	return &Config{
 		Database: DatabaseConfig{
@@ -46,6 +118,45 @@ func Load() *Config {
 		JWT: JWTConfig{
 			Secret: getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
 		},
+		Tax: TaxConfig{
+			PricingMode: getEnv("TAX_PRICING_MODE", "inclusive"),
+		},
+		Service: ServiceChargeConfig{
+			Rate:      getEnvFloat("SERVICE_CHARGE_RATE", 0),
+			PartySize: int32(getEnvInt("SERVICE_CHARGE_PARTY_SIZE", 5)),
+		},
+		Receipt: ReceiptConfig{
+			BusinessName: getEnv("RECEIPT_BUSINESS_NAME", "Lili"),
+			TaxID:        getEnv("RECEIPT_TAX_ID", ""),
+			Address:      getEnv("RECEIPT_ADDRESS", ""),
+			Phone:        getEnv("RECEIPT_PHONE", ""),
+			Footer:       getEnv("RECEIPT_FOOTER", "¡Gracias por su compra!"),
+		},
+		Feed: FeedConfig{
+			RetentionHours: getEnvInt("ORDER_EVENTS_RETENTION_HOURS", 24),
+		},
+		Idempotency: IdempotencyConfig{
+			TTLHours: getEnvInt("IDEMPOTENCY_KEY_TTL_HOURS", 24),
+		},
+		Scheduler: SchedulerConfig{
+			LeadMinutes:     getEnvInt("SCHEDULED_ORDER_LEAD_MINUTES", 10),
+			IntervalSeconds: getEnvInt("ORDER_SCHEDULER_INTERVAL_SECONDS", 60),
+		},
+		Sync: SyncConfig{
+			PricePolicy: getEnv("SYNC_PRICE_POLICY", "server"),
+		},
+		Inventory: InventoryConfig{
+			NegativeStockPolicy: getEnv("INVENTORY_NEGATIVE_STOCK", "block"),
+			DeductOnStatus:      getEnv("INVENTORY_DEDUCT_ON_STATUS", "preparing"),
+		},
+		StockAlerts: StockAlertConfig{
+			IntervalSeconds: getEnvInt("STOCK_ALERT_INTERVAL_SECONDS", 300),
+			Notifier:        getEnv("STOCK_ALERT_NOTIFIER", "log"),
+			WebhookURL:      getEnv("STOCK_ALERT_WEBHOOK_URL", ""),
+			SMTPAddr:        getEnv("STOCK_ALERT_SMTP_ADDR", "localhost:25"),
+			EmailFrom:       getEnv("STOCK_ALERT_EMAIL_FROM", ""),
+			EmailTo:         getEnvList("STOCK_ALERT_EMAIL_TO"),
+		},
 	}
 }
 
@@ -60,3 +171,28 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// Lista separada por comas; vacía si la variable no está definida
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -7,12 +7,21 @@ type User struct {
     Username    string    `json:"username" db:"username"`
     PasswordHash string   `json:"password_hash" db:"password_hash"`
     FullName    string    `json:"full_name" db:"full_name"`
+    Role        string    `json:"role" db:"role"`
     CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// Roles de usuario; los nuevos usuarios entran como staff
+const (
+    UserRoleStaff   = "staff"
+    UserRoleManager = "manager"
+    UserRoleAdmin   = "admin"
+)
+
 type Category struct {
     ID        int32     `json:"id" db:"id"`
     Name      string    `json:"name" db:"name"`
+    TaxRateID *int32    `json:"tax_rate_id,omitempty" db:"tax_rate_id"`
     CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -24,6 +33,12 @@ type Product struct {
     CategoryID  int32     `json:"category_id" db:"category_id"`
     ImageURL    string    `json:"image_url" db:"image_url"`
     IsAvailable bool      `json:"is_available" db:"is_available"`
+    // Impuesto propio del producto; si es nil aplica el de su categoría
+    TaxRateID *int32 `json:"tax_rate_id,omitempty" db:"tax_rate_id"`
+    // Estación de cocina que lo prepara
+    StationID *int32 `json:"station_id,omitempty" db:"station_id"`
+    // Minutos estimados de preparación de una línea del pedido
+    PrepMinutes int32     `json:"prep_minutes" db:"prep_minutes"`
     CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -37,30 +52,777 @@ type Ingredient struct {
     CreatedAt     time.Time `json:"created_at" db:"created_at"`
 }
 
+// Dimensiones de las unidades de medida; solo se convierte dentro de la misma
+const (
+    DimensionMass   = "mass"
+    DimensionVolume = "volume"
+    DimensionCount  = "count"
+)
+
+// Unidad del catálogo. Factor es su equivalencia en la unidad base de su
+// dimensión (g, ml, unidad): kg = 1000, l = 1000, docena = 12.
+type MeasurementUnit struct {
+    ID        int32   `json:"id" db:"id"`
+    Code      string  `json:"code" db:"code"`
+    Name      string  `json:"name" db:"name"`
+    Dimension string  `json:"dimension" db:"dimension"`
+    Factor    float64 `json:"factor" db:"factor"`
+}
+
+// Presentación de compra propia de un ingrediente ("bulto" = 25 kg)
+type PackUnit struct {
+    ID           int32   `json:"id" db:"id"`
+    IngredientID int32   `json:"ingredient_id" db:"ingredient_id"`
+    Name         string  `json:"name" db:"name"`
+    Quantity     float64 `json:"quantity" db:"quantity"`
+    Unit         string  `json:"unit" db:"unit"` // código del catálogo en que se expresa Quantity
+}
+
+// Cantidad de un ingrediente que lleva una unidad de producto, en la unidad del ingrediente
+type RecipeItem struct {
+    ProductID      int32   `json:"product_id" db:"product_id"`
+    IngredientID   int32   `json:"ingredient_id" db:"ingredient_id"`
+    IngredientName string  `json:"ingredient_name" db:"ingredient_name"`
+    Unit           string  `json:"unit" db:"unit"`
+    Quantity       float64 `json:"quantity" db:"quantity"`
+}
+
+type Supplier struct {
+    ID          int32     `json:"id" db:"id"`
+    Name        string    `json:"name" db:"name"`
+    ContactName string    `json:"contact_name" db:"contact_name"`
+    Phone       string    `json:"phone" db:"phone"`
+    Email       string    `json:"email" db:"email"`
+    IsActive    bool      `json:"is_active" db:"is_active"`
+    CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Estados de una orden de compra
+const (
+    PurchaseOrderDraft             = "draft"
+    PurchaseOrderSent              = "sent"
+    PurchaseOrderPartiallyReceived = "partially_received"
+    PurchaseOrderReceived          = "received"
+    PurchaseOrderCancelled         = "cancelled"
+)
+
+type PurchaseOrder struct {
+    ID           int32      `json:"id" db:"id"`
+    SupplierID   int32      `json:"supplier_id" db:"supplier_id"`
+    SupplierName string     `json:"supplier_name" db:"supplier_name"`
+    Status       string     `json:"status" db:"status"`
+    Notes        string     `json:"notes" db:"notes"`
+    ExpectedAt   *time.Time `json:"expected_at,omitempty" db:"expected_at"`
+    // Total pactado: cantidades pedidas por costo acordado
+    Total      float64    `json:"total" db:"total"`
+    CreatedBy  *int32     `json:"created_by,omitempty" db:"created_by"`
+    SentAt     *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+    ReceivedAt *time.Time `json:"received_at,omitempty" db:"received_at"`
+    CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+    UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type PurchaseOrderItem struct {
+    ID               int32   `json:"id" db:"id"`
+    PurchaseOrderID  int32   `json:"purchase_order_id" db:"purchase_order_id"`
+    IngredientID     int32   `json:"ingredient_id" db:"ingredient_id"`
+    IngredientName   string  `json:"ingredient_name" db:"ingredient_name"`
+    Unit             string  `json:"unit" db:"unit"`
+    QuantityOrdered  float64 `json:"quantity_ordered" db:"quantity_ordered"`
+    QuantityReceived float64 `json:"quantity_received" db:"quantity_received"`
+    UnitCost         float64 `json:"unit_cost" db:"unit_cost"` // costo acordado por unidad
+    // Cantidad y unidad tal como se pidieron al proveedor (las de arriba van en la unidad del ingrediente)
+    PurchaseQuantity float64 `json:"purchase_quantity" db:"purchase_quantity"`
+    PurchaseUnit     string  `json:"purchase_unit" db:"purchase_unit"`
+}
+
+// Tipos de diferencia entre lo pedido y lo recibido
+const (
+    DiscrepancyShort = "short" // llegó menos de lo pedido y la orden se cerró así
+    DiscrepancyOver  = "over"  // llegó más de lo pedido
+    DiscrepancyCost  = "cost"  // se facturó a un costo distinto del acordado
+)
+
+type PurchaseDiscrepancy struct {
+    ID                  int32     `json:"id" db:"id"`
+    PurchaseOrderID     int32     `json:"purchase_order_id" db:"purchase_order_id"`
+    PurchaseOrderItemID int32     `json:"purchase_order_item_id" db:"purchase_order_item_id"`
+    IngredientID        int32     `json:"ingredient_id" db:"ingredient_id"`
+    Type                string    `json:"type" db:"type"`
+    Expected            float64   `json:"expected" db:"expected"`
+    Actual              float64   `json:"actual" db:"actual"`
+    Notes               string    `json:"notes" db:"notes"`
+    CreatedAt           time.Time `json:"created_at" db:"created_at"`
+}
+
+// Estados de una alerta de stock bajo
+const (
+    StockAlertOpen         = "open"
+    StockAlertAcknowledged = "acknowledged" // alguien ya la vio; sigue activa hasta reponer
+    StockAlertResolved     = "resolved"
+)
+
+// Alerta por un ingrediente que quedó en o por debajo de su mínimo
+type StockAlert struct {
+    ID             int32   `json:"id" db:"id"`
+    IngredientID   int32   `json:"ingredient_id" db:"ingredient_id"`
+    IngredientName string  `json:"ingredient_name" db:"ingredient_name"`
+    Unit           string  `json:"unit" db:"unit"`
+    // Existencias y mínimo al momento de levantar la alerta
+    StockQuantity  float64    `json:"stock_quantity" db:"stock_quantity"`
+    MinStock       float64    `json:"min_stock" db:"min_stock"`
+    Status         string     `json:"status" db:"status"`
+    NotifiedAt     *time.Time `json:"notified_at,omitempty" db:"notified_at"`
+    AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+    AcknowledgedBy *int32     `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+    ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+    ResolvedBy     *int32     `json:"resolved_by,omitempty" db:"resolved_by"`
+    CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Producto incluido en un combo; al vender el combo se descuenta su receta
+type BundleComponent struct {
+    BundleID    int32  `json:"bundle_id" db:"bundle_id"`
+    ProductID   int32  `json:"product_id" db:"product_id"`
+    ProductName string `json:"product_name" db:"product_name"`
+    Quantity    int32  `json:"quantity" db:"quantity"`
+}
+
+// Modificador que se puede pedir con un producto ("extra queso", "sin
+// cebolla"). Ajusta el precio del item y, si tiene ingrediente, lo que
+// descuenta del inventario: Quantity por unidad de producto, en la unidad del
+// ingrediente; negativa cuando quita ingrediente de la receta.
+type ProductModifier struct {
+    ID           int32   `json:"id" db:"id"`
+    ProductID    int32   `json:"product_id" db:"product_id"`
+    Name         string  `json:"name" db:"name"`
+    PriceDelta   float64 `json:"price_delta" db:"price_delta"`
+    IngredientID *int32  `json:"ingredient_id,omitempty" db:"ingredient_id"`
+    Unit         string  `json:"unit,omitempty" db:"unit"`
+    Quantity     float64 `json:"quantity" db:"quantity"`
+}
+
+// Modificador aplicado a un item; guarda los datos vigentes al venderlo para
+// que el descuento de inventario no cambie si luego se edita el modificador
+type OrderItemModifier struct {
+    OrderItemID  int32   `json:"order_item_id" db:"order_item_id"`
+    ModifierID   int32   `json:"modifier_id" db:"modifier_id"`
+    Name         string  `json:"name" db:"name"`
+    PriceDelta   float64 `json:"price_delta" db:"price_delta"`
+    IngredientID *int32  `json:"ingredient_id,omitempty" db:"ingredient_id"`
+    Quantity     float64 `json:"quantity" db:"quantity"`
+}
+
+// Estados de una orden
+const (
+    // Pre-orden a futuro; pasa a pending cuando el programador la libera a cocina
+    OrderStatusScheduled = "scheduled"
+    OrderStatusPending   = "pending"
+    OrderStatusPreparing = "preparing"
+    OrderStatusReady     = "ready"
+    OrderStatusDelivered = "delivered"
+    OrderStatusClosed    = "closed"
+    OrderStatusCancelled = "cancelled"
+    OrderStatusMerged    = "merged"
+)
+
+// Tipos de orden
+const (
+    OrderTypeDineIn   = "dine_in"
+    OrderTypeTakeaway = "takeaway"
+    OrderTypeDelivery = "delivery"
+)
+
+// Estado de pago de una orden
+const (
+    PaymentStatusUnpaid        = "unpaid"
+    PaymentStatusPartiallyPaid = "partially_paid"
+    PaymentStatusPaid          = "paid"
+)
+
 type Order struct {
     ID          int32     `json:"id" db:"id"`
     OrderNumber string    `json:"order_number" db:"order_number"`
     Status      string    `json:"status" db:"status"`
     TotalAmount float64   `json:"total_amount" db:"total_amount"`
     Notes       string    `json:"notes" db:"notes"`
+    TableID     *int32    `json:"table_id,omitempty" db:"table_id"`
+    OrderType   string    `json:"order_type" db:"order_type"`
+    // Datos de domicilio
+    CustomerAddress string  `json:"customer_address,omitempty" db:"customer_address"`
+    CustomerPhone   string  `json:"customer_phone,omitempty" db:"customer_phone"`
+    DeliveryFee     float64 `json:"delivery_fee" db:"delivery_fee"`
+    // Hora de recogida para pedidos para llevar
+    PickupTime    *time.Time `json:"pickup_time,omitempty" db:"pickup_time"`
+    // Hora a la que debe estar lista una pre-orden programada
+    ScheduledFor  *time.Time `json:"scheduled_for,omitempty" db:"scheduled_for"`
+    PaymentStatus string     `json:"payment_status" db:"payment_status"`
+    // Descuentos a nivel de orden (incluye cupones)
+    DiscountAmount float64 `json:"discount_amount" db:"discount_amount"`
+    // Modo de precios con el que se creó la orden y total de impuestos
+    TaxMode   string  `json:"tax_mode" db:"tax_mode"`
+    TaxAmount float64 `json:"tax_amount" db:"tax_amount"`
+    // Cargo por servicio automático para mesas grandes
+    PartySize         int32     `json:"party_size" db:"party_size"`
+    ServiceChargeRate float64   `json:"service_charge_rate" db:"service_charge_rate"`
+    ServiceCharge     float64   `json:"service_charge" db:"service_charge"`
+    // Último tiempo (entradas, fuertes, postres) enviado a cocina
+    FiredCourse int32     `json:"fired_course" db:"fired_course"`
     CreatedAt   time.Time `json:"created_at" db:"created_at"`
-    UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+    UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+    // Estimación según la cola de cocina; solo para órdenes pendientes o en preparación
+    EstimatedReadyAt *time.Time `json:"estimated_ready_at,omitempty"`
+    EstimatedMinutes *int32     `json:"estimated_minutes,omitempty"`
 }
 
 type OrderItem struct {
-    ID        int32   `json:"id" db:"id"`
-    OrderID   int32   `json:"order_id" db:"order_id"`
-    ProductID int32   `json:"product_id" db:"product_id"`
-    Quantity  int32   `json:"quantity" db:"quantity"`
-    UnitPrice float64 `json:"unit_price" db:"unit_price"`
-    Subtotal  float64 `json:"subtotal" db:"subtotal"`
+    ID             int32   `json:"id" db:"id"`
+    OrderID        int32   `json:"order_id" db:"order_id"`
+    ProductID      int32   `json:"product_id" db:"product_id"`
+    Quantity       int32   `json:"quantity" db:"quantity"`
+    UnitPrice      float64 `json:"unit_price" db:"unit_price"`
+    DiscountAmount float64 `json:"discount_amount" db:"discount_amount"`
+    Subtotal       float64 `json:"subtotal" db:"subtotal"` // quantity * unit_price - discount_amount
+    // Tarifa vigente al agregar el item
+    TaxRateID *int32  `json:"tax_rate_id,omitempty" db:"tax_rate_id"`
+    TaxRate   float64 `json:"tax_rate" db:"tax_rate"`
+    // Estación asignada al agregar el item y su estado de preparación
+    StationID *int32 `json:"station_id,omitempty" db:"station_id"`
+    Status    string `json:"status" db:"status"`
+    // Indicaciones para cocina ("sin cebolla") y tiempo del servicio.
+    // El tiempo 0 sale siempre con la orden (bebidas); los demás esperan a que
+    // el mesero los marche y hasta entonces FiredAt es nil.
+    Notes   string     `json:"notes,omitempty" db:"notes"`
+    Course  int32      `json:"course" db:"course"`
+    FiredAt *time.Time `json:"fired_at,omitempty" db:"fired_at"`
+    // Momento en que la estación empezó a prepararlo
+    StartedAt *time.Time `json:"started_at,omitempty" db:"started_at"`
+    // true cuando ya se descontaron del inventario los ingredientes de su receta
+    StockDeducted bool `json:"stock_deducted" db:"stock_deducted"`
+    // Modificadores pedidos; su precio ya está incluido en UnitPrice
+    Modifiers []OrderItemModifier `json:"modifiers,omitempty" db:"-"`
 }
 
+// Estados de preparación de un item
+const (
+    OrderItemStatusPending   = "pending"
+    OrderItemStatusPreparing = "preparing"
+    OrderItemStatusReady     = "ready"
+)
+
+// Tipos de movimiento de inventario
+const (
+    MovementTypeEntrada = "entrada"
+    MovementTypeSalida  = "salida"
+    MovementTypeAjuste  = "ajuste" // la cantidad lleva signo: positiva suma, negativa resta
+)
+
 type InventoryMovement struct {
     ID           int32     `json:"id" db:"id"`
     IngredientID int32     `json:"ingredient_id" db:"ingredient_id"`
     MovementType string    `json:"movement_type" db:"movement_type"` // "entrada", "salida", "ajuste"
     Quantity     float64   `json:"quantity" db:"quantity"`
     Reason       string    `json:"reason" db:"reason"`
+    // Quién lo registró y existencias del ingrediente después del movimiento
+    UserID     *int32    `json:"user_id,omitempty" db:"user_id"`
+    StockAfter float64   `json:"stock_after" db:"stock_after"`
+    // Orden e item que originaron el movimiento (descuentos por venta y sus reversiones)
+    OrderID     *int32    `json:"order_id,omitempty" db:"order_id"`
+    OrderItemID *int32    `json:"order_item_id,omitempty" db:"order_item_id"`
+    // Salida que esta entrada devuelve (cancelación de una orden)
+    ReversalOf *int32 `json:"reversal_of,omitempty" db:"reversal_of"`
+    // Orden de compra cuya recepción originó la entrada
+    PurchaseOrderID *int32    `json:"purchase_order_id,omitempty" db:"purchase_order_id"`
+    // Conteo físico cuya aprobación originó el ajuste
+    StockTakeID *int32 `json:"stock_take_id,omitempty" db:"stock_take_id"`
+    // Lote que creó una entrada o que se dio de baja; las salidas normales se
+    // reparten entre lotes (ver LotConsumption)
+    LotID     *int32     `json:"lot_id,omitempty" db:"lot_id"`
+    LotNumber string     `json:"lot_number,omitempty" db:"lot_number"`
+    ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+    CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Lote de un ingrediente. Lo crean las entradas de mercancía (compras y
+// manuales); las que no traen número ni vencimiento quedan como lotes sin
+// vencimiento y se consumen al final. Los ajustes positivos no crean lote.
+type InventoryLot struct {
+    ID                int32      `json:"id" db:"id"`
+    IngredientID      int32      `json:"ingredient_id" db:"ingredient_id"`
+    IngredientName    string     `json:"ingredient_name" db:"ingredient_name"`
+    Unit              string     `json:"unit" db:"unit"`
+    LotNumber         string     `json:"lot_number" db:"lot_number"`
+    ExpiresAt         *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+    QuantityReceived  float64    `json:"quantity_received" db:"quantity_received"`
+    QuantityRemaining float64    `json:"quantity_remaining" db:"quantity_remaining"`
+    PurchaseOrderID   *int32     `json:"purchase_order_id,omitempty" db:"purchase_order_id"`
+    CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Motivos de merma
+const (
+    WasteReasonBurnt   = "burnt"
+    WasteReasonExpired = "expired"
+    WasteReasonDropped = "dropped"
+    WasteReasonSpoiled = "spoiled"
+    WasteReasonOther   = "other"
+)
+
+// Merma de un ingrediente o de un producto terminado (en ese caso se descargan
+// los ingredientes de su receta). Cost se valoriza al costo del momento.
+type WasteRecord struct {
+    ID           int32     `json:"id" db:"id"`
+    IngredientID *int32    `json:"ingredient_id,omitempty" db:"ingredient_id"`
+    ProductID    *int32    `json:"product_id,omitempty" db:"product_id"`
+    ItemName     string    `json:"item_name" db:"item_name"`
+    Quantity     float64   `json:"quantity" db:"quantity"`
+    Unit         string    `json:"unit" db:"unit"` // vacío para productos
+    Reason       string    `json:"reason" db:"reason"`
+    Notes        string    `json:"notes" db:"notes"`
+    Cost         float64   `json:"cost" db:"cost"`
+    LotID        *int32    `json:"lot_id,omitempty" db:"lot_id"`
+    UserID       *int32    `json:"user_id,omitempty" db:"user_id"`
+    UserName     string    `json:"user_name" db:"user_name"`
     CreatedAt    time.Time `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+}
+
+// Ingrediente descargado por una merma
+type WasteLine struct {
+    ID             int32   `json:"id" db:"id"`
+    WasteID        int32   `json:"waste_id" db:"waste_id"`
+    IngredientID   int32   `json:"ingredient_id" db:"ingredient_id"`
+    IngredientName string  `json:"ingredient_name" db:"ingredient_name"`
+    Unit           string  `json:"unit" db:"unit"`
+    Quantity       float64 `json:"quantity" db:"quantity"`
+    UnitCost       float64 `json:"unit_cost" db:"unit_cost"`
+    Cost           float64 `json:"cost" db:"cost"`
+    MovementID     int32   `json:"movement_id" db:"movement_id"`
+}
+
+type WasteReasonSummary struct {
+    Reason  string  `json:"reason"`
+    Records int32   `json:"records"`
+    Cost    float64 `json:"cost"`
+}
+
+type WasteIngredientSummary struct {
+    IngredientID int32   `json:"ingredient_id"`
+    Name         string  `json:"name"`
+    Unit         string  `json:"unit"`
+    Quantity     float64 `json:"quantity"`
+    Cost         float64 `json:"cost"`
+}
+
+type WasteStaffSummary struct {
+    StaffID  int32   `json:"staff_id"`
+    FullName string  `json:"full_name"`
+    Records  int32   `json:"records"`
+    Cost     float64 `json:"cost"`
+}
+
+// Costo de las mermas de un periodo por motivo, ingrediente y empleado
+type WasteReport struct {
+    TotalCost    float64                  `json:"total_cost"`
+    ByReason     []WasteReasonSummary     `json:"by_reason"`
+    ByIngredient []WasteIngredientSummary `json:"by_ingredient"`
+    ByStaff      []WasteStaffSummary      `json:"by_staff"`
+}
+
+// Parte de una salida que se tomó de un lote
+type LotConsumption struct {
+    LotID      int32   `json:"lot_id" db:"lot_id"`
+    MovementID int32   `json:"movement_id" db:"movement_id"`
+    Quantity   float64 `json:"quantity" db:"quantity"`
+}
+
+// Estados de un conteo físico
+const (
+    StockTakeOpen      = "open"
+    StockTakeApproved  = "approved"
+    StockTakeCancelled = "cancelled"
+)
+
+type StockTake struct {
+    ID         int32      `json:"id" db:"id"`
+    Status     string     `json:"status" db:"status"`
+    Notes      string     `json:"notes" db:"notes"`
+    CreatedBy  *int32     `json:"created_by,omitempty" db:"created_by"`
+    ApprovedBy *int32     `json:"approved_by,omitempty" db:"approved_by"`
+    ApprovedAt *time.Time `json:"approved_at,omitempty" db:"approved_at"`
+    // Valor de todos los ajustes al aprobar; negativo es faltante
+    TotalVarianceValue float64   `json:"total_variance_value" db:"total_variance_value"`
+    CreatedAt          time.Time `json:"created_at" db:"created_at"`
+}
+
+// Línea de un conteo. SnapshotQuantity es el stock al abrir el conteo y
+// ExpectedQuantity el stock al momento de registrar lo contado, así los
+// movimientos que ocurren mientras se cuenta no aparecen como diferencia.
+type StockTakeLine struct {
+    ID               int32      `json:"id" db:"id"`
+    StockTakeID      int32      `json:"stock_take_id" db:"stock_take_id"`
+    IngredientID     int32      `json:"ingredient_id" db:"ingredient_id"`
+    IngredientName   string     `json:"ingredient_name" db:"ingredient_name"`
+    Unit             string     `json:"unit" db:"unit"`
+    SnapshotQuantity float64    `json:"snapshot_quantity" db:"snapshot_quantity"`
+    CountedQuantity  *float64   `json:"counted_quantity,omitempty" db:"counted_quantity"`
+    ExpectedQuantity *float64   `json:"expected_quantity,omitempty" db:"expected_quantity"`
+    MovedDuringCount *float64   `json:"moved_during_count,omitempty" db:"moved_during_count"`
+    Variance         *float64   `json:"variance,omitempty" db:"variance"`
+    // Hasta la aprobación se muestran con el costo actual del ingrediente
+    UnitCost      *float64   `json:"unit_cost,omitempty" db:"unit_cost"`
+    VarianceValue *float64   `json:"variance_value,omitempty" db:"variance_value"`
+    CountedBy     *int32     `json:"counted_by,omitempty" db:"counted_by"`
+    CountedAt     *time.Time `json:"counted_at,omitempty" db:"counted_at"`
+    MovementID    *int32     `json:"movement_id,omitempty" db:"movement_id"`
+}
+
+// Estados de una mesa
+const (
+    TableStatusAvailable    = "available"
+    TableStatusOccupied     = "occupied"
+    TableStatusReserved     = "reserved"
+    TableStatusOutOfService = "out_of_service"
+)
+
+type Table struct {
+    ID        int32     `json:"id" db:"id"`
+    Number    int32     `json:"number" db:"number"`
+    Zone      string    `json:"zone" db:"zone"`
+    Capacity  int32     `json:"capacity" db:"capacity"`
+    Status    string    `json:"status" db:"status"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Ocupación de una mesa para el plano del salón
+type TableOccupancy struct {
+    Table        Table      `json:"table"`
+    OpenOrders   int32      `json:"open_orders"`
+    RunningTotal float64    `json:"running_total"`
+    OpenedAt     *time.Time `json:"opened_at,omitempty"`
+}
+
+// Resumen de ventas por tipo de orden
+type SalesByType struct {
+    OrderType     string  `json:"order_type"`
+    Orders        int32   `json:"orders"`
+    ItemsSubtotal float64 `json:"items_subtotal"`
+    Tax            float64 `json:"tax"` // incluido en items_subtotal si los precios incluyen impuesto
+    ServiceCharges float64 `json:"service_charges"`
+    DeliveryFees   float64 `json:"delivery_fees"`
+    Total         float64 `json:"total"`
+}
+
+// Estados de una subcuenta
+const (
+    SplitStatusOpen = "open"
+    SplitStatusPaid = "paid"
+)
+
+// Subcuenta de una orden dividida
+type OrderSplit struct {
+    ID          int32            `json:"id" db:"id"`
+    OrderID     int32            `json:"order_id" db:"order_id"`
+    SplitNumber int32            `json:"split_number" db:"split_number"`
+    Amount      float64          `json:"amount" db:"amount"`
+    Status      string           `json:"status" db:"status"`
+    PaidAt      *time.Time       `json:"paid_at,omitempty" db:"paid_at"`
+    CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+    Items       []OrderSplitItem `json:"items,omitempty" db:"-"`
+}
+
+// Porción de un item asignada a una subcuenta (permite cantidades parciales)
+type OrderSplitItem struct {
+    ID          int32   `json:"id" db:"id"`
+    SplitID     int32   `json:"split_id" db:"split_id"`
+    OrderItemID int32   `json:"order_item_id" db:"order_item_id"`
+    Quantity    float64 `json:"quantity" db:"quantity"`
+    Amount      float64 `json:"amount" db:"amount"`
+}
+
+// Medios de pago
+const (
+    PaymentMethodCash     = "cash"
+    PaymentMethodCard     = "card"
+    PaymentMethodTransfer = "transfer"
+)
+
+// Estados de un pago
+const (
+    PaymentCompleted = "completed"
+    PaymentReversed  = "reversed"
+)
+
+type Payment struct {
+    ID             int32      `json:"id" db:"id"`
+    OrderID        int32      `json:"order_id" db:"order_id"`
+    SplitID        *int32     `json:"split_id,omitempty" db:"split_id"`
+    ShiftID        *int32     `json:"shift_id,omitempty" db:"shift_id"`
+    UserID         int32      `json:"user_id" db:"user_id"`
+    Method         string     `json:"method" db:"method"`
+    Amount         float64    `json:"amount" db:"amount"`
+    Tendered       float64    `json:"tendered" db:"tendered"`
+    Change         float64    `json:"change" db:"change_amount"`
+    Reference      string     `json:"reference,omitempty" db:"reference"`
+    // Propina cobrada junto al pago; no cuenta como venta
+    TipAmount      float64    `json:"tip" db:"tip_amount"`
+    TipStaffID     *int32     `json:"tip_staff_id,omitempty" db:"tip_staff_id"`
+    Status         string     `json:"status" db:"status"`
+    ReversalReason string     `json:"reversal_reason,omitempty" db:"reversal_reason"`
+    ReversedAt     *time.Time `json:"reversed_at,omitempty" db:"reversed_at"`
+    CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Estados de un turno de caja
+const (
+    ShiftStatusOpen   = "open"
+    ShiftStatusClosed = "closed"
+)
+
+// Tipos de movimiento de efectivo en caja
+const (
+    CashMovementIn  = "in"
+    CashMovementOut = "out"
+)
+
+type CashShift struct {
+    ID           int32      `json:"id" db:"id"`
+    Register     string     `json:"register" db:"register"`
+    UserID       int32      `json:"user_id" db:"user_id"`
+    OpeningFloat float64    `json:"opening_float" db:"opening_float"`
+    Status       string     `json:"status" db:"status"`
+    ExpectedCash *float64   `json:"expected_cash,omitempty" db:"expected_cash"`
+    CountedCash  *float64   `json:"counted_cash,omitempty" db:"counted_cash"`
+    Discrepancy  *float64   `json:"discrepancy,omitempty" db:"discrepancy"`
+    Notes        string     `json:"notes" db:"notes"`
+    OpenedAt     time.Time  `json:"opened_at" db:"opened_at"`
+    ClosedAt     *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+// Entrada o salida de efectivo que no corresponde a un pago
+type CashMovement struct {
+    ID        int32     `json:"id" db:"id"`
+    ShiftID   int32     `json:"shift_id" db:"shift_id"`
+    UserID    int32     `json:"user_id" db:"user_id"`
+    Type      string    `json:"type" db:"type"`
+    Amount    float64   `json:"amount" db:"amount"`
+    Reason    string    `json:"reason" db:"reason"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type TenderTotal struct {
+    Method string  `json:"method"`
+    Count  int32   `json:"count"`
+    Amount float64 `json:"amount"`
+    Tips   float64 `json:"tips"`
+}
+
+// Reporte de cierre (Z) de un turno de caja
+type ShiftReport struct {
+    Shift        CashShift     `json:"shift"`
+    Tenders      []TenderTotal `json:"tenders"`
+    CashSales    float64       `json:"cash_sales"`
+    Tips         float64       `json:"tips"`
+    CashTips     float64       `json:"cash_tips"`
+    CashIn       float64       `json:"cash_in"`
+    CashOut      float64       `json:"cash_out"`
+    ExpectedCash float64       `json:"expected_cash"`
+    CountedCash  *float64      `json:"counted_cash,omitempty"`
+    Discrepancy  *float64      `json:"discrepancy,omitempty"`
+}
+
+// Tipos de descuento
+const (
+    DiscountTypePercentage = "percentage"
+    DiscountTypeFixed      = "fixed"
+)
+
+// Alcance de un descuento
+const (
+    DiscountScopeItem  = "item"
+    DiscountScopeOrder = "order"
+)
+
+// Descuento predefinido (ej. "Empleado 15%")
+type DiscountPreset struct {
+    ID        int32     `json:"id" db:"id"`
+    Name      string    `json:"name" db:"name"`
+    Type      string    `json:"type" db:"type"`
+    Value     float64   `json:"value" db:"value"`
+    Scope     string    `json:"scope" db:"scope"`
+    IsActive  bool      `json:"is_active" db:"is_active"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type Coupon struct {
+    ID                 int32      `json:"id" db:"id"`
+    Code               string     `json:"code" db:"code"`
+    Description        string     `json:"description" db:"description"`
+    Type               string     `json:"type" db:"type"`
+    Value              float64    `json:"value" db:"value"`
+    MinOrderAmount     float64    `json:"min_order_amount" db:"min_order_amount"`
+    ValidFrom          *time.Time `json:"valid_from,omitempty" db:"valid_from"`
+    ValidUntil         *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+    MaxUses            int32      `json:"max_uses" db:"max_uses"`                           // 0 = sin límite
+    MaxUsesPerCustomer int32      `json:"max_uses_per_customer" db:"max_uses_per_customer"` // 0 = sin límite
+    TimesUsed          int32      `json:"times_used" db:"times_used"`
+    IsActive           bool       `json:"is_active" db:"is_active"`
+    CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Descuento aplicado a una orden o a uno de sus items
+type OrderDiscount struct {
+    ID          int32     `json:"id" db:"id"`
+    OrderID     int32     `json:"order_id" db:"order_id"`
+    OrderItemID *int32    `json:"order_item_id,omitempty" db:"order_item_id"`
+    PresetID    *int32    `json:"preset_id,omitempty" db:"preset_id"`
+    CouponID    *int32    `json:"coupon_id,omitempty" db:"coupon_id"`
+    Name        string    `json:"name" db:"name"`
+    Type        string    `json:"type" db:"type"`
+    Value       float64   `json:"value" db:"value"`
+    Amount      float64   `json:"amount" db:"amount"`
+    CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Modo de precios: los precios del catálogo incluyen o no el impuesto
+const (
+    TaxModeInclusive = "inclusive"
+    TaxModeExclusive = "exclusive"
+)
+
+// Tarifa de impuesto (ej. "IVA 19%")
+type TaxRate struct {
+    ID        int32     `json:"id" db:"id"`
+    Name      string    `json:"name" db:"name"`
+    Rate      float64   `json:"rate" db:"rate"` // porcentaje
+    IsActive  bool      `json:"is_active" db:"is_active"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Desglose de impuestos de una orden por tarifa
+type OrderTax struct {
+    ID        int32   `json:"id" db:"id"`
+    OrderID   int32   `json:"order_id" db:"order_id"`
+    TaxRateID *int32  `json:"tax_rate_id,omitempty" db:"tax_rate_id"`
+    Name      string  `json:"name" db:"name"`
+    Rate      float64 `json:"rate" db:"rate"`
+    Base      float64 `json:"base" db:"base"`
+    Amount    float64 `json:"amount" db:"amount"`
+}
+
+// Impuestos acumulados por tarifa en un periodo
+type TaxSummary struct {
+    TaxRateID *int32  `json:"tax_rate_id,omitempty"`
+    Name      string  `json:"name"`
+    Rate      float64 `json:"rate"`
+    Orders    int32   `json:"orders"`
+    Base      float64 `json:"base"`
+    Amount    float64 `json:"amount"`
+}
+
+// Propinas acumuladas por empleado para su reparto
+type TipSummary struct {
+    StaffID  int32   `json:"staff_id"`
+    FullName string  `json:"full_name"`
+    Payments int32   `json:"payments"`
+    CashTips float64 `json:"cash_tips"`
+    Total    float64 `json:"total"`
+}
+
+// Tipos de evento del feed de órdenes
+const (
+    OrderEventCreated       = "order.created"
+    OrderEventUpdated       = "order.updated"
+    OrderEventStatusChanged = "order.status_changed"
+)
+
+type OrderEvent struct {
+    ID          int64     `json:"id" db:"id"`
+    TxID        int64     `json:"-" db:"tx_id"` // transacción que lo registró; ordena el feed
+    OrderID     int32     `json:"order_id" db:"order_id"`
+    OrderNumber string    `json:"order_number" db:"order_number"`
+    OrderType   string    `json:"order_type" db:"order_type"`
+    Type        string    `json:"type" db:"type"`
+    Status      string    `json:"status" db:"status"`
+    CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Estación de preparación (parrilla, bar, cocina fría...)
+type KitchenStation struct {
+    ID        int32     `json:"id" db:"id"`
+    Name      string    `json:"name" db:"name"`
+    IsActive  bool      `json:"is_active" db:"is_active"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Comanda: items de una orden que prepara una estación
+type StationTicket struct {
+    StationID   *int32       `json:"station_id,omitempty"`
+    StationName string       `json:"station_name"`
+    OrderID     int32        `json:"order_id"`
+    OrderNumber string       `json:"order_number"`
+    OrderType   string       `json:"order_type"`
+    TableID     *int32       `json:"table_id,omitempty"`
+    Notes       string       `json:"notes,omitempty"`
+    CreatedAt   time.Time    `json:"created_at"`
+    Items       []TicketItem `json:"items"`
+}
+
+type TicketItem struct {
+    OrderItemID int32  `json:"order_item_id"`
+    ProductID   int32  `json:"product_id"`
+    ProductName string `json:"product_name"`
+    Quantity    int32  `json:"quantity"`
+    Status      string `json:"status"`
+    Notes       string `json:"notes,omitempty"`
+    Course      int32  `json:"course"`
+    // false mientras el tiempo del item no se haya marchado
+    Fired bool `json:"fired"`
+}
+
+// Item aún no listo de una orden en cocina, en orden de llegada
+type KitchenQueueItem struct {
+    OrderID     int32
+    StationID   *int32
+    PrepMinutes int32
+    // Momento en que el item pasó a preparación; nil si sigue pendiente
+    StartedAt *time.Time
+}
+
+// Respuesta guardada para una Idempotency-Key; StatusCode es 0 mientras
+// la petición original sigue en curso
+type IdempotencyKey struct {
+    UserID       int32     `json:"user_id" db:"user_id"`
+    Key          string    `json:"key" db:"key"`
+    Method       string    `json:"method" db:"method"`
+    Path         string    `json:"path" db:"path"`
+    RequestHash  string    `json:"request_hash" db:"request_hash"`
+    StatusCode   int       `json:"status_code" db:"status_code"`
+    ResponseBody []byte    `json:"-" db:"response_body"`
+    CreatedAt    time.Time `json:"created_at" db:"created_at"`
+    ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// Orden creada sin conexión en un POS y subida por sincronización
+type SyncedOrder struct {
+    ClientUUID      string    `json:"client_uuid" db:"client_uuid"`
+    DeviceID        string    `json:"device_id" db:"device_id"`
+    OrderID         int32     `json:"order_id" db:"order_id"`
+    ClientCreatedAt time.Time `json:"client_created_at" db:"client_created_at"`
+    SyncedAt        time.Time `json:"synced_at" db:"synced_at"`
+}
+
+// Entidades del catálogo registradas en catalog_changes
+const (
+    CatalogEntityProduct  = "product"
+    CatalogEntityCategory = "category"
+)
+
+// Cambios del catálogo desde una versión; Full indica que se envía completo
+type CatalogDelta struct {
+    Version            int64      `json:"-"`
+    Full               bool       `json:"full"`
+    Products           []Product  `json:"products"`
+    DeletedProductIDs  []int32    `json:"deleted_product_ids"`
+    Categories         []Category `json:"categories"`
+    DeletedCategoryIDs []int32    `json:"deleted_category_ids"`
+}
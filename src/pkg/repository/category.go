@@ -19,7 +19,7 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 
 func (r *CategoryRepository) GetAll() ([]data.Category, error) {
     query := `
-        SELECT id, name, created_at 
+        SELECT id, name, tax_rate_id, created_at 
         FROM categories 
         ORDER BY name
     `
@@ -40,7 +40,7 @@ func (r *CategoryRepository) GetAll() ([]data.Category, error) {
 
 func (r *CategoryRepository) GetByID(id int32) (*data.Category, error) {
     query := `
-        SELECT id, name, created_at 
+        SELECT id, name, tax_rate_id, created_at 
         FROM categories 
         WHERE id = $1
     `
@@ -49,6 +49,7 @@ func (r *CategoryRepository) GetByID(id int32) (*data.Category, error) {
     err := r.db.QueryRow(query, id).Scan(
         &category.ID,
         &category.Name,
+        &category.TaxRateID,
         &category.CreatedAt,
     )
     
@@ -0,0 +1,109 @@
+package repository
+
+import (
+    "database/sql"
+    "os"
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+// Las pruebas transaccionales corren contra PostgreSQL solo si TEST_DATABASE_URL
+// apunta a una base desechable: el esquema de testdata la borra y la recrea.
+func openTestDB(t *testing.T) *sql.DB {
+    t.Helper()
+
+    url := os.Getenv("TEST_DATABASE_URL")
+    if url == "" {
+        t.Skip("TEST_DATABASE_URL not set")
+    }
+
+    db, err := sql.Open("postgres", url)
+    if err != nil {
+        t.Fatalf("error opening test database: %v", err)
+    }
+    t.Cleanup(func() { db.Close() })
+
+    schema, err := os.ReadFile("testdata/schema.sql")
+    if err != nil {
+        t.Fatalf("error reading test schema: %v", err)
+    }
+    if _, err := db.Exec(string(schema)); err != nil {
+        t.Fatalf("error loading test schema: %v", err)
+    }
+
+    return db
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+    t.Helper()
+    if _, err := db.Exec(query, args...); err != nil {
+        t.Fatalf("error executing %q: %v", query, err)
+    }
+}
+
+// Inserta una fila con RETURNING id y devuelve el id
+func mustInsert(t *testing.T, db *sql.DB, query string, args ...any) int32 {
+    t.Helper()
+    var id int32
+    if err := db.QueryRow(query, args...).Scan(&id); err != nil {
+        t.Fatalf("error executing %q: %v", query, err)
+    }
+    return id
+}
+
+func mustQueryFloat(t *testing.T, db *sql.DB, query string, args ...any) float64 {
+    t.Helper()
+    var value float64
+    if err := db.QueryRow(query, args...).Scan(&value); err != nil {
+        t.Fatalf("error executing %q: %v", query, err)
+    }
+    return value
+}
+
+// Producto con receta de un solo ingrediente
+func insertTestProduct(t *testing.T, db *sql.DB, name string, price float64, ingredientID int32, quantity float64) int32 {
+    t.Helper()
+    id := mustInsert(t, db, `INSERT INTO products (name, price) VALUES ($1, $2) RETURNING id`, name, price)
+    if ingredientID != 0 {
+        mustExec(t, db, `INSERT INTO product_recipes (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)`, id, ingredientID, quantity)
+    }
+    return id
+}
+
+func insertTestIngredient(t *testing.T, db *sql.DB, name string, stock float64) int32 {
+    t.Helper()
+    return mustInsert(t, db, `INSERT INTO ingredients (name, unit, stock_quantity) VALUES ($1, 'g', $2) RETURNING id`, name, stock)
+}
+
+// Orden en mesa, pendiente y sin pagar, creada por el repositorio
+func createTestOrder(t *testing.T, repo *OrderRepository, tableID int32, items ...data.OrderItem) *data.Order {
+    t.Helper()
+
+    for i := range items {
+        items[i].Subtotal = items[i].UnitPrice * float64(items[i].Quantity)
+    }
+
+    order := &data.Order{
+        Status:        data.OrderStatusPending,
+        TableID:       &tableID,
+        OrderType:     data.OrderTypeDineIn,
+        PaymentStatus: data.PaymentStatusUnpaid,
+        TaxMode:       data.TaxModeExclusive,
+    }
+    if err := repo.CreateWithItems(order, items); err != nil {
+        t.Fatalf("CreateWithItems() error = %v", err)
+    }
+
+    return order
+}
+
+func insertTestTable(t *testing.T, db *sql.DB, number int32) int32 {
+    t.Helper()
+    return mustInsert(t, db, `INSERT INTO restaurant_tables (number) VALUES ($1) RETURNING id`, number)
+}
+
+func insertTestUser(t *testing.T, db *sql.DB, username string) int32 {
+    t.Helper()
+    return mustInsert(t, db, `INSERT INTO users (username, password_hash) VALUES ($1, 'x') RETURNING id`, username)
+}
@@ -0,0 +1,558 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type DiscountRepository struct {
+    *BaseRepository
+}
+
+func NewDiscountRepository(db *sql.DB) *DiscountRepository {
+    return &DiscountRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+// ---- Descuentos predefinidos ----
+
+func (r *DiscountRepository) GetAllPresets() ([]data.DiscountPreset, error) {
+    query := `
+        SELECT id, name, type, value, scope, is_active, created_at
+        FROM discount_presets
+        ORDER BY name
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying discount presets: %w", err)
+    }
+    defer rows.Close()
+
+    var presets []data.DiscountPreset
+    if err := ScanRowsToStruct(rows, &presets); err != nil {
+        return nil, fmt.Errorf("error scanning discount presets: %w", err)
+    }
+
+    return presets, nil
+}
+
+func (r *DiscountRepository) GetPresetByID(id int32) (*data.DiscountPreset, error) {
+    query := `
+        SELECT id, name, type, value, scope, is_active, created_at
+        FROM discount_presets
+        WHERE id = $1
+    `
+
+    var preset data.DiscountPreset
+    err := r.db.QueryRow(query, id).Scan(
+        &preset.ID,
+        &preset.Name,
+        &preset.Type,
+        &preset.Value,
+        &preset.Scope,
+        &preset.IsActive,
+        &preset.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting discount preset: %w", err)
+    }
+
+    return &preset, nil
+}
+
+func (r *DiscountRepository) CreatePreset(preset *data.DiscountPreset) error {
+    query := `
+        INSERT INTO discount_presets (name, type, value, scope, is_active)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(
+        query,
+        preset.Name,
+        preset.Type,
+        preset.Value,
+        preset.Scope,
+        preset.IsActive,
+    ).Scan(&preset.ID, &preset.CreatedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating discount preset: %w", err)
+    }
+
+    return nil
+}
+
+func (r *DiscountRepository) UpdatePreset(preset *data.DiscountPreset) error {
+    query := `
+        UPDATE discount_presets
+        SET name = $2, type = $3, value = $4, scope = $5, is_active = $6
+        WHERE id = $1
+    `
+
+    result, err := r.db.Exec(
+        query,
+        preset.ID,
+        preset.Name,
+        preset.Type,
+        preset.Value,
+        preset.Scope,
+        preset.IsActive,
+    )
+
+    if err != nil {
+        return fmt.Errorf("error updating discount preset: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("discount preset not found")
+    }
+
+    return nil
+}
+
+// ---- Cupones ----
+
+const couponColumns = `id, code, description, type, value, min_order_amount, valid_from, valid_until,
+        max_uses, max_uses_per_customer, times_used, is_active, created_at`
+
+func scanCoupon(row interface{ Scan(...any) error }, coupon *data.Coupon) error {
+    return row.Scan(
+        &coupon.ID,
+        &coupon.Code,
+        &coupon.Description,
+        &coupon.Type,
+        &coupon.Value,
+        &coupon.MinOrderAmount,
+        &coupon.ValidFrom,
+        &coupon.ValidUntil,
+        &coupon.MaxUses,
+        &coupon.MaxUsesPerCustomer,
+        &coupon.TimesUsed,
+        &coupon.IsActive,
+        &coupon.CreatedAt,
+    )
+}
+
+func (r *DiscountRepository) GetAllCoupons() ([]data.Coupon, error) {
+    rows, err := r.db.Query(`SELECT ` + couponColumns + ` FROM coupons ORDER BY created_at DESC`)
+    if err != nil {
+        return nil, fmt.Errorf("error querying coupons: %w", err)
+    }
+    defer rows.Close()
+
+    var coupons []data.Coupon
+    if err := ScanRowsToStruct(rows, &coupons); err != nil {
+        return nil, fmt.Errorf("error scanning coupons: %w", err)
+    }
+
+    return coupons, nil
+}
+
+func (r *DiscountRepository) GetCouponByID(id int32) (*data.Coupon, error) {
+    return r.getCoupon(`SELECT `+couponColumns+` FROM coupons WHERE id = $1`, id)
+}
+
+func (r *DiscountRepository) GetCouponByCode(code string) (*data.Coupon, error) {
+    return r.getCoupon(`SELECT `+couponColumns+` FROM coupons WHERE UPPER(code) = UPPER($1)`, code)
+}
+
+func (r *DiscountRepository) getCoupon(query string, args ...any) (*data.Coupon, error) {
+    var coupon data.Coupon
+    err := scanCoupon(r.db.QueryRow(query, args...), &coupon)
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting coupon: %w", err)
+    }
+
+    return &coupon, nil
+}
+
+func (r *DiscountRepository) CreateCoupon(coupon *data.Coupon) error {
+    query := `
+        INSERT INTO coupons (code, description, type, value, min_order_amount, valid_from, valid_until,
+                             max_uses, max_uses_per_customer, is_active)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, times_used, created_at
+    `
+
+    err := r.db.QueryRow(
+        query,
+        coupon.Code,
+        coupon.Description,
+        coupon.Type,
+        coupon.Value,
+        coupon.MinOrderAmount,
+        coupon.ValidFrom,
+        coupon.ValidUntil,
+        coupon.MaxUses,
+        coupon.MaxUsesPerCustomer,
+        coupon.IsActive,
+    ).Scan(&coupon.ID, &coupon.TimesUsed, &coupon.CreatedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating coupon: %w", err)
+    }
+
+    return nil
+}
+
+func (r *DiscountRepository) UpdateCoupon(coupon *data.Coupon) error {
+    query := `
+        UPDATE coupons
+        SET code = $2, description = $3, type = $4, value = $5, min_order_amount = $6,
+            valid_from = $7, valid_until = $8, max_uses = $9, max_uses_per_customer = $10, is_active = $11
+        WHERE id = $1
+    `
+
+    result, err := r.db.Exec(
+        query,
+        coupon.ID,
+        coupon.Code,
+        coupon.Description,
+        coupon.Type,
+        coupon.Value,
+        coupon.MinOrderAmount,
+        coupon.ValidFrom,
+        coupon.ValidUntil,
+        coupon.MaxUses,
+        coupon.MaxUsesPerCustomer,
+        coupon.IsActive,
+    )
+
+    if err != nil {
+        return fmt.Errorf("error updating coupon: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("coupon not found")
+    }
+
+    return nil
+}
+
+// ---- Descuentos aplicados a órdenes ----
+
+const orderDiscountColumns = `id, order_id, order_item_id, preset_id, coupon_id, name, type, value, amount, created_at`
+
+func (r *DiscountRepository) GetByOrder(orderID int32) ([]data.OrderDiscount, error) {
+    rows, err := r.db.Query(`SELECT `+orderDiscountColumns+` FROM order_discounts WHERE order_id = $1 ORDER BY id`, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order discounts: %w", err)
+    }
+    defer rows.Close()
+
+    var discounts []data.OrderDiscount
+    if err := ScanRowsToStruct(rows, &discounts); err != nil {
+        return nil, fmt.Errorf("error scanning order discounts: %w", err)
+    }
+
+    return discounts, nil
+}
+
+func getOrderDiscountsTx(tx *sql.Tx, orderID int32) ([]data.OrderDiscount, error) {
+    rows, err := tx.Query(`SELECT `+orderDiscountColumns+` FROM order_discounts WHERE order_id = $1 ORDER BY id`, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order discounts: %w", err)
+    }
+    defer rows.Close()
+
+    var discounts []data.OrderDiscount
+    if err := ScanRowsToStruct(rows, &discounts); err != nil {
+        return nil, fmt.Errorf("error scanning order discounts: %w", err)
+    }
+
+    return discounts, nil
+}
+
+// Aplica un descuento a la orden y recalcula sus totales
+func (r *DiscountRepository) AddToOrder(discount *data.OrderDiscount) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := checkOrderDiscountableTx(tx, discount.OrderID); err != nil {
+        return err
+    }
+
+    if err := insertOrderDiscountTx(tx, discount); err != nil {
+        return err
+    }
+
+    if _, err := recalculateOrderTotalTx(tx, discount.OrderID); err != nil {
+        return err
+    }
+
+    if err := publishOrderEventTx(tx, discount.OrderID, data.OrderEventUpdated); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Canjea un cupón sobre la orden validando monto mínimo y límites de uso de forma atómica
+func (r *DiscountRepository) RedeemCoupon(discount *data.OrderDiscount, coupon *data.Coupon, customerRef string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := checkOrderDiscountableTx(tx, discount.OrderID); err != nil {
+        return err
+    }
+
+    var alreadyApplied bool
+    err = tx.QueryRow(
+        `SELECT EXISTS (SELECT 1 FROM order_discounts WHERE order_id = $1 AND coupon_id IS NOT NULL)`,
+        discount.OrderID,
+    ).Scan(&alreadyApplied)
+    if err != nil {
+        return fmt.Errorf("error checking order coupons: %w", err)
+    }
+    if alreadyApplied {
+        return fmt.Errorf("order already has a coupon")
+    }
+
+    var itemsSubtotal float64
+    err = tx.QueryRow(
+        `SELECT COALESCE(SUM(subtotal), 0) FROM order_items WHERE order_id = $1`,
+        discount.OrderID,
+    ).Scan(&itemsSubtotal)
+    if err != nil {
+        return fmt.Errorf("error getting order subtotal: %w", err)
+    }
+    if itemsSubtotal < coupon.MinOrderAmount {
+        return fmt.Errorf("order does not reach the coupon minimum of %.2f", coupon.MinOrderAmount)
+    }
+
+    // El cupón queda bloqueado hasta el commit: dos canjes simultáneos del mismo
+    // cliente no pueden pasar ambos el conteo de usos
+    var couponID int32
+    if err := tx.QueryRow(`SELECT id FROM coupons WHERE id = $1 FOR UPDATE`, coupon.ID).Scan(&couponID); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("coupon not found")
+        }
+        return fmt.Errorf("error locking coupon: %w", err)
+    }
+
+    if coupon.MaxUsesPerCustomer > 0 {
+        var uses int32
+        err := tx.QueryRow(
+            `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND customer_ref = $2`,
+            coupon.ID, customerRef,
+        ).Scan(&uses)
+        if err != nil {
+            return fmt.Errorf("error checking coupon redemptions: %w", err)
+        }
+        if uses >= coupon.MaxUsesPerCustomer {
+            return fmt.Errorf("coupon usage limit reached for this customer")
+        }
+    }
+
+    // Incremento condicionado para respetar el límite global con concurrencia
+    result, err := tx.Exec(`
+        UPDATE coupons
+        SET times_used = times_used + 1
+        WHERE id = $1 AND (max_uses = 0 OR times_used < max_uses)
+    `, coupon.ID)
+    if err != nil {
+        return fmt.Errorf("error updating coupon usage: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+    if rowsAffected == 0 {
+        return fmt.Errorf("coupon usage limit reached")
+    }
+
+    _, err = tx.Exec(
+        `INSERT INTO coupon_redemptions (coupon_id, order_id, customer_ref) VALUES ($1, $2, $3)`,
+        coupon.ID, discount.OrderID, customerRef,
+    )
+    if err != nil {
+        return fmt.Errorf("error creating coupon redemption: %w", err)
+    }
+
+    if err := insertOrderDiscountTx(tx, discount); err != nil {
+        return err
+    }
+
+    if _, err := recalculateOrderTotalTx(tx, discount.OrderID); err != nil {
+        return err
+    }
+
+    if err := publishOrderEventTx(tx, discount.OrderID, data.OrderEventUpdated); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Quita un descuento de la orden; si era un cupón libera su uso
+func (r *DiscountRepository) RemoveFromOrder(orderID, discountID int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := checkOrderDiscountableTx(tx, orderID); err != nil {
+        return err
+    }
+
+    var couponID *int32
+    err = tx.QueryRow(
+        `DELETE FROM order_discounts WHERE id = $1 AND order_id = $2 RETURNING coupon_id`,
+        discountID, orderID,
+    ).Scan(&couponID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order discount not found")
+        }
+        return fmt.Errorf("error deleting order discount: %w", err)
+    }
+
+    if couponID != nil {
+        if err := releaseCouponsTx(tx, orderID); err != nil {
+            return err
+        }
+    }
+
+    if _, err := recalculateOrderTotalTx(tx, orderID); err != nil {
+        return err
+    }
+
+    if err := publishOrderEventTx(tx, orderID, data.OrderEventUpdated); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func insertOrderDiscountTx(tx *sql.Tx, discount *data.OrderDiscount) error {
+    if discount.OrderItemID != nil {
+        var belongs bool
+        err := tx.QueryRow(
+            `SELECT EXISTS (SELECT 1 FROM order_items WHERE id = $1 AND order_id = $2)`,
+            *discount.OrderItemID, discount.OrderID,
+        ).Scan(&belongs)
+        if err != nil {
+            return fmt.Errorf("error checking order item: %w", err)
+        }
+        if !belongs {
+            return fmt.Errorf("order item not found in order")
+        }
+    }
+
+    query := `
+        INSERT INTO order_discounts (order_id, order_item_id, preset_id, coupon_id, name, type, value, amount)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, 0)
+        RETURNING id, created_at
+    `
+
+    err := tx.QueryRow(
+        query,
+        discount.OrderID,
+        discount.OrderItemID,
+        discount.PresetID,
+        discount.CouponID,
+        discount.Name,
+        discount.Type,
+        discount.Value,
+    ).Scan(&discount.ID, &discount.CreatedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating order discount: %w", err)
+    }
+
+    return nil
+}
+
+// Los descuentos solo se modifican en órdenes abiertas, sin pagos ni división
+func checkOrderDiscountableTx(tx *sql.Tx, orderID int32) error {
+    var status, paymentStatus string
+    err := tx.QueryRow(
+        `SELECT status, payment_status FROM orders WHERE id = $1 FOR UPDATE`,
+        orderID,
+    ).Scan(&status, &paymentStatus)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
+        return fmt.Errorf("error locking order: %w", err)
+    }
+
+    switch {
+    case status == data.OrderStatusClosed || status == data.OrderStatusCancelled || status == data.OrderStatusMerged:
+        return fmt.Errorf("order is already closed")
+    case paymentStatus != data.PaymentStatusUnpaid:
+        return fmt.Errorf("order has payments, discounts cannot be changed")
+    }
+
+    var split bool
+    if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM order_splits WHERE order_id = $1)`, orderID).Scan(&split); err != nil {
+        return fmt.Errorf("error checking order splits: %w", err)
+    }
+    if split {
+        return fmt.Errorf("order has been split")
+    }
+
+    return nil
+}
+
+// Libera los cupones canjeados por la orden (al quitarlos o cancelar la orden)
+func releaseCouponsTx(tx *sql.Tx, orderID int32) error {
+    _, err := tx.Exec(`
+        UPDATE coupons c
+        SET times_used = GREATEST(c.times_used - 1, 0)
+        FROM coupon_redemptions cr
+        WHERE cr.coupon_id = c.id AND cr.order_id = $1
+    `, orderID)
+    if err != nil {
+        return fmt.Errorf("error releasing coupon usage: %w", err)
+    }
+
+    if _, err := tx.Exec(`DELETE FROM coupon_redemptions WHERE order_id = $1`, orderID); err != nil {
+        return fmt.Errorf("error deleting coupon redemptions: %w", err)
+    }
+
+    return nil
+}
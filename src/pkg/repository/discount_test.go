@@ -0,0 +1,41 @@
+package repository
+
+import (
+    "sync"
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestConcurrentRedemptionsRespectCustomerLimit(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    discounts := NewDiscountRepository(db)
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    couponID := mustInsert(t, db, `
+        INSERT INTO coupons (code, type, value, max_uses_per_customer) VALUES ('HOLA', 'fixed', 3, 1) RETURNING id
+    `)
+    coupon := &data.Coupon{ID: couponID, Code: "HOLA", Type: data.DiscountTypeFixed, Value: 3, MaxUsesPerCustomer: 1}
+
+    var wg sync.WaitGroup
+    errs := make([]error, 2)
+    for i := range errs {
+        order := createTestOrder(t, orders, insertTestTable(t, db, int32(i+1)), data.OrderItem{ProductID: product, Quantity: 1, UnitPrice: 10})
+        discount := &data.OrderDiscount{OrderID: order.ID, CouponID: &couponID, Name: coupon.Code, Type: coupon.Type, Value: coupon.Value}
+
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            errs[i] = discounts.RedeemCoupon(discount, coupon, "ana")
+        }(i)
+    }
+    wg.Wait()
+
+    if (errs[0] == nil) == (errs[1] == nil) {
+        t.Fatalf("RedeemCoupon() errors = %v, want exactly one redemption to fail", errs)
+    }
+    if got := mustQueryFloat(t, db, `SELECT COUNT(*) FROM coupon_redemptions WHERE customer_ref = 'ana'`); got != 1 {
+        t.Errorf("redemptions = %v, want 1", got)
+    }
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type IdempotencyRepository struct {
+    *BaseRepository
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+    return &IdempotencyRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+// Intenta reservar la clave para una petición nueva. Si ya existe una
+// vigente la devuelve sin modificarla; una vencida se reemplaza, igual que una
+// en curso desde hace más que lease (el proceso que la tomó cayó sin guardar
+// la respuesta).
+func (r *IdempotencyRepository) Reserve(record *data.IdempotencyKey, lease time.Duration) (*data.IdempotencyKey, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return nil, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec(`
+        DELETE FROM idempotency_keys
+        WHERE user_id = $1 AND key = $2
+          AND (expires_at <= NOW() OR (status_code IS NULL AND created_at <= NOW() - make_interval(secs => $3)))
+    `, record.UserID, record.Key, lease.Seconds())
+    if err != nil {
+        return nil, fmt.Errorf("error deleting expired idempotency key: %w", err)
+    }
+
+    err = tx.QueryRow(`
+        INSERT INTO idempotency_keys (user_id, key, method, path, request_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, key) DO NOTHING
+        RETURNING created_at
+    `, record.UserID, record.Key, record.Method, record.Path, record.RequestHash, record.ExpiresAt).Scan(&record.CreatedAt)
+
+    var existing *data.IdempotencyKey
+    switch {
+    case err == sql.ErrNoRows:
+        existing, err = getIdempotencyKeyTx(tx, record.UserID, record.Key)
+        if err != nil {
+            return nil, err
+        }
+    case err != nil:
+        return nil, fmt.Errorf("error reserving idempotency key: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return existing, nil
+}
+
+func getIdempotencyKeyTx(tx *sql.Tx, userID int32, key string) (*data.IdempotencyKey, error) {
+    var record data.IdempotencyKey
+    err := tx.QueryRow(`
+        SELECT user_id, key, method, path, request_hash, COALESCE(status_code, 0),
+               COALESCE(response_body, ''::bytea), created_at, expires_at
+        FROM idempotency_keys
+        WHERE user_id = $1 AND key = $2
+    `, userID, key).Scan(
+        &record.UserID,
+        &record.Key,
+        &record.Method,
+        &record.Path,
+        &record.RequestHash,
+        &record.StatusCode,
+        &record.ResponseBody,
+        &record.CreatedAt,
+        &record.ExpiresAt,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("error getting idempotency key: %w", err)
+    }
+
+    return &record, nil
+}
+
+// Guarda la respuesta que se devolverá en los reintentos
+func (r *IdempotencyRepository) Complete(userID int32, key string, statusCode int, body []byte) error {
+    _, err := r.db.Exec(`
+        UPDATE idempotency_keys
+        SET status_code = $3, response_body = $4
+        WHERE user_id = $1 AND key = $2 AND status_code IS NULL
+    `, userID, key, statusCode, body)
+    if err != nil {
+        return fmt.Errorf("error saving idempotency response: %w", err)
+    }
+
+    return nil
+}
+
+// Libera una clave en curso para que un reintento vuelva a ejecutar la petición
+func (r *IdempotencyRepository) Release(userID int32, key string) error {
+    _, err := r.db.Exec(
+        `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL`,
+        userID, key,
+    )
+    if err != nil {
+        return fmt.Errorf("error releasing idempotency key: %w", err)
+    }
+
+    return nil
+}
+
+// Borra las claves vencidas y las reservas abandonadas
+func (r *IdempotencyRepository) DeleteExpired(lease time.Duration) (int64, error) {
+    result, err := r.db.Exec(`
+        DELETE FROM idempotency_keys
+        WHERE expires_at <= NOW() OR (status_code IS NULL AND created_at <= NOW() - make_interval(secs => $1))
+    `, lease.Seconds())
+    if err != nil {
+        return 0, fmt.Errorf("error deleting expired idempotency keys: %w", err)
+    }
+
+    return result.RowsAffected()
+}
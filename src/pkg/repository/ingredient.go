@@ -0,0 +1,335 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type IngredientRepository struct {
+    *BaseRepository
+}
+
+func NewIngredientRepository(db *sql.DB) *IngredientRepository {
+    return &IngredientRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const ingredientColumns = `id, name, unit, stock_quantity, min_stock, cost_per_unit, created_at`
+
+// Filtros del listado de ingredientes
+type IngredientFilter struct {
+    Name     string // coincidencia parcial, sin distinguir mayúsculas
+    LowStock bool   // solo los que están en o por debajo de su mínimo
+}
+
+func (f IngredientFilter) where(args []any) (string, []any) {
+    clauses := make([]string, 0)
+
+    if f.Name != "" {
+        args = append(args, "%"+f.Name+"%")
+        clauses = append(clauses, fmt.Sprintf("name ILIKE $%d", len(args)))
+    }
+    if f.LowStock {
+        clauses = append(clauses, "min_stock > 0 AND stock_quantity <= min_stock")
+    }
+
+    if len(clauses) == 0 {
+        return "", args
+    }
+    return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *IngredientRepository) Find(filter IngredientFilter) ([]data.Ingredient, error) {
+    where, args := filter.where(nil)
+    query := `SELECT ` + ingredientColumns + ` FROM ingredients` + where + ` ORDER BY name`
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying ingredients: %w", err)
+    }
+    defer rows.Close()
+
+    var ingredients []data.Ingredient
+    if err := ScanRowsToStruct(rows, &ingredients); err != nil {
+        return nil, fmt.Errorf("error scanning ingredients: %w", err)
+    }
+
+    return ingredients, nil
+}
+
+func (r *IngredientRepository) GetByID(id int32) (*data.Ingredient, error) {
+    query := `
+        SELECT ` + ingredientColumns + `
+        FROM ingredients
+        WHERE id = $1
+    `
+
+    return r.getOne(query, id)
+}
+
+func (r *IngredientRepository) GetByName(name string) (*data.Ingredient, error) {
+    query := `
+        SELECT ` + ingredientColumns + `
+        FROM ingredients
+        WHERE LOWER(name) = LOWER($1)
+    `
+
+    return r.getOne(query, name)
+}
+
+func (r *IngredientRepository) getOne(query string, args ...any) (*data.Ingredient, error) {
+    var ingredient data.Ingredient
+    err := r.db.QueryRow(query, args...).Scan(
+        &ingredient.ID,
+        &ingredient.Name,
+        &ingredient.Unit,
+        &ingredient.StockQuantity,
+        &ingredient.MinStock,
+        &ingredient.CostPerUnit,
+        &ingredient.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting ingredient: %w", err)
+    }
+
+    return &ingredient, nil
+}
+
+// Los ingredientes nacen sin existencias; el stock solo cambia con movimientos de inventario
+func (r *IngredientRepository) Create(ingredient *data.Ingredient) error {
+    query := `
+        INSERT INTO ingredients (name, unit, stock_quantity, min_stock, cost_per_unit)
+        VALUES ($1, $2, 0, $3, $4)
+        RETURNING id, stock_quantity, created_at
+    `
+
+    err := r.db.QueryRow(query, ingredient.Name, ingredient.Unit, ingredient.MinStock, ingredient.CostPerUnit).
+        Scan(&ingredient.ID, &ingredient.StockQuantity, &ingredient.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating ingredient: %w", err)
+    }
+
+    return nil
+}
+
+// Actualiza los datos del ingrediente sin tocar sus existencias
+func (r *IngredientRepository) Update(ingredient *data.Ingredient) error {
+    err := r.db.QueryRow(`
+        UPDATE ingredients SET name = $2, unit = $3, min_stock = $4, cost_per_unit = $5
+        WHERE id = $1
+        RETURNING stock_quantity
+    `, ingredient.ID, ingredient.Name, ingredient.Unit, ingredient.MinStock, ingredient.CostPerUnit).Scan(&ingredient.StockQuantity)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("ingredient not found")
+        }
+        return fmt.Errorf("error updating ingredient: %w", err)
+    }
+
+    return nil
+}
+
+// Solo se puede eliminar un ingrediente que ninguna receta ni modificador use
+func (r *IngredientRepository) Delete(id int32) error {
+    var used bool
+    err := r.db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM product_recipes WHERE ingredient_id = $1)
+            OR EXISTS (SELECT 1 FROM product_modifiers WHERE ingredient_id = $1)
+    `, id).Scan(&used)
+    if err != nil {
+        return fmt.Errorf("error checking ingredient recipes: %w", err)
+    }
+    if used {
+        return fmt.Errorf("ingredient is used by recipes or modifiers")
+    }
+
+    result, err := r.db.Exec(`DELETE FROM ingredients WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("error deleting ingredient: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("ingredient not found")
+    }
+
+    return nil
+}
+
+// Indica si el ingrediente ya tiene historia (movimientos, recetas,
+// modificadores, compras o presentaciones) expresada en su unidad actual
+func (r *IngredientRepository) IsReferenced(id int32) (bool, error) {
+    var referenced bool
+    err := r.db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM inventory_movements WHERE ingredient_id = $1)
+            OR EXISTS (SELECT 1 FROM product_recipes WHERE ingredient_id = $1)
+            OR EXISTS (SELECT 1 FROM product_modifiers WHERE ingredient_id = $1)
+            OR EXISTS (SELECT 1 FROM purchase_order_items WHERE ingredient_id = $1)
+            OR EXISTS (SELECT 1 FROM pack_units WHERE ingredient_id = $1)
+    `, id).Scan(&referenced)
+    if err != nil {
+        return false, fmt.Errorf("error checking ingredient references: %w", err)
+    }
+
+    return referenced, nil
+}
+
+func (r *IngredientRepository) GetRecipe(productID int32) ([]data.RecipeItem, error) {
+    query := `
+        SELECT pr.product_id, pr.ingredient_id, i.name AS ingredient_name, i.unit, pr.quantity
+        FROM product_recipes pr
+        JOIN ingredients i ON i.id = pr.ingredient_id
+        WHERE pr.product_id = $1
+        ORDER BY i.name
+    `
+
+    rows, err := r.db.Query(query, productID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying product recipe: %w", err)
+    }
+    defer rows.Close()
+
+    recipe := make([]data.RecipeItem, 0)
+    if err := ScanRowsToStruct(rows, &recipe); err != nil {
+        return nil, fmt.Errorf("error scanning product recipe: %w", err)
+    }
+
+    return recipe, nil
+}
+
+// Reemplaza la receta completa de un producto
+func (r *IngredientRepository) SetRecipe(productID int32, items []data.RecipeItem) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var exists bool
+    if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+        return fmt.Errorf("error checking product: %w", err)
+    }
+    if !exists {
+        return fmt.Errorf("product not found")
+    }
+
+    if _, err := tx.Exec(`DELETE FROM product_recipes WHERE product_id = $1`, productID); err != nil {
+        return fmt.Errorf("error clearing product recipe: %w", err)
+    }
+
+    for _, item := range items {
+        _, err := tx.Exec(
+            `INSERT INTO product_recipes (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)`,
+            productID, item.IngredientID, item.Quantity,
+        )
+        if err != nil {
+            return fmt.Errorf("error saving product recipe: %w", err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Niveles de combos dentro de combos que se recorren al descontar inventario
+const maxBundleDepth = 5
+
+func (r *IngredientRepository) GetBundleComponents(bundleID int32) ([]data.BundleComponent, error) {
+    query := `
+        SELECT bc.bundle_id, bc.product_id, p.name AS product_name, bc.quantity
+        FROM bundle_components bc
+        JOIN products p ON p.id = bc.product_id
+        WHERE bc.bundle_id = $1
+        ORDER BY p.name
+    `
+
+    rows, err := r.db.Query(query, bundleID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying bundle components: %w", err)
+    }
+    defer rows.Close()
+
+    components := make([]data.BundleComponent, 0)
+    if err := ScanRowsToStruct(rows, &components); err != nil {
+        return nil, fmt.Errorf("error scanning bundle components: %w", err)
+    }
+
+    return components, nil
+}
+
+// Reemplaza los productos que incluye un combo. Rechaza combos que se
+// contengan a sí mismos o que superen la profundidad permitida.
+func (r *IngredientRepository) SetBundleComponents(bundleID int32, components []data.BundleComponent) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var exists bool
+    if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, bundleID).Scan(&exists); err != nil {
+        return fmt.Errorf("error checking product: %w", err)
+    }
+    if !exists {
+        return fmt.Errorf("product not found")
+    }
+
+    if _, err := tx.Exec(`DELETE FROM bundle_components WHERE bundle_id = $1`, bundleID); err != nil {
+        return fmt.Errorf("error clearing bundle components: %w", err)
+    }
+
+    for _, component := range components {
+        _, err := tx.Exec(
+            `INSERT INTO bundle_components (bundle_id, product_id, quantity) VALUES ($1, $2, $3)`,
+            bundleID, component.ProductID, component.Quantity,
+        )
+        if err != nil {
+            return fmt.Errorf("error saving bundle components: %w", err)
+        }
+    }
+
+    // Recorrer lo que queda dentro del combo; UNION descarta repetidos y corta los ciclos
+    var cyclic bool
+    var depth int
+    err = tx.QueryRow(`
+        WITH RECURSIVE reach (product_id, depth) AS (
+            SELECT product_id, 1 FROM bundle_components WHERE bundle_id = $1
+            UNION
+            SELECT bc.product_id, reach.depth + 1
+            FROM bundle_components bc
+            JOIN reach ON bc.bundle_id = reach.product_id
+            WHERE reach.product_id <> $1 AND reach.depth <= $2
+        )
+        SELECT COALESCE(BOOL_OR(product_id = $1), false), COALESCE(MAX(depth), 0) FROM reach
+    `, bundleID, maxBundleDepth).Scan(&cyclic, &depth)
+    if err != nil {
+        return fmt.Errorf("error checking bundle components: %w", err)
+    }
+    if cyclic {
+        return fmt.Errorf("a bundle cannot contain itself")
+    }
+    if depth > maxBundleDepth {
+        return fmt.Errorf("bundles cannot be nested more than %d levels", maxBundleDepth)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
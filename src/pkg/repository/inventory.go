@@ -0,0 +1,398 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type InventoryRepository struct {
+    *BaseRepository
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+    return &InventoryRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+// Registra un movimiento y actualiza las existencias del ingrediente en la
+// misma transacción
+func (r *InventoryRepository) RecordMovement(movement *data.InventoryMovement, allowNegative bool) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := recordMovementTx(tx, movement, allowNegative); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Única forma de cambiar stock_quantity: la actualización es atómica sobre la
+// fila del ingrediente, así dos salidas simultáneas no pueden dejarlo en
+// negativo cuando la política no lo permite
+func recordMovementTx(tx *sql.Tx, movement *data.InventoryMovement, allowNegative bool) error {
+    delta := movement.Quantity
+    if movement.MovementType == data.MovementTypeSalida {
+        delta = -movement.Quantity
+    }
+
+    // Solo las entradas de mercancía (compras y manuales) crean lotes; las
+    // reversiones devuelven a sus lotes y los ajustes positivos quedan sin lote
+    createsLot := movement.MovementType == data.MovementTypeEntrada && movement.ReversalOf == nil
+    if (movement.LotNumber != "" || movement.ExpiresAt != nil) && !createsLot {
+        return fmt.Errorf("lot number and expiry only apply to stock entries")
+    }
+
+    err := tx.QueryRow(`
+        UPDATE ingredients SET stock_quantity = stock_quantity + $2
+        WHERE id = $1 AND ($3 OR $2 >= 0 OR stock_quantity + $2 >= 0)
+        RETURNING stock_quantity
+    `, movement.IngredientID, delta, allowNegative).Scan(&movement.StockAfter)
+    if err != nil {
+        if err != sql.ErrNoRows {
+            return fmt.Errorf("error updating ingredient stock: %w", err)
+        }
+
+        var exists bool
+        if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = $1)`, movement.IngredientID).Scan(&exists); err != nil {
+            return fmt.Errorf("error checking ingredient: %w", err)
+        }
+        if !exists {
+            return fmt.Errorf("ingredient not found")
+        }
+        return fmt.Errorf("insufficient stock for ingredient %d", movement.IngredientID)
+    }
+
+    if createsLot {
+        if err := createLotTx(tx, movement); err != nil {
+            return err
+        }
+    }
+
+    query := `
+        INSERT INTO inventory_movements (ingredient_id, movement_type, quantity, reason, user_id, stock_after,
+                                         order_id, order_item_id, purchase_order_id, stock_take_id, lot_id, reversal_of)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        RETURNING id, created_at
+    `
+
+    err = tx.QueryRow(
+        query,
+        movement.IngredientID,
+        movement.MovementType,
+        movement.Quantity,
+        movement.Reason,
+        movement.UserID,
+        movement.StockAfter,
+        movement.OrderID,
+        movement.OrderItemID,
+        movement.PurchaseOrderID,
+        movement.StockTakeID,
+        movement.LotID,
+        movement.ReversalOf,
+    ).Scan(&movement.ID, &movement.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating inventory movement: %w", err)
+    }
+
+    if delta < 0 {
+        if err := consumeLotsTx(tx, movement, -delta); err != nil {
+            return err
+        }
+    }
+
+    if movement.ReversalOf != nil {
+        if err := restoreLotsTx(tx, *movement.ReversalOf); err != nil {
+            return err
+        }
+    }
+
+    return checkStockAlertTx(tx, movement.IngredientID)
+}
+
+// Historial de un ingrediente, del más reciente al más antiguo; devuelve
+// también el total de movimientos que cumplen el filtro
+func (r *InventoryRepository) GetMovements(ingredientID int32, movementType string, limit, offset int) ([]data.InventoryMovement, int, error) {
+    var total int
+    err := r.db.QueryRow(`
+        SELECT COUNT(*) FROM inventory_movements
+        WHERE ingredient_id = $1 AND ($2 = '' OR movement_type = $2)
+    `, ingredientID, movementType).Scan(&total)
+    if err != nil {
+        return nil, 0, fmt.Errorf("error counting inventory movements: %w", err)
+    }
+
+    query := `
+        SELECT m.id, m.ingredient_id, m.movement_type, m.quantity, m.reason, m.user_id, m.stock_after,
+               m.order_id, m.order_item_id, m.purchase_order_id, m.stock_take_id, m.lot_id,
+               m.reversal_of, COALESCE(l.lot_number, '') AS lot_number, l.expires_at, m.created_at
+        FROM inventory_movements m
+        LEFT JOIN inventory_lots l ON l.id = m.lot_id
+        WHERE m.ingredient_id = $1 AND ($2 = '' OR m.movement_type = $2)
+        ORDER BY m.created_at DESC, m.id DESC
+        LIMIT $3 OFFSET $4
+    `
+
+    rows, err := r.db.Query(query, ingredientID, movementType, limit, offset)
+    if err != nil {
+        return nil, 0, fmt.Errorf("error querying inventory movements: %w", err)
+    }
+    defer rows.Close()
+
+    movements := make([]data.InventoryMovement, 0)
+    if err := ScanRowsToStruct(rows, &movements); err != nil {
+        return nil, 0, fmt.Errorf("error scanning inventory movements: %w", err)
+    }
+
+    return movements, total, nil
+}
+
+// Avance de una orden para decidir si ya se descuenta su inventario
+var orderStockStages = map[string]int{
+    data.OrderStatusPending:   1,
+    data.OrderStatusPreparing: 2,
+    data.OrderStatusReady:     3,
+    data.OrderStatusDelivered: 4,
+    data.OrderStatusClosed:    5,
+}
+
+// Indica si la orden puede descontar inventario al llegar a ese estado
+func IsValidStockDeductionStatus(status string) bool {
+    _, ok := orderStockStages[status]
+    return ok
+}
+
+// Deja el inventario de una orden al día con su estado: descuenta los items
+// marchados pendientes de descontar cuando la orden alcanzó el estado
+// deductOn y devuelve lo descontado si se canceló. Los tiempos retenidos se
+// descuentan al marcharse. Es idempotente: cada item se marca al descontarse
+// y se desmarca al revertirse.
+func syncOrderStockTx(tx *sql.Tx, orderID int32, deductOn string) error {
+    var status, orderNumber string
+    if err := tx.QueryRow(`SELECT status, order_number FROM orders WHERE id = $1`, orderID).Scan(&status, &orderNumber); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
+        return fmt.Errorf("error getting order: %w", err)
+    }
+
+    deduct := true
+    if status == data.OrderStatusCancelled {
+        deduct = false
+    } else if stage, ok := orderStockStages[status]; !ok || stage < orderStockStages[deductOn] {
+        return nil
+    }
+
+    rows, err := tx.Query(`
+        SELECT id, product_id, quantity
+        FROM order_items
+        WHERE order_id = $1 AND stock_deducted = $2 AND ($2 OR fired_at IS NOT NULL)
+        ORDER BY id
+        FOR UPDATE
+    `, orderID, !deduct)
+    if err != nil {
+        return fmt.Errorf("error querying order items: %w", err)
+    }
+
+    var items []data.OrderItem
+    for rows.Next() {
+        var item data.OrderItem
+        if err := rows.Scan(&item.ID, &item.ProductID, &item.Quantity); err != nil {
+            rows.Close()
+            return fmt.Errorf("error scanning order items: %w", err)
+        }
+        items = append(items, item)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("error scanning order items: %w", err)
+    }
+
+    for _, item := range items {
+        if deduct {
+            err = deductOrderItemStockTx(tx, orderID, item, "Order "+orderNumber)
+        } else {
+            err = reverseOrderItemStockTx(tx, orderID, item.ID, "Order "+orderNumber+" cancelled")
+        }
+        if err != nil {
+            return err
+        }
+
+        if _, err := tx.Exec(`UPDATE order_items SET stock_deducted = $2 WHERE id = $1`, item.ID, deduct); err != nil {
+            return fmt.Errorf("error marking order item stock: %w", err)
+        }
+    }
+
+    return nil
+}
+
+func deductOrderItemStockTx(tx *sql.Tx, orderID int32, item data.OrderItem, reason string) error {
+    consumption, err := orderItemConsumptionTx(tx, item)
+    if err != nil {
+        return err
+    }
+
+    for _, used := range consumption {
+        movement := &data.InventoryMovement{
+            IngredientID: used.IngredientID,
+            MovementType: data.MovementTypeSalida,
+            Quantity:     used.Quantity,
+            Reason:       reason,
+            OrderID:      &orderID,
+            OrderItemID:  &item.ID,
+        }
+
+        // Lo vendido ya salió de la cocina: el descuento nunca se bloquea por falta de stock
+        if err := recordMovementTx(tx, movement, true); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// Devuelve exactamente las salidas que registró el item y que no se han
+// revertido, no lo que diría la receta actual (que pudo cambiar desde la venta)
+func reverseOrderItemStockTx(tx *sql.Tx, orderID, itemID int32, reason string) error {
+    rows, err := tx.Query(`
+        SELECT m.id, m.ingredient_id, m.quantity
+        FROM inventory_movements m
+        WHERE m.order_item_id = $1 AND m.movement_type = $2
+          AND NOT EXISTS (SELECT 1 FROM inventory_movements r WHERE r.reversal_of = m.id)
+        ORDER BY m.id
+    `, itemID, data.MovementTypeSalida)
+    if err != nil {
+        return fmt.Errorf("error querying order item movements: %w", err)
+    }
+
+    var deducted []data.InventoryMovement
+    for rows.Next() {
+        var movement data.InventoryMovement
+        if err := rows.Scan(&movement.ID, &movement.IngredientID, &movement.Quantity); err != nil {
+            rows.Close()
+            return fmt.Errorf("error scanning order item movements: %w", err)
+        }
+        deducted = append(deducted, movement)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("error scanning order item movements: %w", err)
+    }
+
+    for _, original := range deducted {
+        movement := &data.InventoryMovement{
+            IngredientID: original.IngredientID,
+            MovementType: data.MovementTypeEntrada,
+            Quantity:     original.Quantity,
+            Reason:       reason,
+            OrderID:      &orderID,
+            OrderItemID:  &itemID,
+            ReversalOf:   &original.ID,
+        }
+        if err := recordMovementTx(tx, movement, true); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// Ingredientes que consume un item: la receta del producto ajustada por sus
+// modificadores. Lo que un modificador quita nunca deja un ingrediente en
+// negativo; simplemente deja de descontarse.
+func orderItemConsumptionTx(tx *sql.Tx, item data.OrderItem) ([]data.RecipeItem, error) {
+    consumption, err := productConsumptionTx(tx, item.ProductID, float64(item.Quantity))
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := tx.Query(`
+        SELECT ingredient_id, SUM(quantity)
+        FROM order_item_modifiers
+        WHERE order_item_id = $1 AND ingredient_id IS NOT NULL
+        GROUP BY ingredient_id
+        ORDER BY ingredient_id
+    `, item.ID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order item modifiers: %w", err)
+    }
+    defer rows.Close()
+
+    positions := make(map[int32]int, len(consumption))
+    for i, used := range consumption {
+        positions[used.IngredientID] = i
+    }
+
+    for rows.Next() {
+        var ingredientID int32
+        var quantity float64
+        if err := rows.Scan(&ingredientID, &quantity); err != nil {
+            return nil, fmt.Errorf("error scanning order item modifiers: %w", err)
+        }
+        quantity *= float64(item.Quantity)
+
+        if i, ok := positions[ingredientID]; ok {
+            consumption[i].Quantity += quantity
+        } else {
+            positions[ingredientID] = len(consumption)
+            consumption = append(consumption, data.RecipeItem{ProductID: item.ProductID, IngredientID: ingredientID, Quantity: quantity})
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error scanning order item modifiers: %w", err)
+    }
+
+    adjusted := consumption[:0]
+    for _, used := range consumption {
+        if used.Quantity > 0 {
+            adjusted = append(adjusted, used)
+        }
+    }
+
+    return adjusted, nil
+}
+
+// Ingredientes que consume una cantidad de producto: su propia receta más la
+// de los productos que incluye si es un combo (a cualquier profundidad)
+func productConsumptionTx(tx *sql.Tx, productID int32, quantity float64) ([]data.RecipeItem, error) {
+    rows, err := tx.Query(`
+        WITH RECURSIVE parts (product_id, quantity, depth) AS (
+            SELECT $1::integer, $2::double precision, 0
+            UNION ALL
+            SELECT bc.product_id, parts.quantity * bc.quantity, parts.depth + 1
+            FROM bundle_components bc
+            JOIN parts ON bc.bundle_id = parts.product_id
+            WHERE parts.depth < $3
+        )
+        SELECT pr.ingredient_id, SUM(parts.quantity * pr.quantity)
+        FROM parts
+        JOIN product_recipes pr ON pr.product_id = parts.product_id
+        GROUP BY pr.ingredient_id
+        ORDER BY pr.ingredient_id
+    `, productID, quantity, maxBundleDepth)
+    if err != nil {
+        return nil, fmt.Errorf("error querying product recipe: %w", err)
+    }
+    defer rows.Close()
+
+    var consumption []data.RecipeItem
+    for rows.Next() {
+        item := data.RecipeItem{ProductID: productID}
+        if err := rows.Scan(&item.IngredientID, &item.Quantity); err != nil {
+            return nil, fmt.Errorf("error scanning product recipe: %w", err)
+        }
+        consumption = append(consumption, item)
+    }
+
+    return consumption, rows.Err()
+}
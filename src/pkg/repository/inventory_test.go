@@ -0,0 +1,61 @@
+package repository
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestHeldCourseDeductsWhenFired(t *testing.T) {
+    db := openTestDB(t)
+    repo := NewOrderRepository(db, data.OrderStatusPreparing)
+
+    flour := insertTestIngredient(t, db, "Harina", 100)
+    beef := insertTestIngredient(t, db, "Res", 100)
+    starter := insertTestProduct(t, db, "Empanada", 5, flour, 10)
+    entree := insertTestProduct(t, db, "Lomo", 20, beef, 30)
+    table := insertTestTable(t, db, 1)
+
+    order := createTestOrder(t, repo, table,
+        data.OrderItem{ProductID: starter, Quantity: 2, UnitPrice: 5, Course: 1},
+        data.OrderItem{ProductID: entree, Quantity: 1, UnitPrice: 20, Course: 2},
+    )
+
+    stock := func(ingredientID int32) float64 {
+        return mustQueryFloat(t, db, `SELECT stock_quantity FROM ingredients WHERE id = $1`, ingredientID)
+    }
+
+    if got := stock(flour); got != 100 {
+        t.Fatalf("flour before deductOn = %v, want 100", got)
+    }
+
+    if err := repo.UpdateStatus(order.ID, data.OrderStatusPreparing); err != nil {
+        t.Fatalf("UpdateStatus() error = %v", err)
+    }
+
+    if got := stock(flour); got != 80 {
+        t.Errorf("flour after deductOn = %v, want 80", got)
+    }
+    if got := stock(beef); got != 100 {
+        t.Errorf("held course took stock: beef = %v, want 100", got)
+    }
+
+    if _, err := repo.FireCourse(order.ID, 2); err != nil {
+        t.Fatalf("FireCourse() error = %v", err)
+    }
+
+    if got := stock(beef); got != 70 {
+        t.Errorf("beef after firing = %v, want 70", got)
+    }
+    if got := stock(flour); got != 80 {
+        t.Errorf("flour deducted twice: %v, want 80", got)
+    }
+
+    // Al cancelar se devuelve todo lo descontado
+    if err := repo.UpdateStatus(order.ID, data.OrderStatusCancelled); err != nil {
+        t.Fatalf("UpdateStatus(cancelled) error = %v", err)
+    }
+    if got, want := stock(flour)+stock(beef), 200.0; got != want {
+        t.Errorf("stock after cancel = %v, want %v", got, want)
+    }
+}
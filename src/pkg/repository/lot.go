@@ -0,0 +1,288 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type LotRepository struct {
+    *BaseRepository
+}
+
+func NewLotRepository(db *sql.DB) *LotRepository {
+    return &LotRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const lotColumns = `l.id, l.ingredient_id, i.name AS ingredient_name, i.unit, l.lot_number, l.expires_at,
+        l.quantity_received, l.quantity_remaining, l.purchase_order_id, l.created_at`
+
+// Filtros del listado de lotes; por defecto solo los que tienen existencias
+type LotFilter struct {
+    IngredientID int32
+    // Vencidos o que vencen en los próximos N días
+    ExpiringWithinDays *int
+    IncludeEmpty       bool
+}
+
+func (f LotFilter) where(args []any) (string, []any) {
+    clauses := make([]string, 0)
+
+    if f.IngredientID != 0 {
+        args = append(args, f.IngredientID)
+        clauses = append(clauses, fmt.Sprintf("l.ingredient_id = $%d", len(args)))
+    }
+    if f.ExpiringWithinDays != nil {
+        args = append(args, *f.ExpiringWithinDays)
+        clauses = append(clauses, fmt.Sprintf("l.expires_at <= CURRENT_DATE + $%d::int", len(args)))
+    }
+    if !f.IncludeEmpty {
+        clauses = append(clauses, "l.quantity_remaining > 0")
+    }
+
+    if len(clauses) == 0 {
+        return "", args
+    }
+    return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// Lotes en orden de consumo: primero los que vencen antes
+func (r *LotRepository) Find(filter LotFilter) ([]data.InventoryLot, error) {
+    where, args := filter.where(nil)
+    query := `
+        SELECT ` + lotColumns + `
+        FROM inventory_lots l
+        JOIN ingredients i ON i.id = l.ingredient_id` + where + `
+        ORDER BY l.expires_at NULLS LAST, l.created_at, l.id
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying lots: %w", err)
+    }
+    defer rows.Close()
+
+    lots := make([]data.InventoryLot, 0)
+    if err := ScanRowsToStruct(rows, &lots); err != nil {
+        return nil, fmt.Errorf("error scanning lots: %w", err)
+    }
+
+    return lots, nil
+}
+
+func (r *LotRepository) GetByID(id int32) (*data.InventoryLot, error) {
+    query := `
+        SELECT ` + lotColumns + `
+        FROM inventory_lots l
+        JOIN ingredients i ON i.id = l.ingredient_id
+        WHERE l.id = $1
+    `
+
+    var lot data.InventoryLot
+    err := r.db.QueryRow(query, id).Scan(
+        &lot.ID,
+        &lot.IngredientID,
+        &lot.IngredientName,
+        &lot.Unit,
+        &lot.LotNumber,
+        &lot.ExpiresAt,
+        &lot.QuantityReceived,
+        &lot.QuantityRemaining,
+        &lot.PurchaseOrderID,
+        &lot.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting lot: %w", err)
+    }
+
+    return &lot, nil
+}
+
+// Da de baja como merma vencida todo lo que queda de los lotes ya vencidos
+func (r *LotRepository) WriteOffExpired(userID int32, notes string) ([]data.WasteRecord, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return nil, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    rows, err := tx.Query(`
+        SELECT id, ingredient_id, lot_number, quantity_remaining FROM inventory_lots
+        WHERE expires_at < CURRENT_DATE AND quantity_remaining > 0
+        ORDER BY expires_at, id
+        FOR UPDATE
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("error querying expired lots: %w", err)
+    }
+
+    var lots []data.InventoryLot
+    for rows.Next() {
+        var lot data.InventoryLot
+        if err := rows.Scan(&lot.ID, &lot.IngredientID, &lot.LotNumber, &lot.QuantityRemaining); err != nil {
+            rows.Close()
+            return nil, fmt.Errorf("error scanning expired lot: %w", err)
+        }
+        lots = append(lots, lot)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error reading expired lots: %w", err)
+    }
+
+    records := make([]data.WasteRecord, 0, len(lots))
+    for _, lot := range lots {
+        record := NewLotWriteOff(&lot, lot.QuantityRemaining, userID, notes)
+        if err := createWasteTx(tx, record); err != nil {
+            return nil, err
+        }
+        records = append(records, *record)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return records, nil
+}
+
+// Merma vencida que da de baja quantity de un lote
+func NewLotWriteOff(lot *data.InventoryLot, quantity float64, userID int32, notes string) *data.WasteRecord {
+    label := lot.LotNumber
+    if label == "" {
+        label = fmt.Sprint(lot.ID)
+    }
+
+    return &data.WasteRecord{
+        IngredientID: &lot.IngredientID,
+        Quantity:     quantity,
+        Reason:       data.WasteReasonExpired,
+        Notes:        fmt.Sprintf("Lot %s written off: %s", label, notes),
+        LotID:        &lot.ID,
+        UserID:       &userID,
+    }
+}
+
+func createLotTx(tx *sql.Tx, movement *data.InventoryMovement) error {
+    var lotID int32
+    err := tx.QueryRow(`
+        INSERT INTO inventory_lots (ingredient_id, lot_number, expires_at, quantity_received, quantity_remaining, purchase_order_id)
+        VALUES ($1, $2, $3, $4, $4, $5)
+        RETURNING id
+    `, movement.IngredientID, movement.LotNumber, movement.ExpiresAt, movement.Quantity, movement.PurchaseOrderID).Scan(&lotID)
+    if err != nil {
+        return fmt.Errorf("error creating lot: %w", err)
+    }
+    movement.LotID = &lotID
+
+    return nil
+}
+
+// Reparte una salida entre lotes. Con LotID se toma de ese lote; si no, se
+// consumen primero los que vencen antes (FEFO). Lo que no alcanza a cubrirse
+// con lotes (stock previo a los lotes o stock negativo) queda sin asignar.
+func consumeLotsTx(tx *sql.Tx, movement *data.InventoryMovement, quantity float64) error {
+    if movement.LotID != nil {
+        result, err := tx.Exec(`
+            UPDATE inventory_lots SET quantity_remaining = quantity_remaining - $2
+            WHERE id = $1 AND ingredient_id = $3 AND quantity_remaining >= $2
+        `, *movement.LotID, quantity, movement.IngredientID)
+        if err != nil {
+            return fmt.Errorf("error consuming lot: %w", err)
+        }
+
+        rowsAffected, err := result.RowsAffected()
+        if err != nil {
+            return fmt.Errorf("error getting rows affected: %w", err)
+        }
+        if rowsAffected == 0 {
+            return fmt.Errorf("lot %d does not have enough quantity", *movement.LotID)
+        }
+
+        return insertLotConsumptionTx(tx, *movement.LotID, movement.ID, quantity)
+    }
+
+    rows, err := tx.Query(`
+        SELECT id, quantity_remaining FROM inventory_lots
+        WHERE ingredient_id = $1 AND quantity_remaining > 0
+        ORDER BY expires_at NULLS LAST, created_at, id
+        FOR UPDATE
+    `, movement.IngredientID)
+    if err != nil {
+        return fmt.Errorf("error querying lots: %w", err)
+    }
+
+    type lotStock struct {
+        id        int32
+        remaining float64
+    }
+    var lots []lotStock
+    for rows.Next() {
+        var lot lotStock
+        if err := rows.Scan(&lot.id, &lot.remaining); err != nil {
+            rows.Close()
+            return fmt.Errorf("error scanning lot: %w", err)
+        }
+        lots = append(lots, lot)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("error reading lots: %w", err)
+    }
+
+    for _, lot := range lots {
+        if quantity <= 0 {
+            break
+        }
+
+        taken := min(quantity, lot.remaining)
+        if _, err := tx.Exec(`UPDATE inventory_lots SET quantity_remaining = quantity_remaining - $2 WHERE id = $1`, lot.id, taken); err != nil {
+            return fmt.Errorf("error consuming lot: %w", err)
+        }
+        if err := insertLotConsumptionTx(tx, lot.id, movement.ID, taken); err != nil {
+            return err
+        }
+        quantity -= taken
+    }
+
+    return nil
+}
+
+// Devuelve a cada lote lo que le tomó la salida revertida, según lot_consumptions
+func restoreLotsTx(tx *sql.Tx, movementID int32) error {
+    _, err := tx.Exec(`
+        UPDATE inventory_lots l SET quantity_remaining = l.quantity_remaining + c.quantity
+        FROM (
+            SELECT lot_id, SUM(quantity) AS quantity
+            FROM lot_consumptions
+            WHERE movement_id = $1
+            GROUP BY lot_id
+        ) c
+        WHERE l.id = c.lot_id
+    `, movementID)
+    if err != nil {
+        return fmt.Errorf("error restoring lots: %w", err)
+    }
+
+    return nil
+}
+
+func insertLotConsumptionTx(tx *sql.Tx, lotID, movementID int32, quantity float64) error {
+    _, err := tx.Exec(`
+        INSERT INTO lot_consumptions (lot_id, movement_id, quantity)
+        VALUES ($1, $2, $3)
+    `, lotID, movementID, quantity)
+    if err != nil {
+        return fmt.Errorf("error recording lot consumption: %w", err)
+    }
+
+    return nil
+}
@@ -3,28 +3,74 @@ package repository
 import (
     "database/sql"
     "fmt"
+    "math"
+    "strings"
     "time"
 
+    "github.com/lib/pq"
     "github.com/pkgzx/liliApi/src/pkg/data"
 )
 
 type OrderRepository struct {
     *BaseRepository
+    // Estado de la orden en el que se descuenta su inventario
+    deductOn string
 }
 
-func NewOrderRepository(db *sql.DB) *OrderRepository {
+func NewOrderRepository(db *sql.DB, deductOn string) *OrderRepository {
     return &OrderRepository{
         BaseRepository: NewBaseRepository(db),
+        deductOn:       deductOn,
     }
 }
 
+// Columnas comunes de la tabla orders
+const orderColumns = `id, order_number, status, total_amount, notes, table_id, order_type,
+        customer_address, customer_phone, delivery_fee, pickup_time, payment_status, discount_amount,
+        tax_mode, tax_amount, party_size, service_charge_rate, service_charge, scheduled_for, fired_course,
+        created_at, updated_at`
+
+const orderItemColumns = `id, order_id, product_id, quantity, unit_price, discount_amount, subtotal,
+        tax_rate_id, tax_rate, station_id, status, notes, course, fired_at, started_at,
+        stock_deducted`
+
+// Condición SQL para órdenes que siguen abiertas
+const openOrderCondition = `status NOT IN ('closed', 'cancelled', 'merged')`
+
+func scanOrder(row interface{ Scan(...any) error }, order *data.Order) error {
+    return row.Scan(
+        &order.ID,
+        &order.OrderNumber,
+        &order.Status,
+        &order.TotalAmount,
+        &order.Notes,
+        &order.TableID,
+        &order.OrderType,
+        &order.CustomerAddress,
+        &order.CustomerPhone,
+        &order.DeliveryFee,
+        &order.PickupTime,
+        &order.PaymentStatus,
+        &order.DiscountAmount,
+        &order.TaxMode,
+        &order.TaxAmount,
+        &order.PartySize,
+        &order.ServiceChargeRate,
+        &order.ServiceCharge,
+        &order.ScheduledFor,
+        &order.FiredCourse,
+        &order.CreatedAt,
+        &order.UpdatedAt,
+    )
+}
+
 func (r *OrderRepository) GetAll() ([]data.Order, error) {
     query := `
-        SELECT id, order_number, status, total_amount, notes, created_at, updated_at
-        FROM orders 
+        SELECT ` + orderColumns + `
+        FROM orders
         ORDER BY created_at DESC
     `
-    
+
     rows, err := r.db.Query(query)
     if err != nil {
         return nil, fmt.Errorf("error querying orders: %w", err)
@@ -41,22 +87,14 @@ func (r *OrderRepository) GetAll() ([]data.Order, error) {
 
 func (r *OrderRepository) GetByID(id int32) (*data.Order, error) {
     query := `
-        SELECT id, order_number, status, total_amount, notes, created_at, updated_at
-        FROM orders 
+        SELECT ` + orderColumns + `
+        FROM orders
         WHERE id = $1
     `
-    
+
     var order data.Order
-    err := r.db.QueryRow(query, id).Scan(
-        &order.ID,
-        &order.OrderNumber,
-        &order.Status,
-        &order.TotalAmount,
-        &order.Notes,
-        &order.CreatedAt,
-        &order.UpdatedAt,
-    )
-    
+    err := scanOrder(r.db.QueryRow(query, id), &order)
+
     if err != nil {
         if err == sql.ErrNoRows {
             return nil, nil
@@ -68,35 +106,166 @@ func (r *OrderRepository) GetByID(id int32) (*data.Order, error) {
 }
 
 func (r *OrderRepository) Create(order *data.Order) error {
+    return r.CreateWithItems(order, nil)
+}
+
+// Crea la orden junto con sus items en una sola transacción
+func (r *OrderRepository) CreateWithItems(order *data.Order, items []data.OrderItem) error {
     tx, err := r.db.Begin()
     if err != nil {
         return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
-    // Generar número de orden único
-    orderNumber := fmt.Sprintf("ORD-%d", time.Now().Unix())
-    
+    if err := createOrderWithItemsTx(tx, order, items, r.deductOn); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func createOrderWithItemsTx(tx *sql.Tx, order *data.Order, items []data.OrderItem, deductOn string) error {
+    if order.TableID != nil {
+        if err := occupyTableTx(tx, *order.TableID); err != nil {
+            return err
+        }
+    }
+
+    order.FiredCourse = initialFiredCourse(order.OrderType, items)
+    if err := createOrderTx(tx, order); err != nil {
+        return err
+    }
+
+    for i := range items {
+        items[i].OrderID = order.ID
+        if err := insertOrderItemTx(tx, &items[i]); err != nil {
+            return err
+        }
+    }
+
+    total, err := recalculateOrderTotalTx(tx, order.ID)
+    if err != nil {
+        return err
+    }
+    order.TotalAmount = total
+
+    // Con el descuento configurado en pending, la orden descuenta al crearse
+    if err := syncOrderStockTx(tx, order.ID, deductOn); err != nil {
+        return err
+    }
+
+    return nil
+}
+
+func createOrderTx(tx *sql.Tx, order *data.Order) error {
+    // Generar número de orden único (las órdenes sincronizadas ya traen el suyo)
+    orderNumber := order.OrderNumber
+    if orderNumber == "" {
+        orderNumber = fmt.Sprintf("ORD-%d", time.Now().Unix())
+    }
+
     query := `
-        INSERT INTO orders (order_number, status, total_amount, notes)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO orders (order_number, status, total_amount, notes, table_id, order_type,
+                            customer_address, customer_phone, delivery_fee, pickup_time, payment_status, tax_mode,
+                            party_size, service_charge_rate, scheduled_for, fired_course)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
         RETURNING id, created_at, updated_at
     `
-    
-    err = tx.QueryRow(
+
+    err := tx.QueryRow(
         query,
         orderNumber,
         order.Status,
         order.TotalAmount,
         order.Notes,
+        order.TableID,
+        order.OrderType,
+        order.CustomerAddress,
+        order.CustomerPhone,
+        order.DeliveryFee,
+        order.PickupTime,
+        order.PaymentStatus,
+        order.TaxMode,
+        order.PartySize,
+        order.ServiceChargeRate,
+        order.ScheduledFor,
+        order.FiredCourse,
     ).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
-    
+
     if err != nil {
         return fmt.Errorf("error creating order: %w", err)
     }
 
     order.OrderNumber = orderNumber
 
+    return publishOrderEventTx(tx, order.ID, data.OrderEventCreated)
+}
+
+// Transiciones permitidas entre estados de una orden
+var orderTransitions = map[string][]string{
+    data.OrderStatusScheduled: {data.OrderStatusPending, data.OrderStatusCancelled},
+    data.OrderStatusPending:   {data.OrderStatusPreparing, data.OrderStatusCancelled},
+    data.OrderStatusPreparing: {data.OrderStatusReady, data.OrderStatusCancelled},
+    data.OrderStatusReady:     {data.OrderStatusDelivered, data.OrderStatusCancelled},
+    data.OrderStatusDelivered: {data.OrderStatusClosed},
+}
+
+func canTransition(from, to string) bool {
+    for _, allowed := range orderTransitions[from] {
+        if allowed == to {
+            return true
+        }
+    }
+    return false
+}
+
+// Cambia el estado de la orden validando la transición y sus pagos con la
+// orden bloqueada. Una orden ya pagada se cierra apenas se entrega.
+func (r *OrderRepository) UpdateStatus(id int32, status string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var current, paymentStatus string
+    var total float64
+    err = tx.QueryRow(
+        `SELECT status, payment_status, total_amount FROM orders WHERE id = $1 FOR UPDATE`, id,
+    ).Scan(&current, &paymentStatus, &total)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
+        return fmt.Errorf("error locking order: %w", err)
+    }
+
+    if !canTransition(current, status) {
+        return fmt.Errorf("invalid status transition from %s to %s", current, status)
+    }
+
+    if status == data.OrderStatusCancelled && paymentStatus != data.PaymentStatusUnpaid {
+        return fmt.Errorf("order has payments, reverse them before cancelling")
+    }
+
+    if status == data.OrderStatusClosed && !isSettled(paymentStatus, total) {
+        return fmt.Errorf("order has an outstanding balance")
+    }
+
+    if err := updateOrderStatusTx(tx, id, status, r.deductOn); err != nil {
+        return err
+    }
+
+    if status == data.OrderStatusDelivered {
+        if _, err := closeIfSettledTx(tx, id, r.deductOn); err != nil {
+            return err
+        }
+    }
+
     if err := tx.Commit(); err != nil {
         return fmt.Errorf("error committing transaction: %w", err)
     }
@@ -104,38 +273,102 @@ func (r *OrderRepository) Create(order *data.Order) error {
     return nil
 }
 
-func (r *OrderRepository) UpdateStatus(id int32, status string) error {
+// Actualiza el estado de la orden y libera su mesa si ya no quedan órdenes
+// abiertas. Al cerrar se guarda el estado anterior para poder reabrirla. Una
+// orden con tiempos sin marchar no puede quedar lista ni entregada.
+func updateOrderStatusTx(tx *sql.Tx, id int32, status, deductOn string) error {
+    if status == data.OrderStatusReady || status == data.OrderStatusDelivered {
+        var held bool
+        err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM order_items WHERE order_id = $1 AND fired_at IS NULL)`, id).Scan(&held)
+        if err != nil {
+            return fmt.Errorf("error checking held courses: %w", err)
+        }
+        if held {
+            return fmt.Errorf("order has courses that have not been fired")
+        }
+    }
+
     query := `
-        UPDATE orders 
-        SET status = $2, updated_at = CURRENT_TIMESTAMP
+        UPDATE orders
+        SET status = $2, updated_at = CURRENT_TIMESTAMP,
+            previous_status = CASE WHEN $2 = 'closed' THEN status ELSE previous_status END
         WHERE id = $1
+        RETURNING table_id
     `
-    
-    result, err := r.db.Exec(query, id, status)
+
+    var tableID *int32
+    err := tx.QueryRow(query, id, status).Scan(&tableID)
     if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
         return fmt.Errorf("error updating order status: %w", err)
     }
 
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        return fmt.Errorf("error getting rows affected: %w", err)
+    if err := publishOrderEventTx(tx, id, data.OrderEventStatusChanged); err != nil {
+        return err
+    }
+
+    // Una pre-orden liberada entra a cocina ahora, no cuando se tomó
+    if status == data.OrderStatusPending {
+        _, err := tx.Exec(`UPDATE order_items SET fired_at = NOW() WHERE order_id = $1 AND fired_at IS NOT NULL`, id)
+        if err != nil {
+            return fmt.Errorf("error firing released order: %w", err)
+        }
+    }
+
+    if status == data.OrderStatusCancelled {
+        if err := releaseCouponsTx(tx, id); err != nil {
+            return err
+        }
+    }
+
+    // Descuenta el inventario al llegar al estado configurado, o lo devuelve si se cancela
+    if err := syncOrderStockTx(tx, id, deductOn); err != nil {
+        return err
     }
 
-    if rowsAffected == 0 {
-        return fmt.Errorf("order not found")
+    if tableID != nil {
+        return releaseTableIfIdleTx(tx, *tableID)
     }
 
     return nil
 }
 
+// Cierra la orden si ya fue entregada y está saldada. Devuelve true si la cerró.
+func closeIfSettledTx(tx *sql.Tx, id int32, deductOn string) (bool, error) {
+    var status, paymentStatus string
+    var total float64
+    err := tx.QueryRow(
+        `SELECT status, payment_status, total_amount FROM orders WHERE id = $1`, id,
+    ).Scan(&status, &paymentStatus, &total)
+    if err != nil {
+        return false, fmt.Errorf("error getting order: %w", err)
+    }
+
+    if status != data.OrderStatusDelivered || !isSettled(paymentStatus, total) {
+        return false, nil
+    }
+
+    if err := updateOrderStatusTx(tx, id, data.OrderStatusClosed, deductOn); err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
+func isSettled(paymentStatus string, total float64) bool {
+    return paymentStatus == data.PaymentStatusPaid || total <= 0
+}
+
 func (r *OrderRepository) GetByStatus(status string) ([]data.Order, error) {
     query := `
-        SELECT id, order_number, status, total_amount, notes, created_at, updated_at
-        FROM orders 
+        SELECT ` + orderColumns + `
+        FROM orders
         WHERE status = $1
         ORDER BY created_at ASC
     `
-    
+
     rows, err := r.db.Query(query, status)
     if err != nil {
         return nil, fmt.Errorf("error querying orders by status: %w", err)
@@ -148,4 +381,930 @@ func (r *OrderRepository) GetByStatus(status string) ([]data.Order, error) {
     }
 
     return orders, nil
-}
\ No newline at end of file
+}
+
+// Filtros para las consultas de cocina y reportes
+type OrderFilter struct {
+    Status    string
+    OrderType string
+    From      *time.Time
+    To        *time.Time
+}
+
+func (f OrderFilter) where(args []any) (string, []any) {
+    clauses := make([]string, 0)
+
+    if f.Status != "" {
+        args = append(args, f.Status)
+        clauses = append(clauses, fmt.Sprintf("status = $%d", len(args)))
+    }
+    if f.OrderType != "" {
+        args = append(args, f.OrderType)
+        clauses = append(clauses, fmt.Sprintf("order_type = $%d", len(args)))
+    }
+    if f.From != nil {
+        args = append(args, *f.From)
+        clauses = append(clauses, fmt.Sprintf("created_at >= $%d", len(args)))
+    }
+    if f.To != nil {
+        args = append(args, *f.To)
+        clauses = append(clauses, fmt.Sprintf("created_at < $%d", len(args)))
+    }
+
+    if len(clauses) == 0 {
+        return "", args
+    }
+    return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *OrderRepository) Find(filter OrderFilter) ([]data.Order, error) {
+    where, args := filter.where(nil)
+    query := `SELECT ` + orderColumns + ` FROM orders` + where + ` ORDER BY created_at ASC`
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying orders: %w", err)
+    }
+    defer rows.Close()
+
+    var orders []data.Order
+    if err := ScanRowsToStruct(rows, &orders); err != nil {
+        return nil, fmt.Errorf("error scanning orders: %w", err)
+    }
+
+    return orders, nil
+}
+
+// Ventas agrupadas por tipo de orden (excluye canceladas y unidas)
+func (r *OrderRepository) GetSalesByType(filter OrderFilter) ([]data.SalesByType, error) {
+    where, args := filter.where(nil)
+    if where == "" {
+        where = " WHERE "
+    } else {
+        where += " AND "
+    }
+    where += "status NOT IN ('cancelled', 'merged')"
+
+    query := `
+        SELECT order_type, COUNT(*),
+               COALESCE(SUM(total_amount - delivery_fee - service_charge
+                            - CASE WHEN tax_mode = 'exclusive' THEN tax_amount ELSE 0 END), 0),
+               COALESCE(SUM(tax_amount), 0), COALESCE(SUM(service_charge), 0),
+               COALESCE(SUM(delivery_fee), 0), COALESCE(SUM(total_amount), 0)
+        FROM orders` + where + `
+        GROUP BY order_type
+        ORDER BY order_type
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying sales by type: %w", err)
+    }
+    defer rows.Close()
+
+    var sales []data.SalesByType
+    for rows.Next() {
+        var s data.SalesByType
+        if err := rows.Scan(&s.OrderType, &s.Orders, &s.ItemsSubtotal, &s.Tax, &s.ServiceCharges, &s.DeliveryFees, &s.Total); err != nil {
+            return nil, fmt.Errorf("error scanning sales by type: %w", err)
+        }
+        sales = append(sales, s)
+    }
+
+    return sales, rows.Err()
+}
+
+func (r *OrderRepository) GetOpenByTable(tableID int32) ([]data.Order, error) {
+    query := `
+        SELECT ` + orderColumns + `
+        FROM orders
+        WHERE table_id = $1 AND ` + openOrderCondition + `
+        ORDER BY created_at ASC
+    `
+
+    rows, err := r.db.Query(query, tableID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying orders by table: %w", err)
+    }
+    defer rows.Close()
+
+    var orders []data.Order
+    if err := ScanRowsToStruct(rows, &orders); err != nil {
+        return nil, fmt.Errorf("error scanning orders: %w", err)
+    }
+
+    return orders, nil
+}
+
+// Pre-órdenes aún no liberadas, por hora programada. From y To filtran por
+// scheduled_for (no por created_at) y Status se ignora.
+func (r *OrderRepository) GetScheduled(filter OrderFilter) ([]data.Order, error) {
+    args := []any{data.OrderStatusScheduled}
+    clauses := []string{"status = $1"}
+
+    if filter.OrderType != "" {
+        args = append(args, filter.OrderType)
+        clauses = append(clauses, fmt.Sprintf("order_type = $%d", len(args)))
+    }
+    if filter.From != nil {
+        args = append(args, *filter.From)
+        clauses = append(clauses, fmt.Sprintf("scheduled_for >= $%d", len(args)))
+    }
+    if filter.To != nil {
+        args = append(args, *filter.To)
+        clauses = append(clauses, fmt.Sprintf("scheduled_for < $%d", len(args)))
+    }
+
+    query := `
+        SELECT ` + orderColumns + `
+        FROM orders
+        WHERE ` + strings.Join(clauses, " AND ") + `
+        ORDER BY scheduled_for ASC, id ASC
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying scheduled orders: %w", err)
+    }
+    defer rows.Close()
+
+    var orders []data.Order
+    if err := ScanRowsToStruct(rows, &orders); err != nil {
+        return nil, fmt.Errorf("error scanning orders: %w", err)
+    }
+
+    return orders, nil
+}
+
+// Pasa una pre-orden a pending para que entre a cocina. Devuelve false si ya
+// no estaba programada (liberada a mano o cancelada entre tanto).
+func (r *OrderRepository) ReleaseScheduled(id int32) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var status string
+    if err := tx.QueryRow(`SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+        if err == sql.ErrNoRows {
+            return false, nil
+        }
+        return false, fmt.Errorf("error getting order: %w", err)
+    }
+
+    if status != data.OrderStatusScheduled {
+        return false, nil
+    }
+
+    if err := updateOrderStatusTx(tx, id, data.OrderStatusPending, r.deductOn); err != nil {
+        return false, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return false, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return true, nil
+}
+
+// Marcha un tiempo: sus items (y los de tiempos anteriores que sigan
+// retenidos) pasan a cocina. course 0 marcha el siguiente tiempo retenido.
+// Devuelve el tiempo marchado.
+func (r *OrderRepository) FireCourse(orderID, course int32) (int32, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return 0, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    status, err := lockOrderForKitchenTx(tx, orderID)
+    if err != nil {
+        return 0, err
+    }
+
+    var next sql.NullInt32
+    err = tx.QueryRow(`
+        SELECT MIN(course) FROM order_items WHERE order_id = $1 AND fired_at IS NULL
+    `, orderID).Scan(&next)
+    if err != nil {
+        return 0, fmt.Errorf("error getting next course: %w", err)
+    }
+
+    if !next.Valid {
+        return 0, fmt.Errorf("order has no courses left to fire")
+    }
+
+    if course == 0 {
+        course = next.Int32
+    } else if course < next.Int32 {
+        return 0, fmt.Errorf("course has already been fired")
+    }
+
+    result, err := tx.Exec(`
+        UPDATE order_items SET fired_at = NOW()
+        WHERE order_id = $1 AND fired_at IS NULL AND course <= $2
+    `, orderID, course)
+    if err != nil {
+        return 0, fmt.Errorf("error firing course: %w", err)
+    }
+
+    if fired, err := result.RowsAffected(); err != nil {
+        return 0, fmt.Errorf("error firing course: %w", err)
+    } else if fired == 0 {
+        return 0, fmt.Errorf("order has no items in that course")
+    }
+
+    _, err = tx.Exec(`UPDATE orders SET fired_course = GREATEST(fired_course, $2), updated_at = NOW() WHERE id = $1`, orderID, course)
+    if err != nil {
+        return 0, fmt.Errorf("error updating fired course: %w", err)
+    }
+
+    // Lo recién marchado aún no sale de cocina: la orden vuelve a preparación
+    if status == data.OrderStatusReady || status == data.OrderStatusDelivered {
+        if err := updateOrderStatusTx(tx, orderID, data.OrderStatusPreparing, r.deductOn); err != nil {
+            return 0, err
+        }
+    }
+
+    if err := finishKitchenUpdateTx(tx, orderID, r.deductOn); err != nil {
+        return 0, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return course, nil
+}
+
+// Tiempo que sale a cocina al crear la orden: en mesa, el primero que traiga;
+// fuera de mesa, todos
+func initialFiredCourse(orderType string, items []data.OrderItem) int32 {
+    var fired int32
+    for _, item := range items {
+        if orderType != data.OrderTypeDineIn {
+            if item.Course > fired {
+                fired = item.Course
+            }
+        } else if item.Course > 0 && (fired == 0 || item.Course < fired) {
+            fired = item.Course
+        }
+    }
+    return fired
+}
+
+func (r *OrderRepository) GetTaxes(orderID int32) ([]data.OrderTax, error) {
+    query := `
+        SELECT id, order_id, tax_rate_id, name, rate, base, amount
+        FROM order_taxes
+        WHERE order_id = $1
+        ORDER BY rate DESC, id
+    `
+
+    rows, err := r.db.Query(query, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order taxes: %w", err)
+    }
+    defer rows.Close()
+
+    var taxes []data.OrderTax
+    if err := ScanRowsToStruct(rows, &taxes); err != nil {
+        return nil, fmt.Errorf("error scanning order taxes: %w", err)
+    }
+
+    return taxes, nil
+}
+
+func (r *OrderRepository) GetItems(orderID int32) ([]data.OrderItem, error) {
+    query := `
+        SELECT ` + orderItemColumns + `
+        FROM order_items
+        WHERE order_id = $1
+        ORDER BY id
+    `
+
+    rows, err := r.db.Query(query, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order items: %w", err)
+    }
+    defer rows.Close()
+
+    var items []data.OrderItem
+    if err := ScanRowsToStruct(rows, &items); err != nil {
+        return nil, fmt.Errorf("error scanning order items: %w", err)
+    }
+
+    modifiers, err := r.getItemModifiers(orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range items {
+        items[i].Modifiers = modifiers[items[i].ID]
+    }
+
+    return items, nil
+}
+
+// Modificadores de los items de una orden, agrupados por item
+func (r *OrderRepository) getItemModifiers(orderID int32) (map[int32][]data.OrderItemModifier, error) {
+    query := `
+        SELECT m.order_item_id, m.modifier_id, m.name, m.price_delta, m.ingredient_id, m.quantity
+        FROM order_item_modifiers m
+        JOIN order_items oi ON oi.id = m.order_item_id
+        WHERE oi.order_id = $1
+        ORDER BY m.order_item_id, m.name
+    `
+
+    rows, err := r.db.Query(query, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order item modifiers: %w", err)
+    }
+    defer rows.Close()
+
+    var modifiers []data.OrderItemModifier
+    if err := ScanRowsToStruct(rows, &modifiers); err != nil {
+        return nil, fmt.Errorf("error scanning order item modifiers: %w", err)
+    }
+
+    byItem := make(map[int32][]data.OrderItemModifier)
+    for _, modifier := range modifiers {
+        byItem[modifier.OrderItemID] = append(byItem[modifier.OrderItemID], modifier)
+    }
+
+    return byItem, nil
+}
+
+// Agrega items a una orden y recalcula su total en la misma transacción
+func (r *OrderRepository) AddItems(orderID int32, items []data.OrderItem) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    // Una orden dividida debe anular su división antes de recibir más items
+    var split bool
+    if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM order_splits WHERE order_id = $1)`, orderID).Scan(&split); err != nil {
+        return fmt.Errorf("error checking order splits: %w", err)
+    }
+    if split {
+        return fmt.Errorf("order has been split")
+    }
+
+    for i := range items {
+        items[i].OrderID = orderID
+        if err := insertOrderItemTx(tx, &items[i]); err != nil {
+            return err
+        }
+    }
+
+    if _, err := recalculateOrderTotalTx(tx, orderID); err != nil {
+        return err
+    }
+
+    // Items nuevos devuelven a preparación una orden que ya estaba lista
+    if err := syncOrderStatusFromItemsTx(tx, orderID, r.deductOn); err != nil {
+        return err
+    }
+
+    // Una orden que ya pasó por el descuento de inventario descuenta también lo nuevo
+    if err := syncOrderStockTx(tx, orderID, r.deductOn); err != nil {
+        return err
+    }
+
+    if err := publishOrderEventTx(tx, orderID, data.OrderEventUpdated); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func insertOrderItemTx(tx *sql.Tx, item *data.OrderItem) error {
+    taxRateID, taxRate, err := productTaxRateTx(tx, item.ProductID)
+    if err != nil {
+        return err
+    }
+    item.TaxRateID = taxRateID
+    item.TaxRate = taxRate
+
+    if err := tx.QueryRow(`SELECT station_id FROM products WHERE id = $1`, item.ProductID).Scan(&item.StationID); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("product not found")
+        }
+        return fmt.Errorf("error getting product station: %w", err)
+    }
+    item.Status = data.OrderItemStatusPending
+
+    // El item sale a cocina de inmediato si su tiempo ya fue marchado;
+    // fuera de mesa no se esperan tiempos
+    query := `
+        INSERT INTO order_items (order_id, product_id, quantity, unit_price, subtotal, tax_rate_id, tax_rate,
+                                 station_id, status, notes, course, fired_at)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+               CASE WHEN o.order_type <> 'dine_in' OR $11 <= o.fired_course THEN NOW() END
+        FROM orders o
+        WHERE o.id = $1
+        RETURNING id, fired_at
+    `
+
+    err = tx.QueryRow(
+        query,
+        item.OrderID,
+        item.ProductID,
+        item.Quantity,
+        item.UnitPrice,
+        item.Subtotal,
+        item.TaxRateID,
+        item.TaxRate,
+        item.StationID,
+        item.Status,
+        item.Notes,
+        item.Course,
+    ).Scan(&item.ID, &item.FiredAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating order item: %w", err)
+    }
+
+    for i := range item.Modifiers {
+        modifier := &item.Modifiers[i]
+        modifier.OrderItemID = item.ID
+        _, err := tx.Exec(`
+            INSERT INTO order_item_modifiers (order_item_id, modifier_id, name, price_delta, ingredient_id, quantity)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, item.ID, modifier.ModifierID, modifier.Name, modifier.PriceDelta, modifier.IngredientID, modifier.Quantity)
+        if err != nil {
+            return fmt.Errorf("error saving order item modifiers: %w", err)
+        }
+    }
+
+    return nil
+}
+
+// Recalcula descuentos, subtotales, impuestos y el total de la orden:
+// items (menos sus descuentos) - descuentos de orden + impuestos no incluidos
+// + cargo por servicio + costo de domicilio
+func recalculateOrderTotalTx(tx *sql.Tx, orderID int32) (float64, error) {
+    items, err := lockOrderItemsTx(tx, orderID)
+    if err != nil {
+        return 0, err
+    }
+
+    discounts, err := getOrderDiscountsTx(tx, orderID)
+    if err != nil {
+        return 0, err
+    }
+
+    // Descuentos por item
+    itemDiscounts := make(map[int32]float64, len(items))
+    gross := make(map[int32]float64, len(items))
+    for _, item := range items {
+        gross[item.ID] = roundCents(item.UnitPrice * float64(item.Quantity))
+    }
+
+    for i := range discounts {
+        discount := &discounts[i]
+        if discount.OrderItemID == nil {
+            continue
+        }
+        base := gross[*discount.OrderItemID] - itemDiscounts[*discount.OrderItemID]
+        discount.Amount = discountAmount(discount.Type, discount.Value, base)
+        itemDiscounts[*discount.OrderItemID] += discount.Amount
+    }
+
+    var itemsSubtotal float64
+    subtotals := make(map[int32]float64, len(items))
+    for _, item := range items {
+        subtotal := roundCents(gross[item.ID] - itemDiscounts[item.ID])
+        subtotals[item.ID] = subtotal
+        itemsSubtotal += subtotal
+
+        _, err := tx.Exec(
+            `UPDATE order_items SET discount_amount = $2, subtotal = $3 WHERE id = $1`,
+            item.ID, roundCents(itemDiscounts[item.ID]), subtotal,
+        )
+        if err != nil {
+            return 0, fmt.Errorf("error updating order item subtotal: %w", err)
+        }
+    }
+
+    // Descuentos de orden sobre el subtotal ya descontado
+    var orderDiscount float64
+    for i := range discounts {
+        discount := &discounts[i]
+        if discount.OrderItemID != nil {
+            continue
+        }
+        discount.Amount = discountAmount(discount.Type, discount.Value, itemsSubtotal-orderDiscount)
+        orderDiscount += discount.Amount
+    }
+
+    for _, discount := range discounts {
+        if _, err := tx.Exec(`UPDATE order_discounts SET amount = $2 WHERE id = $1`, discount.ID, discount.Amount); err != nil {
+            return 0, fmt.Errorf("error updating order discount: %w", err)
+        }
+    }
+
+    var taxMode string
+    var serviceChargeRate float64
+    err = tx.QueryRow(`SELECT tax_mode, service_charge_rate FROM orders WHERE id = $1`, orderID).Scan(&taxMode, &serviceChargeRate)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return 0, fmt.Errorf("order not found")
+        }
+        return 0, fmt.Errorf("error getting order tax mode: %w", err)
+    }
+
+    taxNames, err := orderTaxNamesTx(tx, orderID)
+    if err != nil {
+        return 0, err
+    }
+
+    taxes := computeOrderTaxes(items, subtotals, itemsSubtotal, orderDiscount, taxMode, taxNames)
+    if err := replaceOrderTaxesTx(tx, orderID, taxes); err != nil {
+        return 0, err
+    }
+
+    var taxAmount float64
+    for _, tax := range taxes {
+        taxAmount += tax.Amount
+    }
+
+    // El cargo por servicio se calcula sobre los items ya descontados, antes de impuestos no incluidos
+    net := itemsSubtotal - orderDiscount
+    serviceCharge := roundCents(math.Max(net, 0) * serviceChargeRate / 100)
+    if taxMode == data.TaxModeExclusive {
+        net += taxAmount
+    }
+
+    query := `
+        UPDATE orders
+        SET discount_amount = $2,
+            tax_amount = $3,
+            service_charge = $4,
+            total_amount = $5 + delivery_fee,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+        RETURNING total_amount
+    `
+
+    var total float64
+    err = tx.QueryRow(
+        query, orderID, roundCents(orderDiscount), roundCents(taxAmount), serviceCharge, roundCents(net+serviceCharge),
+    ).Scan(&total)
+    if err != nil {
+        return 0, fmt.Errorf("error updating order total: %w", err)
+    }
+
+    return total, nil
+}
+
+// Agrupa por tarifa los montos netos de los items, repartiendo los descuentos de orden
+// en proporción al subtotal de cada item. Con precios que incluyen impuesto la base se
+// extrae del monto; si no, el impuesto se calcula sobre él.
+func computeOrderTaxes(items []data.OrderItem, subtotals map[int32]float64, itemsSubtotal, orderDiscount float64, taxMode string, names map[int32]string) []data.OrderTax {
+    taxes := make([]data.OrderTax, 0)
+    index := make(map[string]int)
+
+    for _, item := range items {
+        net := subtotals[item.ID]
+        if itemsSubtotal > 0 {
+            net -= orderDiscount * subtotals[item.ID] / itemsSubtotal
+        }
+        if net <= 0 {
+            continue
+        }
+
+        key := fmt.Sprintf("%v", item.TaxRate)
+        if item.TaxRateID != nil {
+            key = fmt.Sprintf("%d:%v", *item.TaxRateID, item.TaxRate)
+        }
+
+        i, ok := index[key]
+        if !ok {
+            tax := data.OrderTax{TaxRateID: item.TaxRateID, Rate: item.TaxRate, Name: "Exento"}
+            if item.TaxRateID != nil {
+                tax.Name = names[*item.TaxRateID]
+            }
+            taxes = append(taxes, tax)
+            i = len(taxes) - 1
+            index[key] = i
+        }
+        taxes[i].Base += net
+    }
+
+    for i := range taxes {
+        tax := &taxes[i]
+        amount := tax.Base
+        if taxMode == data.TaxModeExclusive {
+            tax.Amount = roundCents(amount * tax.Rate / 100)
+            tax.Base = roundCents(amount)
+        } else {
+            tax.Amount = roundCents(amount - amount/(1+tax.Rate/100))
+            tax.Base = roundCents(amount - tax.Amount)
+        }
+    }
+
+    return taxes
+}
+
+func lockOrderItemsTx(tx *sql.Tx, orderID int32) ([]data.OrderItem, error) {
+    rows, err := tx.Query(`
+        SELECT ` + orderItemColumns + `
+        FROM order_items
+        WHERE order_id = $1
+        ORDER BY id
+        FOR UPDATE
+    `, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order items: %w", err)
+    }
+    defer rows.Close()
+
+    var items []data.OrderItem
+    if err := ScanRowsToStruct(rows, &items); err != nil {
+        return nil, fmt.Errorf("error scanning order items: %w", err)
+    }
+
+    return items, nil
+}
+
+// Monto de un descuento sobre una base, sin exceder la base
+func discountAmount(discountType string, value, base float64) float64 {
+    if base <= 0 {
+        return 0
+    }
+
+    amount := value
+    if discountType == data.DiscountTypePercentage {
+        amount = base * value / 100
+    }
+
+    return roundCents(math.Min(amount, base))
+}
+
+// Mueve una orden abierta a otra mesa
+func (r *OrderRepository) MoveToTable(orderID, tableID int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := moveToTableTx(tx, orderID, tableID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func moveToTableTx(tx *sql.Tx, orderID, tableID int32) error {
+    var previousTableID *int32
+    err := tx.QueryRow(
+        `SELECT table_id FROM orders WHERE id = $1 AND `+openOrderCondition+` FOR UPDATE`,
+        orderID,
+    ).Scan(&previousTableID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("open order not found")
+        }
+        return fmt.Errorf("error getting order: %w", err)
+    }
+
+    if _, err := tx.Exec(`UPDATE orders SET table_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, orderID, tableID); err != nil {
+        return fmt.Errorf("error moving order: %w", err)
+    }
+
+    if err := publishOrderEventTx(tx, orderID, data.OrderEventUpdated); err != nil {
+        return err
+    }
+
+    if err := occupyTableTx(tx, tableID); err != nil {
+        return err
+    }
+
+    if previousTableID != nil && *previousTableID != tableID {
+        return releaseTableIfIdleTx(tx, *previousTableID)
+    }
+
+    return nil
+}
+
+// Une las cuentas de la mesa origen en la cuenta abierta más antigua de la
+// mesa destino, todo en una transacción. Si la mesa destino no tiene cuenta,
+// las órdenes de la mesa origen se mueven tal cual y, si son varias, se
+// consolidan en la primera. Devuelve la orden resultante.
+func (r *OrderRepository) MergeTables(sourceTableID, targetTableID int32) (int32, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return 0, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    sourceIDs, err := lockOpenOrderIDsByTableTx(tx, sourceTableID)
+    if err != nil {
+        return 0, err
+    }
+
+    if len(sourceIDs) == 0 {
+        return 0, fmt.Errorf("source table has no open orders")
+    }
+
+    targetIDs, err := lockOpenOrderIDsByTableTx(tx, targetTableID)
+    if err != nil {
+        return 0, err
+    }
+
+    targetOrderID := sourceIDs[0]
+    mergeFrom := sourceTableID
+    if len(targetIDs) > 0 {
+        targetOrderID = targetIDs[0]
+    } else {
+        for _, id := range sourceIDs {
+            if err := moveToTableTx(tx, id, targetTableID); err != nil {
+                return 0, err
+            }
+        }
+        mergeFrom = targetTableID
+    }
+
+    if len(targetIDs) > 0 || len(sourceIDs) > 1 {
+        if err := r.mergeIntoTx(tx, targetOrderID, mergeFrom); err != nil {
+            return 0, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return targetOrderID, nil
+}
+
+// Órdenes abiertas de una mesa, de la más antigua a la más reciente, bloqueadas
+func lockOpenOrderIDsByTableTx(tx *sql.Tx, tableID int32) ([]int32, error) {
+    rows, err := tx.Query(
+        `SELECT id FROM orders WHERE table_id = $1 AND `+openOrderCondition+` ORDER BY created_at, id FOR UPDATE`,
+        tableID,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("error querying orders by table: %w", err)
+    }
+    defer rows.Close()
+
+    var ids []int32
+    for rows.Next() {
+        var id int32
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("error scanning orders by table: %w", err)
+        }
+        ids = append(ids, id)
+    }
+
+    return ids, rows.Err()
+}
+
+// Une las órdenes abiertas de sourceTableID dentro de la orden destino.
+// Los items, descuentos y cupones se reasignan, las órdenes origen quedan
+// como "merged" y la mesa origen se libera. Solo se unen cuentas sin pagos
+// ni división, para que lo ya cobrado no se pierda del saldo.
+func (r *OrderRepository) mergeIntoTx(tx *sql.Tx, targetOrderID, sourceTableID int32) error {
+    var targetNumber string
+    err := tx.QueryRow(
+        `SELECT order_number FROM orders WHERE id = $1 AND `+openOrderCondition+` FOR UPDATE`,
+        targetOrderID,
+    ).Scan(&targetNumber)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("open order not found")
+        }
+        return fmt.Errorf("error getting target order: %w", err)
+    }
+
+    if err := checkOrderMergeableTx(tx, targetOrderID); err != nil {
+        return err
+    }
+
+    rows, err := tx.Query(
+        `SELECT id FROM orders WHERE table_id = $1 AND id <> $2 AND `+openOrderCondition+` FOR UPDATE`,
+        sourceTableID, targetOrderID,
+    )
+    if err != nil {
+        return fmt.Errorf("error querying source orders: %w", err)
+    }
+
+    var sourceIDs []int32
+    for rows.Next() {
+        var id int32
+        if err := rows.Scan(&id); err != nil {
+            rows.Close()
+            return fmt.Errorf("error scanning source orders: %w", err)
+        }
+        sourceIDs = append(sourceIDs, id)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("error scanning source orders: %w", err)
+    }
+
+    if len(sourceIDs) == 0 {
+        return fmt.Errorf("no open orders to merge")
+    }
+
+    for _, sourceID := range sourceIDs {
+        if err := checkOrderMergeableTx(tx, sourceID); err != nil {
+            return err
+        }
+    }
+
+    // Una orden lleva a lo sumo un cupón
+    var couponOrders int
+    err = tx.QueryRow(
+        `SELECT COUNT(DISTINCT order_id) FROM order_discounts WHERE order_id = ANY($1) AND coupon_id IS NOT NULL`,
+        pq.Array(append([]int32{targetOrderID}, sourceIDs...)),
+    ).Scan(&couponOrders)
+    if err != nil {
+        return fmt.Errorf("error checking order coupons: %w", err)
+    }
+    if couponOrders > 1 {
+        return fmt.Errorf("more than one of the orders has a coupon")
+    }
+
+    for _, sourceID := range sourceIDs {
+        if _, err := tx.Exec(`UPDATE order_items SET order_id = $1 WHERE order_id = $2`, targetOrderID, sourceID); err != nil {
+            return fmt.Errorf("error moving order items: %w", err)
+        }
+
+        if _, err := tx.Exec(`UPDATE order_discounts SET order_id = $1 WHERE order_id = $2`, targetOrderID, sourceID); err != nil {
+            return fmt.Errorf("error moving order discounts: %w", err)
+        }
+
+        if _, err := tx.Exec(`UPDATE coupon_redemptions SET order_id = $1 WHERE order_id = $2`, targetOrderID, sourceID); err != nil {
+            return fmt.Errorf("error moving coupon redemptions: %w", err)
+        }
+
+        _, err := tx.Exec(`
+            UPDATE orders
+            SET status = $2, total_amount = 0, notes = TRIM(notes || ' ' || $3), updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1
+        `, sourceID, data.OrderStatusMerged, "Merged into "+targetNumber)
+        if err != nil {
+            return fmt.Errorf("error closing merged order: %w", err)
+        }
+
+        if err := publishOrderEventTx(tx, sourceID, data.OrderEventStatusChanged); err != nil {
+            return err
+        }
+    }
+
+    if _, err := recalculateOrderTotalTx(tx, targetOrderID); err != nil {
+        return err
+    }
+
+    if err := syncOrderStockTx(tx, targetOrderID, r.deductOn); err != nil {
+        return err
+    }
+
+    if err := publishOrderEventTx(tx, targetOrderID, data.OrderEventUpdated); err != nil {
+        return err
+    }
+
+    return releaseTableIfIdleTx(tx, sourceTableID)
+}
+
+// Una cuenta con pagos o dividida no se une: sus cobros y partes quedarían
+// fuera del saldo de la orden resultante
+func checkOrderMergeableTx(tx *sql.Tx, orderID int32) error {
+    var orderNumber, paymentStatus string
+    var split bool
+    err := tx.QueryRow(`
+        SELECT order_number, payment_status, EXISTS (SELECT 1 FROM order_splits WHERE order_id = $1)
+        FROM orders
+        WHERE id = $1
+    `, orderID).Scan(&orderNumber, &paymentStatus, &split)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
+        return fmt.Errorf("error checking order payments: %w", err)
+    }
+
+    if paymentStatus != data.PaymentStatusUnpaid {
+        return fmt.Errorf("order %s has payments", orderNumber)
+    }
+    if split {
+        return fmt.Errorf("order %s has been split", orderNumber)
+    }
+
+    return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+// Canal de Postgres por el que se avisa de nuevos eventos de órdenes
+const OrderEventsChannel = "order_events"
+
+type OrderEventRepository struct {
+    *BaseRepository
+}
+
+func NewOrderEventRepository(db *sql.DB) *OrderEventRepository {
+    return &OrderEventRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+// Posición de un cliente en el feed. El id serial se asigna al insertar y no
+// sigue el orden en que confirman las transacciones, así que el feed se ordena
+// por (tx_id, id) y solo entrega eventos de transacciones anteriores al xmin
+// de la foto: esas ya terminaron y ningún evento puede aparecer detrás.
+type OrderEventCursor struct {
+    TxID int64
+    ID   int64
+}
+
+// Formato del id SSE: "<tx_id>-<id>"
+func (c OrderEventCursor) String() string {
+    return fmt.Sprintf("%d-%d", c.TxID, c.ID)
+}
+
+func ParseOrderEventCursor(value string) (OrderEventCursor, error) {
+    txPart, idPart, ok := strings.Cut(value, "-")
+    if !ok {
+        return OrderEventCursor{}, fmt.Errorf("invalid event cursor %q", value)
+    }
+
+    txID, err := strconv.ParseInt(txPart, 10, 64)
+    if err != nil || txID < 0 {
+        return OrderEventCursor{}, fmt.Errorf("invalid event cursor %q", value)
+    }
+
+    id, err := strconv.ParseInt(idPart, 10, 64)
+    if err != nil || id < 0 {
+        return OrderEventCursor{}, fmt.Errorf("invalid event cursor %q", value)
+    }
+
+    return OrderEventCursor{TxID: txID, ID: id}, nil
+}
+
+// Eventos confirmados posteriores al cursor, en orden. pending indica que hay
+// eventos ya confirmados retenidos detrás de una transacción que sigue
+// abierta; se entregan en cuanto termine, así que conviene volver a leer pronto.
+func (r *OrderEventRepository) GetSince(after OrderEventCursor, limit int) ([]data.OrderEvent, bool, error) {
+    // Una sola foto para que el xmin corresponda con los eventos leídos
+    tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+    if err != nil {
+        return nil, false, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var watermark int64
+    if err := tx.QueryRow(`SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`).Scan(&watermark); err != nil {
+        return nil, false, fmt.Errorf("error getting order event watermark: %w", err)
+    }
+
+    query := `
+        SELECT e.id, e.tx_id, e.order_id, o.order_number, o.order_type, e.type, e.status, e.created_at
+        FROM order_events e
+        JOIN orders o ON o.id = e.order_id
+        WHERE (e.tx_id, e.id) > ($1, $2) AND e.tx_id < $3
+        ORDER BY e.tx_id, e.id
+        LIMIT $4
+    `
+
+    rows, err := tx.Query(query, after.TxID, after.ID, watermark, limit)
+    if err != nil {
+        return nil, false, fmt.Errorf("error querying order events: %w", err)
+    }
+    defer rows.Close()
+
+    var events []data.OrderEvent
+    for rows.Next() {
+        var event data.OrderEvent
+        err := rows.Scan(
+            &event.ID,
+            &event.TxID,
+            &event.OrderID,
+            &event.OrderNumber,
+            &event.OrderType,
+            &event.Type,
+            &event.Status,
+            &event.CreatedAt,
+        )
+        if err != nil {
+            return nil, false, fmt.Errorf("error scanning order events: %w", err)
+        }
+        events = append(events, event)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, false, fmt.Errorf("error scanning order events: %w", err)
+    }
+
+    var pending bool
+    err = tx.QueryRow(
+        `SELECT EXISTS (SELECT 1 FROM order_events WHERE tx_id >= $1 AND (tx_id, id) > ($2, $3))`,
+        watermark, after.TxID, after.ID,
+    ).Scan(&pending)
+    if err != nil {
+        return nil, false, fmt.Errorf("error checking pending order events: %w", err)
+    }
+
+    return events, pending, nil
+}
+
+// Posición actual del feed; los nuevos suscriptores sin Last-Event-ID arrancan
+// desde aquí. Lo que confirme después del xmin se entrega, aunque algún evento
+// ya confirmado pueda repetirse.
+func (r *OrderEventRepository) GetCurrentCursor() (OrderEventCursor, error) {
+    var cursor OrderEventCursor
+    if err := r.db.QueryRow(`SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`).Scan(&cursor.TxID); err != nil {
+        return cursor, fmt.Errorf("error getting order event cursor: %w", err)
+    }
+
+    return cursor, nil
+}
+
+// Borra los eventos anteriores a before; ya no se pueden reproducir al reconectar
+func (r *OrderEventRepository) DeleteBefore(before time.Time) (int64, error) {
+    result, err := r.db.Exec(`DELETE FROM order_events WHERE created_at < $1`, before)
+    if err != nil {
+        return 0, fmt.Errorf("error deleting order events: %w", err)
+    }
+
+    return result.RowsAffected()
+}
+
+// Registra el evento dentro de la transacción y lo notifica por LISTEN/NOTIFY;
+// Postgres solo entrega la notificación si la transacción confirma.
+func publishOrderEventTx(tx *sql.Tx, orderID int32, eventType string) error {
+    _, err := tx.Exec(`
+        WITH event AS (
+            INSERT INTO order_events (order_id, type, status, tx_id)
+            SELECT id, $2, status, pg_current_xact_id()::text::bigint FROM orders WHERE id = $1
+            RETURNING id
+        )
+        SELECT pg_notify($3, id::text) FROM event
+    `, orderID, eventType, OrderEventsChannel)
+    if err != nil {
+        return fmt.Errorf("error publishing order event: %w", err)
+    }
+
+    return nil
+}
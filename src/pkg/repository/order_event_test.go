@@ -0,0 +1,109 @@
+package repository
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestParseOrderEventCursor(t *testing.T) {
+    tests := []struct {
+        value   string
+        want    OrderEventCursor
+        wantErr bool
+    }{
+        {value: "812-45", want: OrderEventCursor{TxID: 812, ID: 45}},
+        {value: "0-0", want: OrderEventCursor{}},
+        {value: "45", wantErr: true},
+        {value: "812-", wantErr: true},
+        {value: "-45", wantErr: true},
+        {value: "812--45", wantErr: true},
+        {value: "a-b", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.value, func(t *testing.T) {
+            got, err := ParseOrderEventCursor(tt.value)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("ParseOrderEventCursor() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("ParseOrderEventCursor() = %+v, want %+v", got, tt.want)
+            }
+            if !tt.wantErr && got.String() != tt.value {
+                t.Errorf("String() = %q, want %q", got.String(), tt.value)
+            }
+        })
+    }
+}
+
+// Un evento con id menor que confirma después de otro ya leído no se pierde
+func TestOrderEventsFollowCommitOrder(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    events := NewOrderEventRepository(db)
+
+    order := createTestOrder(t, orders, insertTestTable(t, db, 1))
+
+    cursor, err := events.GetCurrentCursor()
+    if err != nil {
+        t.Fatalf("GetCurrentCursor() error = %v", err)
+    }
+
+    slow, err := db.Begin()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer slow.Rollback()
+    if err := publishOrderEventTx(slow, order.ID, data.OrderEventUpdated); err != nil {
+        t.Fatal(err)
+    }
+
+    fast, err := db.Begin()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := publishOrderEventTx(fast, order.ID, data.OrderEventStatusChanged); err != nil {
+        t.Fatal(err)
+    }
+    if err := fast.Commit(); err != nil {
+        t.Fatal(err)
+    }
+
+    // El evento confirmado queda retenido mientras la transacción anterior siga abierta
+    got, pending, err := events.GetSince(cursor, 10)
+    if err != nil {
+        t.Fatalf("GetSince() error = %v", err)
+    }
+    if len(got) != 0 || !pending {
+        t.Fatalf("GetSince() = %d events, pending %v; want 0 events, pending", len(got), pending)
+    }
+
+    if err := slow.Commit(); err != nil {
+        t.Fatal(err)
+    }
+
+    got, pending, err = events.GetSince(cursor, 10)
+    if err != nil {
+        t.Fatalf("GetSince() error = %v", err)
+    }
+    if pending {
+        t.Error("GetSince() pending = true after every transaction finished")
+    }
+    if len(got) != 2 {
+        t.Fatalf("GetSince() = %d events, want 2", len(got))
+    }
+    if got[0].Type != data.OrderEventUpdated || got[1].Type != data.OrderEventStatusChanged {
+        t.Errorf("GetSince() types = %s, %s; want commit order", got[0].Type, got[1].Type)
+    }
+
+    // Al reanudar desde el primero solo llega el segundo
+    resume := OrderEventCursor{TxID: got[0].TxID, ID: got[0].ID}
+    rest, _, err := events.GetSince(resume, 10)
+    if err != nil {
+        t.Fatalf("GetSince() error = %v", err)
+    }
+    if len(rest) != 1 || rest[0].ID != got[1].ID {
+        t.Errorf("GetSince(%s) = %+v, want only event %d", resume, rest, got[1].ID)
+    }
+}
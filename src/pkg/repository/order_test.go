@@ -0,0 +1,215 @@
+package repository
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestComputeOrderTaxes(t *testing.T) {
+    iva, reduced := int32(1), int32(2)
+    names := map[int32]string{iva: "IVA", reduced: "IVA reducido"}
+
+    tests := []struct {
+        name          string
+        items         []data.OrderItem
+        subtotals     map[int32]float64
+        itemsSubtotal float64
+        orderDiscount float64
+        taxMode       string
+        want          []data.OrderTax
+    }{
+        {
+            name: "exclusive groups items with the same rate",
+            items: []data.OrderItem{
+                {ID: 1, TaxRateID: &iva, TaxRate: 16},
+                {ID: 2, TaxRateID: &iva, TaxRate: 16},
+            },
+            subtotals:     map[int32]float64{1: 100, 2: 50},
+            itemsSubtotal: 150,
+            taxMode:       data.TaxModeExclusive,
+            want: []data.OrderTax{
+                {TaxRateID: &iva, Name: "IVA", Rate: 16, Base: 150, Amount: 24},
+            },
+        },
+        {
+            name:          "inclusive extracts the tax from the amount",
+            items:         []data.OrderItem{{ID: 1, TaxRateID: &iva, TaxRate: 16}},
+            subtotals:     map[int32]float64{1: 116},
+            itemsSubtotal: 116,
+            taxMode:       data.TaxModeInclusive,
+            want: []data.OrderTax{
+                {TaxRateID: &iva, Name: "IVA", Rate: 16, Base: 100, Amount: 16},
+            },
+        },
+        {
+            name: "order discount is spread by item subtotal",
+            items: []data.OrderItem{
+                {ID: 1, TaxRateID: &iva, TaxRate: 16},
+                {ID: 2},
+            },
+            subtotals:     map[int32]float64{1: 100, 2: 100},
+            itemsSubtotal: 200,
+            orderDiscount: 50,
+            taxMode:       data.TaxModeExclusive,
+            want: []data.OrderTax{
+                {TaxRateID: &iva, Name: "IVA", Rate: 16, Base: 75, Amount: 12},
+                {Name: "Exento", Base: 75},
+            },
+        },
+        {
+            name: "different rates stay apart",
+            items: []data.OrderItem{
+                {ID: 1, TaxRateID: &iva, TaxRate: 16},
+                {ID: 2, TaxRateID: &reduced, TaxRate: 8},
+            },
+            subtotals:     map[int32]float64{1: 10, 2: 10},
+            itemsSubtotal: 20,
+            taxMode:       data.TaxModeExclusive,
+            want: []data.OrderTax{
+                {TaxRateID: &iva, Name: "IVA", Rate: 16, Base: 10, Amount: 1.6},
+                {TaxRateID: &reduced, Name: "IVA reducido", Rate: 8, Base: 10, Amount: 0.8},
+            },
+        },
+        {
+            name:          "fully discounted items are skipped",
+            items:         []data.OrderItem{{ID: 1, TaxRateID: &iva, TaxRate: 16}},
+            subtotals:     map[int32]float64{1: 0},
+            itemsSubtotal: 0,
+            taxMode:       data.TaxModeExclusive,
+            want:          []data.OrderTax{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := computeOrderTaxes(tt.items, tt.subtotals, tt.itemsSubtotal, tt.orderDiscount, tt.taxMode, names)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("computeOrderTaxes() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestInitialFiredCourse(t *testing.T) {
+    courses := func(values ...int32) []data.OrderItem {
+        items := make([]data.OrderItem, 0, len(values))
+        for _, course := range values {
+            items = append(items, data.OrderItem{Course: course})
+        }
+        return items
+    }
+
+    tests := []struct {
+        name      string
+        orderType string
+        items     []data.OrderItem
+        want      int32
+    }{
+        {"dine in fires the first course", data.OrderTypeDineIn, courses(0, 2, 1), 1},
+        {"dine in with drinks only", data.OrderTypeDineIn, courses(0, 0), 0},
+        {"dine in without course 1", data.OrderTypeDineIn, courses(3, 2), 2},
+        {"takeaway fires every course", data.OrderTypeTakeaway, courses(0, 2, 1), 2},
+        {"delivery without items", data.OrderTypeDelivery, nil, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := initialFiredCourse(tt.orderType, tt.items); got != tt.want {
+                t.Errorf("initialFiredCourse() = %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestMergeTablesCarriesDiscounts(t *testing.T) {
+    db := openTestDB(t)
+    repo := NewOrderRepository(db, data.OrderStatusPreparing)
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    sourceTable := insertTestTable(t, db, 1)
+    targetTable := insertTestTable(t, db, 2)
+
+    source := createTestOrder(t, repo, sourceTable, data.OrderItem{ProductID: product, Quantity: 1, UnitPrice: 10})
+    target := createTestOrder(t, repo, targetTable, data.OrderItem{ProductID: product, Quantity: 2, UnitPrice: 10})
+
+    coupon := mustInsert(t, db, `INSERT INTO coupons (code, type, value, times_used) VALUES ('HOLA', 'fixed', 3, 1) RETURNING id`)
+    mustExec(t, db, `INSERT INTO coupon_redemptions (coupon_id, order_id, customer_ref) VALUES ($1, $2, 'ana')`, coupon, source.ID)
+    mustExec(t, db, `
+        INSERT INTO order_discounts (order_id, coupon_id, name, type, value)
+        VALUES ($1, $2, 'HOLA', 'fixed', 3)
+    `, source.ID, coupon)
+
+    merged, err := repo.MergeTables(sourceTable, targetTable)
+    if err != nil {
+        t.Fatalf("MergeTables() error = %v", err)
+    }
+    if merged != target.ID {
+        t.Fatalf("MergeTables() = %d, want %d", merged, target.ID)
+    }
+
+    if got := mustQueryFloat(t, db, `SELECT total_amount FROM orders WHERE id = $1`, target.ID); got != 27 {
+        t.Errorf("target total = %v, want 27", got)
+    }
+    if got := mustQueryFloat(t, db, `SELECT COUNT(*) FROM order_discounts WHERE order_id = $1`, target.ID); got != 1 {
+        t.Errorf("target discounts = %v, want 1", got)
+    }
+    if got := mustQueryFloat(t, db, `SELECT COUNT(*) FROM coupon_redemptions WHERE order_id = $1`, target.ID); got != 1 {
+        t.Errorf("target coupon redemptions = %v, want 1", got)
+    }
+
+    var status string
+    if err := db.QueryRow(`SELECT status FROM orders WHERE id = $1`, source.ID).Scan(&status); err != nil {
+        t.Fatal(err)
+    }
+    if status != data.OrderStatusMerged {
+        t.Errorf("source status = %s, want %s", status, data.OrderStatusMerged)
+    }
+}
+
+func TestMergeTablesRefusesPaidOrSplitOrders(t *testing.T) {
+    const (
+        paid  = `UPDATE orders SET payment_status = 'partially_paid' WHERE id = $1`
+        split = `INSERT INTO order_splits (order_id, split_number, amount) VALUES ($1, 1, 10)`
+    )
+
+    tests := []struct {
+        name     string
+        prepare  string
+        onTarget bool
+    }{
+        {"source with payments", paid, false},
+        {"source split", split, false},
+        {"target with payments", paid, true},
+        {"target split", split, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db := openTestDB(t)
+            repo := NewOrderRepository(db, data.OrderStatusPreparing)
+
+            product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+            sourceTable := insertTestTable(t, db, 1)
+            targetTable := insertTestTable(t, db, 2)
+
+            source := createTestOrder(t, repo, sourceTable, data.OrderItem{ProductID: product, Quantity: 1, UnitPrice: 10})
+            target := createTestOrder(t, repo, targetTable, data.OrderItem{ProductID: product, Quantity: 1, UnitPrice: 10})
+
+            prepared := source.ID
+            if tt.onTarget {
+                prepared = target.ID
+            }
+            mustExec(t, db, tt.prepare, prepared)
+
+            if _, err := repo.MergeTables(sourceTable, targetTable); err == nil {
+                t.Fatal("MergeTables() error = nil, want an error")
+            }
+
+            if got := mustQueryFloat(t, db, `SELECT COUNT(*) FROM order_items WHERE order_id = $1`, source.ID); got != 1 {
+                t.Errorf("source items = %v, want 1 (merge must roll back)", got)
+            }
+        })
+    }
+}
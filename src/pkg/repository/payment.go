@@ -0,0 +1,471 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "math"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type PaymentRepository struct {
+    *BaseRepository
+    // Estado en el que se descuenta el inventario, necesario al cerrar la orden
+    deductOn string
+}
+
+func NewPaymentRepository(db *sql.DB, deductOn string) *PaymentRepository {
+    return &PaymentRepository{
+        BaseRepository: NewBaseRepository(db),
+        deductOn:       deductOn,
+    }
+}
+
+const paymentColumns = `id, order_id, split_id, shift_id, user_id, method, amount, tendered, change_amount,
+        reference, tip_amount, tip_staff_id, status, reversal_reason, reversed_at, created_at`
+
+func scanPayment(row interface{ Scan(...any) error }, payment *data.Payment) error {
+    return row.Scan(
+        &payment.ID,
+        &payment.OrderID,
+        &payment.SplitID,
+        &payment.ShiftID,
+        &payment.UserID,
+        &payment.Method,
+        &payment.Amount,
+        &payment.Tendered,
+        &payment.Change,
+        &payment.Reference,
+        &payment.TipAmount,
+        &payment.TipStaffID,
+        &payment.Status,
+        &payment.ReversalReason,
+        &payment.ReversedAt,
+        &payment.CreatedAt,
+    )
+}
+
+func (r *PaymentRepository) GetByOrder(orderID int32) ([]data.Payment, error) {
+    query := `
+        SELECT ` + paymentColumns + `
+        FROM payments
+        WHERE order_id = $1
+        ORDER BY created_at
+    `
+
+    rows, err := r.db.Query(query, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying payments: %w", err)
+    }
+    defer rows.Close()
+
+    var payments []data.Payment
+    if err := ScanRowsToStruct(rows, &payments); err != nil {
+        return nil, fmt.Errorf("error scanning payments: %w", err)
+    }
+
+    return payments, nil
+}
+
+func (r *PaymentRepository) GetByID(id int32) (*data.Payment, error) {
+    query := `
+        SELECT ` + paymentColumns + `
+        FROM payments
+        WHERE id = $1
+    `
+
+    var payment data.Payment
+    err := scanPayment(r.db.QueryRow(query, id), &payment)
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting payment: %w", err)
+    }
+
+    return &payment, nil
+}
+
+// Propinas de pagos vigentes agrupadas por empleado en el periodo [from, to)
+func (r *PaymentRepository) GetTipsByStaff(from, to *time.Time) ([]data.TipSummary, error) {
+    clauses := []string{"p.status = $1", "p.tip_amount > 0"}
+    args := []any{data.PaymentCompleted, data.PaymentMethodCash}
+
+    if from != nil {
+        args = append(args, *from)
+        clauses = append(clauses, fmt.Sprintf("p.created_at >= $%d", len(args)))
+    }
+    if to != nil {
+        args = append(args, *to)
+        clauses = append(clauses, fmt.Sprintf("p.created_at < $%d", len(args)))
+    }
+
+    query := `
+        SELECT p.tip_staff_id, u.full_name, COUNT(*),
+               COALESCE(SUM(p.tip_amount) FILTER (WHERE p.method = $2), 0),
+               COALESCE(SUM(p.tip_amount), 0)
+        FROM payments p
+        JOIN users u ON u.id = p.tip_staff_id
+        WHERE ` + strings.Join(clauses, " AND ") + `
+        GROUP BY p.tip_staff_id, u.full_name
+        ORDER BY u.full_name
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying tips by staff: %w", err)
+    }
+    defer rows.Close()
+
+    var tips []data.TipSummary
+    for rows.Next() {
+        var tip data.TipSummary
+        if err := rows.Scan(&tip.StaffID, &tip.FullName, &tip.Payments, &tip.CashTips, &tip.Total); err != nil {
+            return nil, fmt.Errorf("error scanning tips by staff: %w", err)
+        }
+        tips = append(tips, tip)
+    }
+
+    return tips, rows.Err()
+}
+
+// Resultado de registrar pagos sobre una orden
+type PaymentResult struct {
+    PaymentStatus string  `json:"payment_status"`
+    AmountPaid    float64 `json:"amount_paid"`
+    Balance       float64 `json:"balance"`
+    OrderClosed   bool    `json:"order_closed"`
+}
+
+// Registra uno o varios pagos (pago mixto) y actualiza el estado de la orden
+// en la misma transacción. Si la orden queda saldada se cierra.
+func (r *PaymentRepository) CreateBatch(orderID int32, payments []data.Payment) (*PaymentResult, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return nil, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    total, status, err := lockOrderForPaymentTx(tx, orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    if status == data.OrderStatusCancelled || status == data.OrderStatusMerged || status == data.OrderStatusClosed {
+        return nil, fmt.Errorf("order does not accept payments")
+    }
+
+    paid, err := paidAmountTx(tx, orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    var incoming float64
+    for _, payment := range payments {
+        incoming += payment.Amount
+    }
+
+    if roundCents(paid+incoming) > roundCents(total) {
+        return nil, fmt.Errorf("payment exceeds order balance of %.2f", roundCents(total-paid))
+    }
+
+    splitsTouched := make(map[int32]bool)
+    for i := range payments {
+        payment := &payments[i]
+        payment.OrderID = orderID
+        payment.Status = data.PaymentCompleted
+
+        if payment.ShiftID != nil {
+            if err := checkShiftOpenTx(tx, *payment.ShiftID); err != nil {
+                return nil, err
+            }
+        }
+
+        if payment.TipStaffID != nil {
+            var exists bool
+            if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, *payment.TipStaffID).Scan(&exists); err != nil {
+                return nil, fmt.Errorf("error checking tip staff member: %w", err)
+            }
+            if !exists {
+                return nil, fmt.Errorf("tip staff member not found")
+            }
+        }
+
+        if payment.SplitID != nil {
+            if err := checkSplitBalanceTx(tx, orderID, *payment.SplitID, payment.Amount); err != nil {
+                return nil, err
+            }
+            splitsTouched[*payment.SplitID] = true
+        }
+
+        if err := insertPaymentTx(tx, payment); err != nil {
+            return nil, err
+        }
+    }
+
+    for splitID := range splitsTouched {
+        if err := settleSplitIfPaidTx(tx, splitID); err != nil {
+            return nil, err
+        }
+    }
+
+    result := &PaymentResult{}
+    if err := refreshPaymentStatusTx(tx, orderID, total, result); err != nil {
+        return nil, err
+    }
+
+    // El pago que salda una orden entregada la cierra en la misma transacción
+    if result.PaymentStatus == data.PaymentStatusPaid {
+        closed, err := closeIfSettledTx(tx, orderID, r.deductOn)
+        if err != nil {
+            return nil, err
+        }
+        result.OrderClosed = closed
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return result, nil
+}
+
+// Reversa un pago. Si la orden estaba cerrada se reabre en el estado que tenía
+// antes del cierre y la subcuenta asociada vuelve a quedar abierta. Cuando el efectivo se
+// devuelve desde otro turno, cashOut registra la salida en ese turno.
+func (r *PaymentRepository) Reverse(paymentID int32, reason string, cashOut *data.CashMovement) (*PaymentResult, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return nil, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var orderID int32
+    var splitID *int32
+    err = tx.QueryRow(`
+        UPDATE payments
+        SET status = $2, reversal_reason = $3, reversed_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = $4
+        RETURNING order_id, split_id
+    `, paymentID, data.PaymentReversed, reason, data.PaymentCompleted).Scan(&orderID, &splitID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, fmt.Errorf("completed payment not found")
+        }
+        return nil, fmt.Errorf("error reversing payment: %w", err)
+    }
+
+    total, status, err := lockOrderForPaymentTx(tx, orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    if cashOut != nil {
+        if err := insertCashMovementTx(tx, cashOut); err != nil {
+            return nil, err
+        }
+    }
+
+    if splitID != nil {
+        _, err := tx.Exec(
+            `UPDATE order_splits SET status = $2, paid_at = NULL WHERE id = $1`,
+            *splitID, data.SplitStatusOpen,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error reopening split: %w", err)
+        }
+    }
+
+    if status == data.OrderStatusClosed {
+        if err := reopenOrderTx(tx, orderID); err != nil {
+            return nil, err
+        }
+    }
+
+    result := &PaymentResult{}
+    if err := refreshPaymentStatusTx(tx, orderID, total, result); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return result, nil
+}
+
+func lockOrderForPaymentTx(tx *sql.Tx, orderID int32) (float64, string, error) {
+    var total float64
+    var status string
+    err := tx.QueryRow(`SELECT total_amount, status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&total, &status)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return 0, "", fmt.Errorf("order not found")
+        }
+        return 0, "", fmt.Errorf("error locking order: %w", err)
+    }
+    return total, status, nil
+}
+
+func paidAmountTx(tx *sql.Tx, orderID int32) (float64, error) {
+    var paid float64
+    err := tx.QueryRow(
+        `SELECT COALESCE(SUM(amount), 0) FROM payments WHERE order_id = $1 AND status = $2`,
+        orderID, data.PaymentCompleted,
+    ).Scan(&paid)
+    if err != nil {
+        return 0, fmt.Errorf("error getting paid amount: %w", err)
+    }
+    return paid, nil
+}
+
+func insertPaymentTx(tx *sql.Tx, payment *data.Payment) error {
+    query := `
+        INSERT INTO payments (order_id, split_id, shift_id, user_id, method, amount, tendered, change_amount, reference,
+                              tip_amount, tip_staff_id, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        RETURNING id, created_at
+    `
+
+    err := tx.QueryRow(
+        query,
+        payment.OrderID,
+        payment.SplitID,
+        payment.ShiftID,
+        payment.UserID,
+        payment.Method,
+        payment.Amount,
+        payment.Tendered,
+        payment.Change,
+        payment.Reference,
+        payment.TipAmount,
+        payment.TipStaffID,
+        payment.Status,
+    ).Scan(&payment.ID, &payment.CreatedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating payment: %w", err)
+    }
+
+    return nil
+}
+
+// Verifica que el pago no supere el saldo pendiente de la subcuenta
+func checkSplitBalanceTx(tx *sql.Tx, orderID, splitID int32, amount float64) error {
+    var splitOrderID int32
+    var splitAmount float64
+    var status string
+    err := tx.QueryRow(
+        `SELECT order_id, amount, status FROM order_splits WHERE id = $1 FOR UPDATE`,
+        splitID,
+    ).Scan(&splitOrderID, &splitAmount, &status)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("split not found")
+        }
+        return fmt.Errorf("error getting split: %w", err)
+    }
+
+    if splitOrderID != orderID {
+        return fmt.Errorf("split not found")
+    }
+
+    if status == data.SplitStatusPaid {
+        return fmt.Errorf("split is already paid")
+    }
+
+    var paid float64
+    err = tx.QueryRow(
+        `SELECT COALESCE(SUM(amount), 0) FROM payments WHERE split_id = $1 AND status = $2`,
+        splitID, data.PaymentCompleted,
+    ).Scan(&paid)
+    if err != nil {
+        return fmt.Errorf("error getting split paid amount: %w", err)
+    }
+
+    if roundCents(paid+amount) > roundCents(splitAmount) {
+        return fmt.Errorf("payment exceeds split balance of %.2f", roundCents(splitAmount-paid))
+    }
+
+    return nil
+}
+
+func settleSplitIfPaidTx(tx *sql.Tx, splitID int32) error {
+    var splitAmount, paid float64
+    err := tx.QueryRow(`
+        SELECT s.amount, COALESCE(SUM(p.amount), 0)
+        FROM order_splits s
+        LEFT JOIN payments p ON p.split_id = s.id AND p.status = $2
+        WHERE s.id = $1
+        GROUP BY s.id
+    `, splitID, data.PaymentCompleted).Scan(&splitAmount, &paid)
+    if err != nil {
+        return fmt.Errorf("error getting split balance: %w", err)
+    }
+
+    if roundCents(paid) < roundCents(splitAmount) {
+        return nil
+    }
+
+    return settleSplitTx(tx, splitID)
+}
+
+func refreshPaymentStatusTx(tx *sql.Tx, orderID int32, total float64, result *PaymentResult) error {
+    paid, err := paidAmountTx(tx, orderID)
+    if err != nil {
+        return err
+    }
+
+    status := data.PaymentStatusPartiallyPaid
+    switch {
+    case roundCents(paid) <= 0:
+        status = data.PaymentStatusUnpaid
+    case roundCents(paid) >= roundCents(total):
+        status = data.PaymentStatusPaid
+    }
+
+    _, err = tx.Exec(
+        `UPDATE orders SET payment_status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+        orderID, status,
+    )
+    if err != nil {
+        return fmt.Errorf("error updating payment status: %w", err)
+    }
+
+    result.PaymentStatus = status
+    result.AmountPaid = roundCents(paid)
+    result.Balance = roundCents(total - paid)
+
+    return nil
+}
+
+// Reabre una orden cerrada en el estado previo al cierre y vuelve a ocupar su mesa
+func reopenOrderTx(tx *sql.Tx, orderID int32) error {
+    var tableID *int32
+    err := tx.QueryRow(`
+        UPDATE orders
+        SET status = COALESCE(previous_status, $2), previous_status = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+        RETURNING table_id
+    `, orderID, data.OrderStatusDelivered).Scan(&tableID)
+    if err != nil {
+        return fmt.Errorf("error reopening order: %w", err)
+    }
+
+    if err := publishOrderEventTx(tx, orderID, data.OrderEventStatusChanged); err != nil {
+        return err
+    }
+
+    if tableID != nil {
+        return setTableStatusTx(tx, *tableID, data.TableStatusOccupied)
+    }
+
+    return nil
+}
+
+func roundCents(amount float64) float64 {
+    return math.Round(amount*100) / 100
+}
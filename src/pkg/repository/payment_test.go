@@ -0,0 +1,91 @@
+package repository
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestSettlingPaymentClosesDeliveredOrder(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    payments := NewPaymentRepository(db, data.OrderStatusPreparing)
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    order := createTestOrder(t, orders, insertTestTable(t, db, 1), data.OrderItem{ProductID: product, Quantity: 2, UnitPrice: 10})
+    mustExec(t, db, `UPDATE orders SET status = $2 WHERE id = $1`, order.ID, data.OrderStatusDelivered)
+
+    total := mustQueryFloat(t, db, `SELECT total_amount FROM orders WHERE id = $1`, order.ID)
+    user := insertTestUser(t, db, "caja")
+
+    result, err := payments.CreateBatch(order.ID, []data.Payment{
+        {UserID: user, Method: data.PaymentMethodCash, Amount: 5},
+    })
+    if err != nil {
+        t.Fatalf("CreateBatch() error = %v", err)
+    }
+    if result.OrderClosed {
+        t.Error("partial payment closed the order")
+    }
+
+    result, err = payments.CreateBatch(order.ID, []data.Payment{
+        {UserID: user, Method: data.PaymentMethodCash, Amount: total - 5},
+    })
+    if err != nil {
+        t.Fatalf("CreateBatch() error = %v", err)
+    }
+    if !result.OrderClosed {
+        t.Error("OrderClosed = false, want true for the settling payment")
+    }
+
+    closed, err := orders.GetByID(order.ID)
+    if err != nil {
+        t.Fatalf("GetByID() error = %v", err)
+    }
+    if closed.Status != data.OrderStatusClosed {
+        t.Errorf("status = %s, want %s", closed.Status, data.OrderStatusClosed)
+    }
+}
+
+func TestUpdateStatusChecksTransitionAndBalance(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    order := createTestOrder(t, orders, insertTestTable(t, db, 1), data.OrderItem{ProductID: product, Quantity: 2, UnitPrice: 10})
+
+    if err := orders.UpdateStatus(order.ID, data.OrderStatusClosed); err == nil {
+        t.Error("UpdateStatus(pending -> closed) error = nil, want an invalid transition")
+    }
+
+    mustExec(t, db, `UPDATE orders SET status = $2, payment_status = $3 WHERE id = $1`,
+        order.ID, data.OrderStatusReady, data.PaymentStatusPartiallyPaid)
+
+    if err := orders.UpdateStatus(order.ID, data.OrderStatusCancelled); err == nil {
+        t.Error("UpdateStatus(cancelled) error = nil, want an error for an order with payments")
+    }
+
+    if err := orders.UpdateStatus(order.ID, data.OrderStatusDelivered); err != nil {
+        t.Fatalf("UpdateStatus(delivered) error = %v", err)
+    }
+
+    if err := orders.UpdateStatus(order.ID, data.OrderStatusClosed); err == nil {
+        t.Error("UpdateStatus(closed) error = nil, want an error for an outstanding balance")
+    }
+
+    // Entregar una orden ya pagada la cierra en la misma transacción
+    mustExec(t, db, `UPDATE orders SET status = $2, payment_status = $3 WHERE id = $1`,
+        order.ID, data.OrderStatusReady, data.PaymentStatusPaid)
+
+    if err := orders.UpdateStatus(order.ID, data.OrderStatusDelivered); err != nil {
+        t.Fatalf("UpdateStatus(delivered) error = %v", err)
+    }
+
+    updated, err := orders.GetByID(order.ID)
+    if err != nil {
+        t.Fatalf("GetByID() error = %v", err)
+    }
+    if updated.Status != data.OrderStatusClosed {
+        t.Errorf("status = %s, want %s", updated.Status, data.OrderStatusClosed)
+    }
+}
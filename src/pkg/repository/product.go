@@ -3,6 +3,7 @@ package repository
 import (
     "database/sql"
     "fmt"
+    "time"
 
     "github.com/pkgzx/liliApi/src/pkg/data"
 )
@@ -19,7 +20,7 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 
 func (r *ProductRepository) GetAll() ([]data.Product, error) {
     query := `
-        SELECT id, name, description, price, category_id, image_url, is_available, created_at 
+        SELECT id, name, description, price, category_id, image_url, is_available, tax_rate_id, station_id, prep_minutes, created_at 
         FROM products 
         ORDER BY created_at DESC
     `
@@ -40,7 +41,7 @@ func (r *ProductRepository) GetAll() ([]data.Product, error) {
 
 func (r *ProductRepository) GetByID(id int32) (*data.Product, error) {
     query := `
-        SELECT id, name, description, price, category_id, image_url, is_available, created_at 
+        SELECT id, name, description, price, category_id, image_url, is_available, tax_rate_id, station_id, prep_minutes, created_at 
         FROM products 
         WHERE id = $1
     `
@@ -54,6 +55,9 @@ func (r *ProductRepository) GetByID(id int32) (*data.Product, error) {
         &product.CategoryID,
         &product.ImageURL,
         &product.IsAvailable,
+        &product.TaxRateID,
+        &product.StationID,
+        &product.PrepMinutes,
         &product.CreatedAt,
     )
     
@@ -69,7 +73,7 @@ func (r *ProductRepository) GetByID(id int32) (*data.Product, error) {
 
 func (r *ProductRepository) GetByCategory(categoryID int32) ([]data.Product, error) {
     query := `
-        SELECT id, name, description, price, category_id, image_url, is_available, created_at 
+        SELECT id, name, description, price, category_id, image_url, is_available, tax_rate_id, station_id, prep_minutes, created_at 
         FROM products 
         WHERE category_id = $1 AND is_available = true
         ORDER BY name
@@ -90,13 +94,20 @@ func (r *ProductRepository) GetByCategory(categoryID int32) ([]data.Product, err
 }
 
 func (r *ProductRepository) Create(product *data.Product) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
     query := `
-        INSERT INTO products (name, description, price, category_id, image_url, is_available)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO products (name, description, price, category_id, image_url, is_available, tax_rate_id, station_id,
+                              prep_minutes)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
         RETURNING id, created_at
     `
     
-    err := r.db.QueryRow(
+    err = tx.QueryRow(
         query,
         product.Name,
         product.Description,
@@ -104,24 +115,45 @@ func (r *ProductRepository) Create(product *data.Product) error {
         product.CategoryID,
         product.ImageURL,
         product.IsAvailable,
+        product.TaxRateID,
+        product.StationID,
+        product.PrepMinutes,
     ).Scan(&product.ID, &product.CreatedAt)
     
     if err != nil {
         return fmt.Errorf("error creating product: %w", err)
     }
 
+    if err := recordProductPriceTx(tx, product.ID, product.Price); err != nil {
+        return err
+    }
+
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityProduct, product.ID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
     return nil
 }
 
 func (r *ProductRepository) Update(product *data.Product) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
     query := `
         UPDATE products 
         SET name = $2, description = $3, price = $4, category_id = $5, 
-            image_url = $6, is_available = $7
+            image_url = $6, is_available = $7, tax_rate_id = $8, station_id = $9, prep_minutes = $10
         WHERE id = $1
     `
     
-    result, err := r.db.Exec(
+    result, err := tx.Exec(
         query,
         product.ID,
         product.Name,
@@ -130,6 +162,9 @@ func (r *ProductRepository) Update(product *data.Product) error {
         product.CategoryID,
         product.ImageURL,
         product.IsAvailable,
+        product.TaxRateID,
+        product.StationID,
+        product.PrepMinutes,
     )
     
     if err != nil {
@@ -145,13 +180,31 @@ func (r *ProductRepository) Update(product *data.Product) error {
         return fmt.Errorf("product not found")
     }
 
+    if err := recordProductPriceTx(tx, product.ID, product.Price); err != nil {
+        return err
+    }
+
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityProduct, product.ID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
     return nil
 }
 
 func (r *ProductRepository) Delete(id int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
     query := "DELETE FROM products WHERE id = $1"
     
-    result, err := r.db.Exec(query, id)
+    result, err := tx.Exec(query, id)
     if err != nil {
         return fmt.Errorf("error deleting product: %w", err)
     }
@@ -165,5 +218,114 @@ func (r *ProductRepository) Delete(id int32) error {
         return fmt.Errorf("product not found")
     }
 
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityProduct, id); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Indica si price fue el precio del producto en algún momento desde since
+func (r *ProductRepository) WasPriceValidSince(productID int32, price float64, since time.Time) (bool, error) {
+    var valid bool
+    err := r.db.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM product_prices
+            WHERE product_id = $1 AND ROUND(price::numeric, 2) = ROUND($2::numeric, 2)
+              AND (valid_to IS NULL OR valid_to >= $3)
+        )
+    `, productID, price, since).Scan(&valid)
+    if err != nil {
+        return false, fmt.Errorf("error checking product price history: %w", err)
+    }
+
+    return valid, nil
+}
+
+// Lleva el historial de precios: cierra el vigente si cambió y abre uno nuevo
+func recordProductPriceTx(tx *sql.Tx, productID int32, price float64) error {
+    _, err := tx.Exec(`
+        UPDATE product_prices SET valid_to = CURRENT_TIMESTAMP
+        WHERE product_id = $1 AND valid_to IS NULL AND price <> $2
+    `, productID, price)
+    if err != nil {
+        return fmt.Errorf("error closing product price: %w", err)
+    }
+
+    _, err = tx.Exec(`
+        INSERT INTO product_prices (product_id, price, valid_from)
+        SELECT $1, $2, CURRENT_TIMESTAMP
+        WHERE NOT EXISTS (SELECT 1 FROM product_prices WHERE product_id = $1 AND valid_to IS NULL)
+    `, productID, price)
+    if err != nil {
+        return fmt.Errorf("error recording product price: %w", err)
+    }
+
+    return nil
+}
+
+func (r *ProductRepository) GetModifiers(productID int32) ([]data.ProductModifier, error) {
+    query := `
+        SELECT pm.id, pm.product_id, pm.name, pm.price_delta, pm.ingredient_id,
+               COALESCE(i.unit, '') AS unit, pm.quantity
+        FROM product_modifiers pm
+        LEFT JOIN ingredients i ON i.id = pm.ingredient_id
+        WHERE pm.product_id = $1
+        ORDER BY pm.name
+    `
+
+    rows, err := r.db.Query(query, productID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying product modifiers: %w", err)
+    }
+    defer rows.Close()
+
+    modifiers := make([]data.ProductModifier, 0)
+    if err := ScanRowsToStruct(rows, &modifiers); err != nil {
+        return nil, fmt.Errorf("error scanning product modifiers: %w", err)
+    }
+
+    return modifiers, nil
+}
+
+// Reemplaza los modificadores de un producto. Los items ya vendidos guardan
+// su copia, así que borrar un modificador no altera órdenes existentes.
+func (r *ProductRepository) SetModifiers(productID int32, modifiers []data.ProductModifier) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var exists bool
+    if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+        return fmt.Errorf("error checking product: %w", err)
+    }
+    if !exists {
+        return fmt.Errorf("product not found")
+    }
+
+    if _, err := tx.Exec(`DELETE FROM product_modifiers WHERE product_id = $1`, productID); err != nil {
+        return fmt.Errorf("error clearing product modifiers: %w", err)
+    }
+
+    for _, modifier := range modifiers {
+        _, err := tx.Exec(`
+            INSERT INTO product_modifiers (product_id, name, price_delta, ingredient_id, quantity)
+            VALUES ($1, $2, $3, $4, $5)
+        `, productID, modifier.Name, modifier.PriceDelta, modifier.IngredientID, modifier.Quantity)
+        if err != nil {
+            return fmt.Errorf("error saving product modifiers: %w", err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
     return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,489 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "math"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+// Margen al comparar cantidades y costos de compra: vienen de conversiones de
+// unidad (redondeadas a 6 decimales) y de sumas de recepciones parciales
+const purchaseTolerance = 1e-6
+
+func nearlyEqual(a, b float64) bool {
+    return math.Abs(a-b) <= purchaseTolerance
+}
+
+type PurchaseOrderRepository struct {
+    *BaseRepository
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) *PurchaseOrderRepository {
+    return &PurchaseOrderRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const purchaseOrderColumns = `po.id, po.supplier_id, s.name AS supplier_name, po.status, po.notes, po.expected_at,
+        po.total, po.created_by, po.sent_at, po.received_at, po.created_at, po.updated_at`
+
+const purchaseOrderItemColumns = `poi.id, poi.purchase_order_id, poi.ingredient_id, i.name AS ingredient_name, i.unit,
+        poi.quantity_ordered, poi.quantity_received, poi.unit_cost, poi.purchase_quantity, poi.purchase_unit`
+
+// Filtros del listado de órdenes de compra
+type PurchaseOrderFilter struct {
+    Status     string
+    SupplierID int32
+}
+
+func (f PurchaseOrderFilter) where(args []any) (string, []any) {
+    clauses := make([]string, 0)
+
+    if f.Status != "" {
+        args = append(args, f.Status)
+        clauses = append(clauses, fmt.Sprintf("po.status = $%d", len(args)))
+    }
+    if f.SupplierID != 0 {
+        args = append(args, f.SupplierID)
+        clauses = append(clauses, fmt.Sprintf("po.supplier_id = $%d", len(args)))
+    }
+
+    if len(clauses) == 0 {
+        return "", args
+    }
+    return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// Línea de una recepción, ya validada y con el costo facturado resuelto
+type ReceiptLine struct {
+    ItemID    int32
+    Quantity  float64
+    UnitCost  float64
+    LotNumber string
+    ExpiresAt *time.Time
+}
+
+func (r *PurchaseOrderRepository) Find(filter PurchaseOrderFilter) ([]data.PurchaseOrder, error) {
+    where, args := filter.where(nil)
+    query := `
+        SELECT ` + purchaseOrderColumns + `
+        FROM purchase_orders po
+        JOIN suppliers s ON s.id = po.supplier_id` + where + `
+        ORDER BY po.created_at DESC, po.id DESC
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying purchase orders: %w", err)
+    }
+    defer rows.Close()
+
+    orders := make([]data.PurchaseOrder, 0)
+    if err := ScanRowsToStruct(rows, &orders); err != nil {
+        return nil, fmt.Errorf("error scanning purchase orders: %w", err)
+    }
+
+    return orders, nil
+}
+
+func (r *PurchaseOrderRepository) GetByID(id int32) (*data.PurchaseOrder, error) {
+    query := `
+        SELECT ` + purchaseOrderColumns + `
+        FROM purchase_orders po
+        JOIN suppliers s ON s.id = po.supplier_id
+        WHERE po.id = $1
+    `
+
+    var order data.PurchaseOrder
+    err := r.db.QueryRow(query, id).Scan(
+        &order.ID,
+        &order.SupplierID,
+        &order.SupplierName,
+        &order.Status,
+        &order.Notes,
+        &order.ExpectedAt,
+        &order.Total,
+        &order.CreatedBy,
+        &order.SentAt,
+        &order.ReceivedAt,
+        &order.CreatedAt,
+        &order.UpdatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting purchase order: %w", err)
+    }
+
+    return &order, nil
+}
+
+func (r *PurchaseOrderRepository) GetItems(purchaseOrderID int32) ([]data.PurchaseOrderItem, error) {
+    query := `
+        SELECT ` + purchaseOrderItemColumns + `
+        FROM purchase_order_items poi
+        JOIN ingredients i ON i.id = poi.ingredient_id
+        WHERE poi.purchase_order_id = $1
+        ORDER BY poi.id
+    `
+
+    rows, err := r.db.Query(query, purchaseOrderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying purchase order items: %w", err)
+    }
+    defer rows.Close()
+
+    items := make([]data.PurchaseOrderItem, 0)
+    if err := ScanRowsToStruct(rows, &items); err != nil {
+        return nil, fmt.Errorf("error scanning purchase order items: %w", err)
+    }
+
+    return items, nil
+}
+
+func (r *PurchaseOrderRepository) GetDiscrepancies(purchaseOrderID int32) ([]data.PurchaseDiscrepancy, error) {
+    query := `
+        SELECT id, purchase_order_id, purchase_order_item_id, ingredient_id, type, expected, actual, notes, created_at
+        FROM purchase_discrepancies
+        WHERE purchase_order_id = $1
+        ORDER BY created_at, id
+    `
+
+    rows, err := r.db.Query(query, purchaseOrderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying purchase discrepancies: %w", err)
+    }
+    defer rows.Close()
+
+    discrepancies := make([]data.PurchaseDiscrepancy, 0)
+    if err := ScanRowsToStruct(rows, &discrepancies); err != nil {
+        return nil, fmt.Errorf("error scanning purchase discrepancies: %w", err)
+    }
+
+    return discrepancies, nil
+}
+
+// Crea la orden de compra en borrador junto con sus items
+func (r *PurchaseOrderRepository) Create(order *data.PurchaseOrder, items []data.PurchaseOrderItem) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    err = tx.QueryRow(`
+        INSERT INTO purchase_orders (supplier_id, status, notes, expected_at, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, updated_at
+    `, order.SupplierID, data.PurchaseOrderDraft, order.Notes, order.ExpectedAt, order.CreatedBy).
+        Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating purchase order: %w", err)
+    }
+    order.Status = data.PurchaseOrderDraft
+
+    if err := replacePurchaseItemsTx(tx, order, items); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Reemplaza los datos e items de una orden que sigue en borrador
+func (r *PurchaseOrderRepository) UpdateDraft(order *data.PurchaseOrder, items []data.PurchaseOrderItem) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    status, err := lockPurchaseOrderTx(tx, order.ID)
+    if err != nil {
+        return err
+    }
+    if status != data.PurchaseOrderDraft {
+        return fmt.Errorf("purchase order is %s, only drafts can be edited", status)
+    }
+
+    _, err = tx.Exec(`
+        UPDATE purchase_orders SET supplier_id = $2, notes = $3, expected_at = $4, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, order.ID, order.SupplierID, order.Notes, order.ExpectedAt)
+    if err != nil {
+        return fmt.Errorf("error updating purchase order: %w", err)
+    }
+
+    if _, err := tx.Exec(`DELETE FROM purchase_order_items WHERE purchase_order_id = $1`, order.ID); err != nil {
+        return fmt.Errorf("error clearing purchase order items: %w", err)
+    }
+
+    if err := replacePurchaseItemsTx(tx, order, items); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func replacePurchaseItemsTx(tx *sql.Tx, order *data.PurchaseOrder, items []data.PurchaseOrderItem) error {
+    for i := range items {
+        items[i].PurchaseOrderID = order.ID
+        err := tx.QueryRow(`
+            INSERT INTO purchase_order_items (purchase_order_id, ingredient_id, quantity_ordered, unit_cost,
+                                              purchase_quantity, purchase_unit)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id
+        `, order.ID, items[i].IngredientID, items[i].QuantityOrdered, items[i].UnitCost,
+            items[i].PurchaseQuantity, items[i].PurchaseUnit).Scan(&items[i].ID)
+        if err != nil {
+            return fmt.Errorf("error creating purchase order item: %w", err)
+        }
+    }
+
+    err := tx.QueryRow(`
+        UPDATE purchase_orders
+        SET total = (SELECT COALESCE(SUM(quantity_ordered * unit_cost), 0) FROM purchase_order_items WHERE purchase_order_id = $1)
+        WHERE id = $1
+        RETURNING total
+    `, order.ID).Scan(&order.Total)
+    if err != nil {
+        return fmt.Errorf("error updating purchase order total: %w", err)
+    }
+
+    return nil
+}
+
+// Cambia el estado si la orden está en alguno de los estados from
+func (r *PurchaseOrderRepository) UpdateStatus(id int32, from []string, to string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    status, err := lockPurchaseOrderTx(tx, id)
+    if err != nil {
+        return err
+    }
+
+    allowed := false
+    for _, s := range from {
+        allowed = allowed || s == status
+    }
+    if !allowed {
+        return fmt.Errorf("purchase order is %s", status)
+    }
+
+    _, err = tx.Exec(`
+        UPDATE purchase_orders
+        SET status = $2, sent_at = CASE WHEN $2 = 'sent' THEN CURRENT_TIMESTAMP ELSE sent_at END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, id, to)
+    if err != nil {
+        return fmt.Errorf("error updating purchase order status: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Registra lo que llegó del proveedor: entradas de inventario, costo promedio
+// ponderado de cada ingrediente y diferencias contra lo pactado. Con closeOrder
+// la orden se da por recibida aunque falte mercancía y el faltante queda
+// registrado.
+func (r *PurchaseOrderRepository) Receive(id, userID int32, lines []ReceiptLine, closeOrder bool, notes string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    status, err := lockPurchaseOrderTx(tx, id)
+    if err != nil {
+        return err
+    }
+    if status != data.PurchaseOrderSent && status != data.PurchaseOrderPartiallyReceived {
+        return fmt.Errorf("purchase order is %s", status)
+    }
+
+    items, err := lockPurchaseItemsTx(tx, id)
+    if err != nil {
+        return err
+    }
+
+    byID := make(map[int32]*data.PurchaseOrderItem, len(items))
+    for i := range items {
+        byID[items[i].ID] = &items[i]
+    }
+
+    for _, line := range lines {
+        item := byID[line.ItemID]
+        if item == nil {
+            return fmt.Errorf("purchase order item %d not found", line.ItemID)
+        }
+
+        if !nearlyEqual(line.UnitCost, item.UnitCost) {
+            if err := insertDiscrepancyTx(tx, item, data.DiscrepancyCost, item.UnitCost, line.UnitCost, notes); err != nil {
+                return err
+            }
+        }
+
+        received := item.QuantityReceived + line.Quantity
+        if received > item.QuantityOrdered+purchaseTolerance {
+            if err := insertDiscrepancyTx(tx, item, data.DiscrepancyOver, item.QuantityOrdered, received, notes); err != nil {
+                return err
+            }
+        }
+
+        if err := averageIngredientCostTx(tx, item.IngredientID, line.Quantity, line.UnitCost); err != nil {
+            return err
+        }
+
+        movement := &data.InventoryMovement{
+            IngredientID:    item.IngredientID,
+            MovementType:    data.MovementTypeEntrada,
+            Quantity:        line.Quantity,
+            Reason:          fmt.Sprintf("Purchase order %d", id),
+            UserID:          &userID,
+            PurchaseOrderID: &id,
+            LotNumber:       line.LotNumber,
+            ExpiresAt:       line.ExpiresAt,
+        }
+        if err := recordMovementTx(tx, movement, true); err != nil {
+            return err
+        }
+
+        if _, err := tx.Exec(`UPDATE purchase_order_items SET quantity_received = $2 WHERE id = $1`, item.ID, received); err != nil {
+            return fmt.Errorf("error updating received quantity: %w", err)
+        }
+        item.QuantityReceived = received
+    }
+
+    complete := true
+    for _, item := range items {
+        complete = complete && item.QuantityReceived >= item.QuantityOrdered-purchaseTolerance
+    }
+
+    status = data.PurchaseOrderPartiallyReceived
+    if complete || closeOrder {
+        status = data.PurchaseOrderReceived
+    }
+
+    if !complete && closeOrder {
+        for i := range items {
+            if items[i].QuantityReceived >= items[i].QuantityOrdered-purchaseTolerance {
+                continue
+            }
+            err := insertDiscrepancyTx(tx, &items[i], data.DiscrepancyShort, items[i].QuantityOrdered, items[i].QuantityReceived, notes)
+            if err != nil {
+                return err
+            }
+        }
+    }
+
+    _, err = tx.Exec(`
+        UPDATE purchase_orders
+        SET status = $2, received_at = CASE WHEN $2 = 'received' THEN CURRENT_TIMESTAMP END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, id, status)
+    if err != nil {
+        return fmt.Errorf("error updating purchase order status: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func lockPurchaseOrderTx(tx *sql.Tx, id int32) (string, error) {
+    var status string
+    if err := tx.QueryRow(`SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+        if err == sql.ErrNoRows {
+            return "", fmt.Errorf("purchase order not found")
+        }
+        return "", fmt.Errorf("error getting purchase order: %w", err)
+    }
+
+    return status, nil
+}
+
+func lockPurchaseItemsTx(tx *sql.Tx, purchaseOrderID int32) ([]data.PurchaseOrderItem, error) {
+    rows, err := tx.Query(`
+        SELECT id, purchase_order_id, ingredient_id, quantity_ordered, quantity_received, unit_cost
+        FROM purchase_order_items
+        WHERE purchase_order_id = $1
+        ORDER BY id
+        FOR UPDATE
+    `, purchaseOrderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying purchase order items: %w", err)
+    }
+    defer rows.Close()
+
+    var items []data.PurchaseOrderItem
+    if err := ScanRowsToStruct(rows, &items); err != nil {
+        return nil, fmt.Errorf("error scanning purchase order items: %w", err)
+    }
+
+    return items, nil
+}
+
+func insertDiscrepancyTx(tx *sql.Tx, item *data.PurchaseOrderItem, discrepancyType string, expected, actual float64, notes string) error {
+    _, err := tx.Exec(`
+        INSERT INTO purchase_discrepancies (purchase_order_id, purchase_order_item_id, ingredient_id, type, expected, actual, notes)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, item.PurchaseOrderID, item.ID, item.IngredientID, discrepancyType, expected, actual, notes)
+    if err != nil {
+        return fmt.Errorf("error recording purchase discrepancy: %w", err)
+    }
+
+    return nil
+}
+
+// Costo promedio ponderado entre las existencias actuales y lo que entra.
+// Un stock negativo no aporta costo: se toma como cero.
+func averageIngredientCostTx(tx *sql.Tx, ingredientID int32, quantity, unitCost float64) error {
+    var stock, cost float64
+    err := tx.QueryRow(`SELECT stock_quantity, cost_per_unit FROM ingredients WHERE id = $1 FOR UPDATE`, ingredientID).Scan(&stock, &cost)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("ingredient not found")
+        }
+        return fmt.Errorf("error getting ingredient cost: %w", err)
+    }
+
+    if stock < 0 {
+        stock = 0
+    }
+
+    average := unitCost
+    if stock+quantity > 0 {
+        average = (stock*cost + quantity*unitCost) / (stock + quantity)
+    }
+
+    if _, err := tx.Exec(`UPDATE ingredients SET cost_per_unit = $2 WHERE id = $1`, ingredientID, average); err != nil {
+        return fmt.Errorf("error updating ingredient cost: %w", err)
+    }
+
+    return nil
+}
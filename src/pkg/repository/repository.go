@@ -1,10 +1,17 @@
 package repository
 
 import (
+    "context"
     "database/sql"
+    "database/sql/driver"
+    "errors"
     "fmt"
+    "io"
+    "net"
     "reflect"
     "strings"
+
+    "github.com/lib/pq"
 )
 
 type BaseRepository struct {
@@ -19,6 +26,21 @@ func (r *BaseRepository) GetDB() *sql.DB {
     return r.db
 }
 
+// Indica si el error viene de la base de datos o de la conexión (y no de una
+// regla de negocio), es decir, si reintentar la operación tiene sentido.
+// Los repositorios envuelven estos errores con %w.
+func IsDatabaseError(err error) bool {
+    var pqErr *pq.Error
+    var netErr net.Error
+    return errors.As(err, &pqErr) ||
+        errors.As(err, &netErr) ||
+        errors.Is(err, driver.ErrBadConn) ||
+        errors.Is(err, sql.ErrConnDone) ||
+        errors.Is(err, sql.ErrTxDone) ||
+        errors.Is(err, io.ErrUnexpectedEOF) ||
+        errors.Is(err, context.DeadlineExceeded)
+}
+
 // Función auxiliar para escanear filas a structs
 func ScanRowsToStruct(rows *sql.Rows, dest any) error {
     v := reflect.ValueOf(dest).Elem()
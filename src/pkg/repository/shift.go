@@ -0,0 +1,342 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type ShiftRepository struct {
+    *BaseRepository
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+    return &ShiftRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+// Permite ejecutar la misma consulta dentro o fuera de una transacción
+type rowQuerier interface {
+    QueryRow(query string, args ...any) *sql.Row
+}
+
+const shiftColumns = `id, register, user_id, opening_float, status, expected_cash, counted_cash,
+        discrepancy, notes, opened_at, closed_at`
+
+func scanShift(row interface{ Scan(...any) error }, shift *data.CashShift) error {
+    return row.Scan(
+        &shift.ID,
+        &shift.Register,
+        &shift.UserID,
+        &shift.OpeningFloat,
+        &shift.Status,
+        &shift.ExpectedCash,
+        &shift.CountedCash,
+        &shift.Discrepancy,
+        &shift.Notes,
+        &shift.OpenedAt,
+        &shift.ClosedAt,
+    )
+}
+
+func (r *ShiftRepository) GetAll(status string) ([]data.CashShift, error) {
+    query := `SELECT ` + shiftColumns + ` FROM cash_shifts`
+    args := make([]any, 0)
+    if status != "" {
+        query += ` WHERE status = $1`
+        args = append(args, status)
+    }
+    query += ` ORDER BY opened_at DESC`
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying cash shifts: %w", err)
+    }
+    defer rows.Close()
+
+    var shifts []data.CashShift
+    if err := ScanRowsToStruct(rows, &shifts); err != nil {
+        return nil, fmt.Errorf("error scanning cash shifts: %w", err)
+    }
+
+    return shifts, nil
+}
+
+func (r *ShiftRepository) GetByID(id int32) (*data.CashShift, error) {
+    return r.getOne(`SELECT `+shiftColumns+` FROM cash_shifts WHERE id = $1`, id)
+}
+
+func (r *ShiftRepository) GetOpenByUser(userID int32) (*data.CashShift, error) {
+    return r.getOne(`SELECT `+shiftColumns+` FROM cash_shifts WHERE user_id = $1 AND status = $2`, userID, data.ShiftStatusOpen)
+}
+
+func (r *ShiftRepository) GetOpenByRegister(register string) (*data.CashShift, error) {
+    return r.getOne(`SELECT `+shiftColumns+` FROM cash_shifts WHERE register = $1 AND status = $2`, register, data.ShiftStatusOpen)
+}
+
+func (r *ShiftRepository) getOne(query string, args ...any) (*data.CashShift, error) {
+    var shift data.CashShift
+    err := scanShift(r.db.QueryRow(query, args...), &shift)
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting cash shift: %w", err)
+    }
+
+    return &shift, nil
+}
+
+func (r *ShiftRepository) Create(shift *data.CashShift) error {
+    query := `
+        INSERT INTO cash_shifts (register, user_id, opening_float, status, notes)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, opened_at
+    `
+
+    err := r.db.QueryRow(
+        query,
+        shift.Register,
+        shift.UserID,
+        shift.OpeningFloat,
+        shift.Status,
+        shift.Notes,
+    ).Scan(&shift.ID, &shift.OpenedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating cash shift: %w", err)
+    }
+
+    return nil
+}
+
+func (r *ShiftRepository) GetMovements(shiftID int32) ([]data.CashMovement, error) {
+    query := `
+        SELECT id, shift_id, user_id, type, amount, reason, created_at
+        FROM cash_movements
+        WHERE shift_id = $1
+        ORDER BY created_at
+    `
+
+    rows, err := r.db.Query(query, shiftID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying cash movements: %w", err)
+    }
+    defer rows.Close()
+
+    var movements []data.CashMovement
+    if err := ScanRowsToStruct(rows, &movements); err != nil {
+        return nil, fmt.Errorf("error scanning cash movements: %w", err)
+    }
+
+    return movements, nil
+}
+
+func (r *ShiftRepository) AddMovement(movement *data.CashMovement) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := insertCashMovementTx(tx, movement); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func insertCashMovementTx(tx *sql.Tx, movement *data.CashMovement) error {
+    if err := checkShiftOpenTx(tx, movement.ShiftID); err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO cash_movements (shift_id, user_id, type, amount, reason)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+    err := tx.QueryRow(
+        query,
+        movement.ShiftID,
+        movement.UserID,
+        movement.Type,
+        movement.Amount,
+        movement.Reason,
+    ).Scan(&movement.ID, &movement.CreatedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating cash movement: %w", err)
+    }
+
+    return nil
+}
+
+// Cierra el turno calculando el efectivo esperado en la misma transacción y
+// guarda los totales por medio de pago: el reporte Z no cambia aunque después
+// se reverse un pago del turno
+func (r *ShiftRepository) Close(shiftID int32, countedCash float64, notes string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var status string
+    if err := tx.QueryRow(`SELECT status FROM cash_shifts WHERE id = $1 FOR UPDATE`, shiftID).Scan(&status); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("cash shift not found")
+        }
+        return fmt.Errorf("error locking cash shift: %w", err)
+    }
+
+    if status != data.ShiftStatusOpen {
+        return fmt.Errorf("cash shift is already closed")
+    }
+
+    expected, err := expectedCash(tx, shiftID)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(`
+        UPDATE cash_shifts
+        SET status = $2, expected_cash = $3, counted_cash = $4, discrepancy = $5,
+            notes = TRIM(notes || ' ' || $6), closed_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, shiftID, data.ShiftStatusClosed, expected, countedCash, roundCents(countedCash-expected), notes)
+    if err != nil {
+        return fmt.Errorf("error closing cash shift: %w", err)
+    }
+
+    _, err = tx.Exec(`
+        INSERT INTO cash_shift_tenders (shift_id, method, payment_count, amount, tips)
+        SELECT shift_id, method, COUNT(*), SUM(amount), SUM(tip_amount)
+        FROM payments
+        WHERE shift_id = $1 AND status = $2
+        GROUP BY shift_id, method
+    `, shiftID, data.PaymentCompleted)
+    if err != nil {
+        return fmt.Errorf("error saving tender totals: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Totales por medio de pago de los pagos vigentes del turno
+func (r *ShiftRepository) GetTenderTotals(shiftID int32) ([]data.TenderTotal, error) {
+    query := `
+        SELECT method, COUNT(*), COALESCE(SUM(amount), 0), COALESCE(SUM(tip_amount), 0)
+        FROM payments
+        WHERE shift_id = $1 AND status = $2
+        GROUP BY method
+        ORDER BY method
+    `
+
+    return r.queryTenderTotals(query, shiftID, data.PaymentCompleted)
+}
+
+// Totales por medio de pago guardados al cerrar el turno
+func (r *ShiftRepository) GetClosedTenderTotals(shiftID int32) ([]data.TenderTotal, error) {
+    query := `
+        SELECT method, payment_count, amount, tips
+        FROM cash_shift_tenders
+        WHERE shift_id = $1
+        ORDER BY method
+    `
+
+    return r.queryTenderTotals(query, shiftID)
+}
+
+func (r *ShiftRepository) queryTenderTotals(query string, args ...any) ([]data.TenderTotal, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying tender totals: %w", err)
+    }
+    defer rows.Close()
+
+    var totals []data.TenderTotal
+    for rows.Next() {
+        var total data.TenderTotal
+        if err := rows.Scan(&total.Method, &total.Count, &total.Amount, &total.Tips); err != nil {
+            return nil, fmt.Errorf("error scanning tender totals: %w", err)
+        }
+        totals = append(totals, total)
+    }
+
+    return totals, rows.Err()
+}
+
+// Entradas y salidas de efectivo del turno
+func (r *ShiftRepository) GetCashMovementTotals(shiftID int32) (float64, float64, error) {
+    var cashIn, cashOut float64
+    err := r.db.QueryRow(`
+        SELECT COALESCE(SUM(amount) FILTER (WHERE type = $2), 0),
+               COALESCE(SUM(amount) FILTER (WHERE type = $3), 0)
+        FROM cash_movements
+        WHERE shift_id = $1
+    `, shiftID, data.CashMovementIn, data.CashMovementOut).Scan(&cashIn, &cashOut)
+    if err != nil {
+        return 0, 0, fmt.Errorf("error getting cash movement totals: %w", err)
+    }
+
+    return cashIn, cashOut, nil
+}
+
+func (r *ShiftRepository) GetExpectedCash(shiftID int32) (float64, error) {
+    return expectedCash(r.db, shiftID)
+}
+
+// Efectivo esperado: fondo inicial + ventas y propinas en efectivo + entradas - salidas
+func expectedCash(q rowQuerier, shiftID int32) (float64, error) {
+    var expected float64
+    err := q.QueryRow(`
+        SELECT s.opening_float
+            + COALESCE((SELECT SUM(amount + tip_amount) FROM payments
+                        WHERE shift_id = s.id AND method = $2 AND status = $3), 0)
+            + COALESCE((SELECT SUM(amount) FROM cash_movements
+                        WHERE shift_id = s.id AND type = $4), 0)
+            - COALESCE((SELECT SUM(amount) FROM cash_movements
+                        WHERE shift_id = s.id AND type = $5), 0)
+        FROM cash_shifts s
+        WHERE s.id = $1
+    `, shiftID, data.PaymentMethodCash, data.PaymentCompleted, data.CashMovementIn, data.CashMovementOut).Scan(&expected)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return 0, fmt.Errorf("cash shift not found")
+        }
+        return 0, fmt.Errorf("error calculating expected cash: %w", err)
+    }
+
+    return roundCents(expected), nil
+}
+
+// Verifica (con bloqueo compartido) que el turno siga abierto
+func checkShiftOpenTx(tx *sql.Tx, shiftID int32) error {
+    var status string
+    err := tx.QueryRow(`SELECT status FROM cash_shifts WHERE id = $1 FOR SHARE`, shiftID).Scan(&status)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("cash shift not found")
+        }
+        return fmt.Errorf("error checking cash shift: %w", err)
+    }
+
+    if status != data.ShiftStatusOpen {
+        return fmt.Errorf("cash shift is closed")
+    }
+
+    return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestClosedShiftKeepsTenderTotals(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    payments := NewPaymentRepository(db, data.OrderStatusPreparing)
+    shifts := NewShiftRepository(db)
+
+    user := insertTestUser(t, db, "caja")
+    shift := &data.CashShift{Register: "caja-1", UserID: user, OpeningFloat: 50, Status: data.ShiftStatusOpen}
+    if err := shifts.Create(shift); err != nil {
+        t.Fatalf("Create() error = %v", err)
+    }
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    order := createTestOrder(t, orders, insertTestTable(t, db, 1), data.OrderItem{ProductID: product, Quantity: 2, UnitPrice: 10})
+
+    if _, err := payments.CreateBatch(order.ID, []data.Payment{
+        {ShiftID: &shift.ID, UserID: user, Method: data.PaymentMethodCash, Amount: 8, TipAmount: 1},
+        {ShiftID: &shift.ID, UserID: user, Method: data.PaymentMethodCard, Amount: 12},
+    }); err != nil {
+        t.Fatalf("CreateBatch() error = %v", err)
+    }
+
+    live, err := shifts.GetTenderTotals(shift.ID)
+    if err != nil {
+        t.Fatalf("GetTenderTotals() error = %v", err)
+    }
+
+    if err := shifts.Close(shift.ID, 59, ""); err != nil {
+        t.Fatalf("Close() error = %v", err)
+    }
+
+    // Reversar después del cierre no cambia el reporte Z
+    paid, err := payments.GetByOrder(order.ID)
+    if err != nil {
+        t.Fatalf("GetByOrder() error = %v", err)
+    }
+
+    var card int32
+    for _, payment := range paid {
+        if payment.Method == data.PaymentMethodCard {
+            card = payment.ID
+        }
+    }
+    if _, err := payments.Reverse(card, "cobro duplicado", nil); err != nil {
+        t.Fatalf("Reverse() error = %v", err)
+    }
+
+    closed, err := shifts.GetClosedTenderTotals(shift.ID)
+    if err != nil {
+        t.Fatalf("GetClosedTenderTotals() error = %v", err)
+    }
+    if !reflect.DeepEqual(closed, live) {
+        t.Errorf("closed tender totals = %+v, want %+v", closed, live)
+    }
+}
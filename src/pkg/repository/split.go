@@ -0,0 +1,242 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type SplitRepository struct {
+    *BaseRepository
+}
+
+func NewSplitRepository(db *sql.DB) *SplitRepository {
+    return &SplitRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+func (r *SplitRepository) GetByOrder(orderID int32) ([]data.OrderSplit, error) {
+    query := `
+        SELECT id, order_id, split_number, amount, status, paid_at, created_at
+        FROM order_splits
+        WHERE order_id = $1
+        ORDER BY split_number
+    `
+
+    rows, err := r.db.Query(query, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order splits: %w", err)
+    }
+    defer rows.Close()
+
+    var splits []data.OrderSplit
+    if err := ScanRowsToStruct(rows, &splits); err != nil {
+        return nil, fmt.Errorf("error scanning order splits: %w", err)
+    }
+
+    for i := range splits {
+        items, err := r.getItems(splits[i].ID)
+        if err != nil {
+            return nil, err
+        }
+        splits[i].Items = items
+    }
+
+    return splits, nil
+}
+
+func (r *SplitRepository) GetByID(id int32) (*data.OrderSplit, error) {
+    query := `
+        SELECT id, order_id, split_number, amount, status, paid_at, created_at
+        FROM order_splits
+        WHERE id = $1
+    `
+
+    var split data.OrderSplit
+    err := r.db.QueryRow(query, id).Scan(
+        &split.ID,
+        &split.OrderID,
+        &split.SplitNumber,
+        &split.Amount,
+        &split.Status,
+        &split.PaidAt,
+        &split.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting order split: %w", err)
+    }
+
+    items, err := r.getItems(split.ID)
+    if err != nil {
+        return nil, err
+    }
+    split.Items = items
+
+    return &split, nil
+}
+
+func (r *SplitRepository) getItems(splitID int32) ([]data.OrderSplitItem, error) {
+    query := `
+        SELECT id, split_id, order_item_id, quantity, amount
+        FROM order_split_items
+        WHERE split_id = $1
+        ORDER BY id
+    `
+
+    rows, err := r.db.Query(query, splitID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying split items: %w", err)
+    }
+    defer rows.Close()
+
+    var items []data.OrderSplitItem
+    if err := ScanRowsToStruct(rows, &items); err != nil {
+        return nil, fmt.Errorf("error scanning split items: %w", err)
+    }
+
+    return items, nil
+}
+
+// Reemplaza la división de la orden por las subcuentas indicadas. Las
+// subcuentas suman el total de la orden, así que solo se divide una orden sin
+// pagos; el bloqueo de la orden la serializa con el registro de pagos.
+func (r *SplitRepository) ReplaceForOrder(orderID int32, splits []data.OrderSplit) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    var paymentStatus string
+    err = tx.QueryRow(`SELECT payment_status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&paymentStatus)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
+        return fmt.Errorf("error locking order: %w", err)
+    }
+
+    if paymentStatus != data.PaymentStatusUnpaid {
+        return fmt.Errorf("order has payments, it cannot be split")
+    }
+
+    if err := deleteOpenSplitsTx(tx, orderID); err != nil {
+        return err
+    }
+
+    for i := range splits {
+        split := &splits[i]
+        split.OrderID = orderID
+
+        err := tx.QueryRow(`
+            INSERT INTO order_splits (order_id, split_number, amount, status)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id, created_at
+        `, split.OrderID, split.SplitNumber, split.Amount, split.Status).Scan(&split.ID, &split.CreatedAt)
+        if err != nil {
+            return fmt.Errorf("error creating order split: %w", err)
+        }
+
+        for j := range split.Items {
+            item := &split.Items[j]
+            item.SplitID = split.ID
+
+            err := tx.QueryRow(`
+                INSERT INTO order_split_items (split_id, order_item_id, quantity, amount)
+                VALUES ($1, $2, $3, $4)
+                RETURNING id
+            `, item.SplitID, item.OrderItemID, item.Quantity, item.Amount).Scan(&item.ID)
+            if err != nil {
+                return fmt.Errorf("error creating split item: %w", err)
+            }
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func (r *SplitRepository) DeleteByOrder(orderID int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := deleteOpenSplitsTx(tx, orderID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Borra la división de la orden. Una subcuenta con pagos, aunque estén
+// revertidos, se conserva: payments.split_id seguiría apuntando a ella.
+func deleteOpenSplitsTx(tx *sql.Tx, orderID int32) error {
+    var paid bool
+    err := tx.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM payments p
+            JOIN order_splits s ON s.id = p.split_id
+            WHERE s.order_id = $1
+        )
+    `, orderID).Scan(&paid)
+    if err != nil {
+        return fmt.Errorf("error checking split payments: %w", err)
+    }
+
+    if paid {
+        return fmt.Errorf("order splits have payments")
+    }
+
+    _, err = tx.Exec(`
+        DELETE FROM order_split_items
+        WHERE split_id IN (SELECT id FROM order_splits WHERE order_id = $1)
+    `, orderID)
+    if err != nil {
+        return fmt.Errorf("error deleting split items: %w", err)
+    }
+
+    if _, err := tx.Exec(`DELETE FROM order_splits WHERE order_id = $1`, orderID); err != nil {
+        return fmt.Errorf("error deleting order splits: %w", err)
+    }
+
+    return nil
+}
+
+// Marca la subcuenta como pagada. Solo se llama al registrar el pago que la
+// completa; el cierre de la orden lo decide el servicio de órdenes.
+func settleSplitTx(tx *sql.Tx, splitID int32) error {
+    result, err := tx.Exec(`
+        UPDATE order_splits
+        SET status = $2, paid_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = $3
+    `, splitID, data.SplitStatusPaid, data.SplitStatusOpen)
+    if err != nil {
+        return fmt.Errorf("error settling split: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("open split not found")
+    }
+
+    return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func testSplits(amounts ...float64) []data.OrderSplit {
+    splits := make([]data.OrderSplit, 0, len(amounts))
+    for i, amount := range amounts {
+        splits = append(splits, data.OrderSplit{SplitNumber: int32(i + 1), Amount: amount, Status: data.SplitStatusOpen})
+    }
+    return splits
+}
+
+func TestReplaceSplitsRejectsOrderWithPayments(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    splits := NewSplitRepository(db)
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    order := createTestOrder(t, orders, insertTestTable(t, db, 1), data.OrderItem{ProductID: product, Quantity: 2, UnitPrice: 10})
+    mustExec(t, db, `UPDATE orders SET payment_status = $2 WHERE id = $1`, order.ID, data.PaymentStatusPartiallyPaid)
+
+    if err := splits.ReplaceForOrder(order.ID, testSplits(10, 10)); err == nil {
+        t.Fatal("ReplaceForOrder() error = nil, want an error for a partially paid order")
+    }
+
+    if got := mustQueryFloat(t, db, `SELECT COUNT(*) FROM order_splits WHERE order_id = $1`, order.ID); got != 0 {
+        t.Errorf("splits = %v, want 0", got)
+    }
+}
+
+func TestSplitsWithPaymentsAreKept(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    splits := NewSplitRepository(db)
+
+    product := insertTestProduct(t, db, "Limonada", 10, 0, 0)
+    order := createTestOrder(t, orders, insertTestTable(t, db, 1), data.OrderItem{ProductID: product, Quantity: 2, UnitPrice: 10})
+
+    first := testSplits(10, 10)
+    if err := splits.ReplaceForOrder(order.ID, first); err != nil {
+        t.Fatalf("ReplaceForOrder() error = %v", err)
+    }
+
+    // Un pago revertido también referencia la subcuenta
+    user := insertTestUser(t, db, "caja")
+    mustExec(t, db, `
+        INSERT INTO payments (order_id, split_id, user_id, method, amount, status)
+        VALUES ($1, $2, $3, 'cash', 4, $4)
+    `, order.ID, first[0].ID, user, data.PaymentReversed)
+
+    if err := splits.ReplaceForOrder(order.ID, testSplits(5, 5, 10)); err == nil {
+        t.Error("ReplaceForOrder() error = nil, want an error for splits with payments")
+    }
+    if err := splits.DeleteByOrder(order.ID); err == nil {
+        t.Error("DeleteByOrder() error = nil, want an error for splits with payments")
+    }
+
+    if got := mustQueryFloat(t, db, `SELECT COUNT(*) FROM order_splits WHERE order_id = $1`, order.ID); got != 2 {
+        t.Errorf("splits = %v, want the original 2", got)
+    }
+}
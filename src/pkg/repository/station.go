@@ -0,0 +1,531 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type StationRepository struct {
+    *BaseRepository
+    // Estado de la orden en el que se descuenta su inventario
+    deductOn string
+}
+
+func NewStationRepository(db *sql.DB, deductOn string) *StationRepository {
+    return &StationRepository{
+        BaseRepository: NewBaseRepository(db),
+        deductOn:       deductOn,
+    }
+}
+
+func (r *StationRepository) GetAll() ([]data.KitchenStation, error) {
+    query := `
+        SELECT id, name, is_active, created_at
+        FROM kitchen_stations
+        ORDER BY name
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying kitchen stations: %w", err)
+    }
+    defer rows.Close()
+
+    var stations []data.KitchenStation
+    if err := ScanRowsToStruct(rows, &stations); err != nil {
+        return nil, fmt.Errorf("error scanning kitchen stations: %w", err)
+    }
+
+    return stations, nil
+}
+
+func (r *StationRepository) GetByID(id int32) (*data.KitchenStation, error) {
+    query := `
+        SELECT id, name, is_active, created_at
+        FROM kitchen_stations
+        WHERE id = $1
+    `
+
+    var station data.KitchenStation
+    err := r.db.QueryRow(query, id).Scan(
+        &station.ID,
+        &station.Name,
+        &station.IsActive,
+        &station.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting kitchen station: %w", err)
+    }
+
+    return &station, nil
+}
+
+func (r *StationRepository) GetByName(name string) (*data.KitchenStation, error) {
+    query := `
+        SELECT id, name, is_active, created_at
+        FROM kitchen_stations
+        WHERE LOWER(name) = LOWER($1)
+    `
+
+    var station data.KitchenStation
+    err := r.db.QueryRow(query, name).Scan(
+        &station.ID,
+        &station.Name,
+        &station.IsActive,
+        &station.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting kitchen station: %w", err)
+    }
+
+    return &station, nil
+}
+
+func (r *StationRepository) Create(station *data.KitchenStation) error {
+    query := `
+        INSERT INTO kitchen_stations (name, is_active)
+        VALUES ($1, $2)
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(query, station.Name, station.IsActive).Scan(&station.ID, &station.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating kitchen station: %w", err)
+    }
+
+    return nil
+}
+
+func (r *StationRepository) Update(station *data.KitchenStation) error {
+    result, err := r.db.Exec(
+        `UPDATE kitchen_stations SET name = $2, is_active = $3 WHERE id = $1`,
+        station.ID, station.Name, station.IsActive,
+    )
+    if err != nil {
+        return fmt.Errorf("error updating kitchen station: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("kitchen station not found")
+    }
+
+    return nil
+}
+
+// Solo se puede eliminar una estación sin productos asignados
+func (r *StationRepository) Delete(id int32) error {
+    var assigned bool
+    if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE station_id = $1)`, id).Scan(&assigned); err != nil {
+        return fmt.Errorf("error checking station products: %w", err)
+    }
+    if assigned {
+        return fmt.Errorf("kitchen station has products assigned")
+    }
+
+    result, err := r.db.Exec(`DELETE FROM kitchen_stations WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("error deleting kitchen station: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("kitchen station not found")
+    }
+
+    return nil
+}
+
+// Asigna (o quita, con nil) la estación que prepara un producto.
+// Solo afecta a los items que se agreguen después.
+func (r *StationRepository) AssignProduct(productID int32, stationID *int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`UPDATE products SET station_id = $2 WHERE id = $1`, productID, stationID)
+    if err != nil {
+        return fmt.Errorf("error assigning product station: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("product not found")
+    }
+
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityProduct, productID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func (r *StationRepository) SetProductPrepTime(productID int32, minutes int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`UPDATE products SET prep_minutes = $2 WHERE id = $1`, productID, minutes)
+    if err != nil {
+        return fmt.Errorf("error updating product prep time: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("product not found")
+    }
+
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityProduct, productID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Momento en que la orden entró a cocina: su primer item marchado. Una
+// pre-orden liberada o un tiempo retenido no se adelantan a lo ya marchado.
+const orderFiredAtExpr = `(SELECT MIN(f.fired_at) FROM order_items f WHERE f.order_id = o.id)`
+
+// Items marchados pendientes o en preparación de las órdenes en cocina, en el
+// orden en que entraron a cocina
+func (r *StationRepository) GetKitchenQueue() ([]data.KitchenQueueItem, error) {
+    query := `
+        SELECT oi.order_id, oi.station_id, p.prep_minutes, oi.started_at
+        FROM order_items oi
+        JOIN orders o ON o.id = oi.order_id
+        JOIN products p ON p.id = oi.product_id
+        WHERE o.status IN ($1, $2) AND oi.status <> $3 AND oi.fired_at IS NOT NULL
+        ORDER BY ` + orderFiredAtExpr + `, o.created_at, o.id, oi.id
+    `
+
+    rows, err := r.db.Query(query, data.OrderStatusPending, data.OrderStatusPreparing, data.OrderItemStatusReady)
+    if err != nil {
+        return nil, fmt.Errorf("error querying kitchen queue: %w", err)
+    }
+    defer rows.Close()
+
+    var queue []data.KitchenQueueItem
+    for rows.Next() {
+        var item data.KitchenQueueItem
+        if err := rows.Scan(&item.OrderID, &item.StationID, &item.PrepMinutes, &item.StartedAt); err != nil {
+            return nil, fmt.Errorf("error scanning kitchen queue: %w", err)
+        }
+        queue = append(queue, item)
+    }
+
+    return queue, rows.Err()
+}
+
+// Items de las pre-órdenes programadas, para calcular cuándo liberarlas
+func (r *StationRepository) GetScheduledItems() ([]data.KitchenQueueItem, error) {
+    query := `
+        SELECT oi.order_id, oi.station_id, p.prep_minutes
+        FROM order_items oi
+        JOIN orders o ON o.id = oi.order_id
+        JOIN products p ON p.id = oi.product_id
+        WHERE o.status = $1
+        ORDER BY o.scheduled_for, o.id, oi.id
+    `
+
+    rows, err := r.db.Query(query, data.OrderStatusScheduled)
+    if err != nil {
+        return nil, fmt.Errorf("error querying scheduled items: %w", err)
+    }
+    defer rows.Close()
+
+    var items []data.KitchenQueueItem
+    for rows.Next() {
+        var item data.KitchenQueueItem
+        if err := rows.Scan(&item.OrderID, &item.StationID, &item.PrepMinutes); err != nil {
+            return nil, fmt.Errorf("error scanning scheduled items: %w", err)
+        }
+        items = append(items, item)
+    }
+
+    return items, rows.Err()
+}
+
+// Comandas abiertas de una estación en el orden en que entraron a cocina.
+// Los tiempos que el mesero aún no marcha no llegan a la estación.
+func (r *StationRepository) GetTickets(stationID int32, includeReady bool) ([]data.StationTicket, error) {
+    return queryTickets(r.db, `
+        oi.station_id = $1 AND ($2 OR oi.status <> 'ready') AND oi.fired_at IS NOT NULL
+        AND oi.order_id IN (SELECT id FROM orders WHERE `+openOrderCondition+` AND status <> 'scheduled')
+    `, stationID, includeReady)
+}
+
+// Comandas de una orden separadas por estación, incluidos los tiempos retenidos
+func (r *StationRepository) GetOrderTickets(orderID int32) ([]data.StationTicket, error) {
+    return queryTickets(r.db, `oi.order_id = $1`, orderID)
+}
+
+func queryTickets(db *sql.DB, where string, args ...any) ([]data.StationTicket, error) {
+    query := `
+        SELECT o.id, o.order_number, o.order_type, o.table_id, o.notes, o.created_at,
+               oi.station_id, COALESCE(s.name, ''), oi.id, oi.product_id, p.name, oi.quantity, oi.status,
+               oi.notes, oi.course, oi.fired_at IS NOT NULL
+        FROM order_items oi
+        JOIN orders o ON o.id = oi.order_id
+        JOIN products p ON p.id = oi.product_id
+        LEFT JOIN kitchen_stations s ON s.id = oi.station_id
+        WHERE ` + where + `
+        ORDER BY ` + orderFiredAtExpr + ` NULLS LAST, o.created_at, o.id, oi.station_id NULLS LAST, oi.course, oi.id
+    `
+
+    rows, err := db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying station tickets: %w", err)
+    }
+    defer rows.Close()
+
+    tickets := make([]data.StationTicket, 0)
+    for rows.Next() {
+        var ticket data.StationTicket
+        var item data.TicketItem
+        err := rows.Scan(
+            &ticket.OrderID,
+            &ticket.OrderNumber,
+            &ticket.OrderType,
+            &ticket.TableID,
+            &ticket.Notes,
+            &ticket.CreatedAt,
+            &ticket.StationID,
+            &ticket.StationName,
+            &item.OrderItemID,
+            &item.ProductID,
+            &item.ProductName,
+            &item.Quantity,
+            &item.Status,
+            &item.Notes,
+            &item.Course,
+            &item.Fired,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning station tickets: %w", err)
+        }
+
+        // Las filas vienen ordenadas por orden y estación: cada cambio abre una comanda
+        last := len(tickets) - 1
+        if last < 0 || tickets[last].OrderID != ticket.OrderID || !sameStation(tickets[last].StationID, ticket.StationID) {
+            tickets = append(tickets, ticket)
+            last++
+        }
+        tickets[last].Items = append(tickets[last].Items, item)
+    }
+
+    return tickets, rows.Err()
+}
+
+func sameStation(a, b *int32) bool {
+    if a == nil || b == nil {
+        return a == nil && b == nil
+    }
+    return *a == *b
+}
+
+// Cambia el estado de un item si sigue en el estado esperado (from)
+func (r *StationRepository) UpdateItemStatus(orderID, itemID int32, from, to string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := lockOrderForKitchenTx(tx, orderID); err != nil {
+        return err
+    }
+
+    result, err := tx.Exec(
+        `UPDATE order_items SET status = $4, started_at = CASE WHEN $5 THEN NOW() ELSE started_at END
+         WHERE id = $1 AND order_id = $2 AND status = $3 AND fired_at IS NOT NULL`,
+        itemID, orderID, from, to, to == data.OrderItemStatusPreparing,
+    )
+    if err != nil {
+        return fmt.Errorf("error updating order item status: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("order item status changed, reload and try again")
+    }
+
+    if err := finishKitchenUpdateTx(tx, orderID, r.deductOn); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Avanza todos los items de la comanda de una estación; los que ya
+// están en ese estado o más adelante, o cuyo tiempo no se ha marchado, no se tocan
+func (r *StationRepository) UpdateTicketStatus(orderID int32, stationID *int32, to string) (int64, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return 0, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := lockOrderForKitchenTx(tx, orderID); err != nil {
+        return 0, err
+    }
+
+    from := []string{data.OrderItemStatusPending}
+    if to == data.OrderItemStatusReady {
+        from = append(from, data.OrderItemStatusPreparing)
+    }
+
+    var affected int64
+    for _, status := range from {
+        result, err := tx.Exec(`
+            UPDATE order_items
+            SET status = $4, started_at = CASE WHEN $5 THEN NOW() ELSE started_at END
+            WHERE order_id = $1 AND station_id IS NOT DISTINCT FROM $2 AND status = $3
+              AND fired_at IS NOT NULL
+        `, orderID, stationID, status, to, to == data.OrderItemStatusPreparing)
+        if err != nil {
+            return 0, fmt.Errorf("error updating ticket status: %w", err)
+        }
+
+        rowsAffected, err := result.RowsAffected()
+        if err != nil {
+            return 0, fmt.Errorf("error getting rows affected: %w", err)
+        }
+        affected += rowsAffected
+    }
+
+    if affected > 0 {
+        if err := finishKitchenUpdateTx(tx, orderID, r.deductOn); err != nil {
+            return 0, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return affected, nil
+}
+
+// Bloquea la orden y verifica que siga en cocina o en servicio; devuelve su estado
+func lockOrderForKitchenTx(tx *sql.Tx, orderID int32) (string, error) {
+    var status string
+    err := tx.QueryRow(`SELECT status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&status)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return "", fmt.Errorf("order not found")
+        }
+        return "", fmt.Errorf("error getting order: %w", err)
+    }
+
+    switch status {
+    case data.OrderStatusPending, data.OrderStatusPreparing, data.OrderStatusReady, data.OrderStatusDelivered:
+        return status, nil
+    }
+    return "", fmt.Errorf("order is %s", status)
+}
+
+// Tras mover items en cocina (o marchar un tiempo) actualiza el estado de la
+// orden y descuenta los items recién marchados
+func finishKitchenUpdateTx(tx *sql.Tx, orderID int32, deductOn string) error {
+    if err := syncOrderStatusFromItemsTx(tx, orderID, deductOn); err != nil {
+        return err
+    }
+
+    if err := syncOrderStockTx(tx, orderID, deductOn); err != nil {
+        return err
+    }
+
+    return publishOrderEventTx(tx, orderID, data.OrderEventUpdated)
+}
+
+// Deriva el estado de la orden a partir de sus items marchados; los tiempos
+// retenidos no cuentan. Lista cuando todos están listos y no queda ningún
+// tiempo por marchar, en preparación cuando alguno empezó (o si llegan items
+// nuevos o se marcha otro tiempo de una orden que ya estaba lista). Las
+// órdenes entregadas o cerradas no cambian.
+func syncOrderStatusFromItemsTx(tx *sql.Tx, orderID int32, deductOn string) error {
+    var status string
+    var total, ready, started, held int
+    err := tx.QueryRow(`
+        SELECT o.status,
+               COUNT(oi.id) FILTER (WHERE oi.fired_at IS NOT NULL),
+               COUNT(oi.id) FILTER (WHERE oi.fired_at IS NOT NULL AND oi.status = $2),
+               COUNT(oi.id) FILTER (WHERE oi.fired_at IS NOT NULL AND oi.status <> $3),
+               COUNT(oi.id) FILTER (WHERE oi.fired_at IS NULL)
+        FROM orders o
+        LEFT JOIN order_items oi ON oi.order_id = o.id
+        WHERE o.id = $1
+        GROUP BY o.status
+    `, orderID, data.OrderItemStatusReady, data.OrderItemStatusPending).Scan(&status, &total, &ready, &started, &held)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("order not found")
+        }
+        return fmt.Errorf("error getting order item statuses: %w", err)
+    }
+
+    target := status
+    switch status {
+    case data.OrderStatusPending, data.OrderStatusPreparing, data.OrderStatusReady:
+        switch {
+        case total > 0 && ready == total && held == 0:
+            target = data.OrderStatusReady
+        case status == data.OrderStatusReady:
+            target = data.OrderStatusPreparing
+        case status == data.OrderStatusPending && started > 0:
+            target = data.OrderStatusPreparing
+        }
+    }
+
+    if target == status {
+        return nil
+    }
+
+    return updateOrderStatusTx(tx, orderID, target, deductOn)
+}
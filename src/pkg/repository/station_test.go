@@ -0,0 +1,39 @@
+package repository
+
+import (
+    "testing"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestKitchenQueueFollowsFireTime(t *testing.T) {
+    db := openTestDB(t)
+    orders := NewOrderRepository(db, data.OrderStatusPreparing)
+    stations := NewStationRepository(db, data.OrderStatusPreparing)
+
+    product := insertTestProduct(t, db, "Lomo", 20, 0, 0)
+
+    // La pre-orden se tomó antes pero se libera después de la otra
+    preOrder := createTestOrder(t, orders, insertTestTable(t, db, 1), data.OrderItem{ProductID: product, Quantity: 1, UnitPrice: 20})
+    mustExec(t, db, `
+        UPDATE orders SET status = $2, created_at = NOW() - INTERVAL '1 hour' WHERE id = $1
+    `, preOrder.ID, data.OrderStatusScheduled)
+
+    walkIn := createTestOrder(t, orders, insertTestTable(t, db, 2), data.OrderItem{ProductID: product, Quantity: 1, UnitPrice: 20})
+
+    if released, err := orders.ReleaseScheduled(preOrder.ID); err != nil || !released {
+        t.Fatalf("ReleaseScheduled() = %v, %v, want true", released, err)
+    }
+
+    queue, err := stations.GetKitchenQueue()
+    if err != nil {
+        t.Fatalf("GetKitchenQueue() error = %v", err)
+    }
+
+    if len(queue) != 2 {
+        t.Fatalf("queue has %d items, want 2", len(queue))
+    }
+    if queue[0].OrderID != walkIn.ID || queue[1].OrderID != preOrder.ID {
+        t.Errorf("queue order = [%d %d], want [%d %d]", queue[0].OrderID, queue[1].OrderID, walkIn.ID, preOrder.ID)
+    }
+}
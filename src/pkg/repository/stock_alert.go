@@ -0,0 +1,209 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type StockAlertRepository struct {
+    *BaseRepository
+}
+
+func NewStockAlertRepository(db *sql.DB) *StockAlertRepository {
+    return &StockAlertRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const stockAlertColumns = `a.id, a.ingredient_id, i.name AS ingredient_name, i.unit, a.stock_quantity, a.min_stock,
+        a.status, a.notified_at, a.acknowledged_at, a.acknowledged_by, a.resolved_at, a.resolved_by, a.created_at`
+
+// Condición SQL para alertas que siguen activas
+const activeStockAlertCondition = `status IN ('open', 'acknowledged')`
+
+// Alertas de la más reciente a la más antigua; status vacío trae las activas
+func (r *StockAlertRepository) Find(status string) ([]data.StockAlert, error) {
+    query := `
+        SELECT ` + stockAlertColumns + `
+        FROM stock_alerts a
+        JOIN ingredients i ON i.id = a.ingredient_id
+        WHERE ($1 = '' AND a.` + activeStockAlertCondition + `) OR a.status = $1
+        ORDER BY a.created_at DESC, a.id DESC
+    `
+
+    return r.query(query, status)
+}
+
+func (r *StockAlertRepository) GetByID(id int32) (*data.StockAlert, error) {
+    query := `
+        SELECT ` + stockAlertColumns + `
+        FROM stock_alerts a
+        JOIN ingredients i ON i.id = a.ingredient_id
+        WHERE a.id = $1
+    `
+
+    alerts, err := r.query(query, id)
+    if err != nil || len(alerts) == 0 {
+        return nil, err
+    }
+
+    return &alerts[0], nil
+}
+
+// Alertas abiertas que aún no se han enviado al notificador
+func (r *StockAlertRepository) GetUnnotified() ([]data.StockAlert, error) {
+    query := `
+        SELECT ` + stockAlertColumns + `
+        FROM stock_alerts a
+        JOIN ingredients i ON i.id = a.ingredient_id
+        WHERE a.status = $1 AND a.notified_at IS NULL
+        ORDER BY a.created_at, a.id
+    `
+
+    return r.query(query, data.StockAlertOpen)
+}
+
+func (r *StockAlertRepository) query(query string, args ...any) ([]data.StockAlert, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying stock alerts: %w", err)
+    }
+    defer rows.Close()
+
+    alerts := make([]data.StockAlert, 0)
+    if err := ScanRowsToStruct(rows, &alerts); err != nil {
+        return nil, fmt.Errorf("error scanning stock alerts: %w", err)
+    }
+
+    return alerts, nil
+}
+
+func (r *StockAlertRepository) MarkNotified(id int32) error {
+    if _, err := r.db.Exec(`UPDATE stock_alerts SET notified_at = NOW() WHERE id = $1`, id); err != nil {
+        return fmt.Errorf("error marking stock alert as notified: %w", err)
+    }
+
+    return nil
+}
+
+// Marca una alerta abierta como vista
+func (r *StockAlertRepository) Acknowledge(id, userID int32) error {
+    return r.transition(id, userID, `
+        UPDATE stock_alerts SET status = 'acknowledged', acknowledged_at = NOW(), acknowledged_by = $2
+        WHERE id = $1 AND status = 'open'
+    `)
+}
+
+// Cierra una alerta activa a mano
+func (r *StockAlertRepository) Resolve(id, userID int32) error {
+    return r.transition(id, userID, `
+        UPDATE stock_alerts SET status = 'resolved', resolved_at = NOW(), resolved_by = $2
+        WHERE id = $1 AND `+activeStockAlertCondition)
+}
+
+func (r *StockAlertRepository) transition(id, userID int32, query string) error {
+    result, err := r.db.Exec(query, id, userID)
+    if err != nil {
+        return fmt.Errorf("error updating stock alert: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected > 0 {
+        return nil
+    }
+
+    var status string
+    if err := r.db.QueryRow(`SELECT status FROM stock_alerts WHERE id = $1`, id).Scan(&status); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("stock alert not found")
+        }
+        return fmt.Errorf("error getting stock alert: %w", err)
+    }
+
+    return fmt.Errorf("stock alert is %s", status)
+}
+
+// Revisión completa: levanta las alertas que falten (stock cargado fuera de
+// los movimientos, mínimos cambiados) y resuelve las de ingredientes ya
+// repuestos. Devuelve cuántas alertas nuevas se levantaron.
+func (r *StockAlertRepository) CheckAll() (int64, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return 0, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`
+        INSERT INTO stock_alerts (ingredient_id, stock_quantity, min_stock, status)
+        SELECT i.id, i.stock_quantity, i.min_stock, 'open'
+        FROM ingredients i
+        WHERE i.min_stock > 0 AND i.stock_quantity <= i.min_stock
+          AND NOT EXISTS (
+              SELECT 1 FROM stock_alerts a WHERE a.ingredient_id = i.id AND a.` + activeStockAlertCondition + `
+          )
+    `)
+    if err != nil {
+        return 0, fmt.Errorf("error raising stock alerts: %w", err)
+    }
+
+    raised, err := result.RowsAffected()
+    if err != nil {
+        return 0, fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    _, err = tx.Exec(`
+        UPDATE stock_alerts a SET status = 'resolved', resolved_at = NOW()
+        FROM ingredients i
+        WHERE i.id = a.ingredient_id AND a.` + activeStockAlertCondition + `
+          AND (i.min_stock <= 0 OR i.stock_quantity > i.min_stock)
+    `)
+    if err != nil {
+        return 0, fmt.Errorf("error resolving stock alerts: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return raised, nil
+}
+
+// Revisa un ingrediente justo después de moverle el stock: levanta la alerta
+// si quedó en o bajo su mínimo, o resuelve la activa si ya se repuso. La fila
+// del ingrediente ya está bloqueada por el movimiento, así que no se duplican.
+func checkStockAlertTx(tx *sql.Tx, ingredientID int32) error {
+    var stock, minStock float64
+    err := tx.QueryRow(`SELECT stock_quantity, min_stock FROM ingredients WHERE id = $1`, ingredientID).Scan(&stock, &minStock)
+    if err != nil {
+        return fmt.Errorf("error getting ingredient stock: %w", err)
+    }
+
+    if minStock > 0 && stock <= minStock {
+        _, err = tx.Exec(`
+            INSERT INTO stock_alerts (ingredient_id, stock_quantity, min_stock, status)
+            SELECT $1, $2, $3, 'open'
+            WHERE NOT EXISTS (
+                SELECT 1 FROM stock_alerts WHERE ingredient_id = $1 AND `+activeStockAlertCondition+`
+            )
+        `, ingredientID, stock, minStock)
+        if err != nil {
+            return fmt.Errorf("error raising stock alert: %w", err)
+        }
+        return nil
+    }
+
+    _, err = tx.Exec(`
+        UPDATE stock_alerts SET status = 'resolved', resolved_at = NOW()
+        WHERE ingredient_id = $1 AND `+activeStockAlertCondition, ingredientID)
+    if err != nil {
+        return fmt.Errorf("error resolving stock alert: %w", err)
+    }
+
+    return nil
+}
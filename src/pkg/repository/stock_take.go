@@ -0,0 +1,339 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "math"
+
+    "github.com/lib/pq"
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type StockTakeRepository struct {
+    *BaseRepository
+}
+
+func NewStockTakeRepository(db *sql.DB) *StockTakeRepository {
+    return &StockTakeRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const stockTakeColumns = `id, status, notes, created_by, approved_by, approved_at, total_variance_value, created_at`
+
+// Cantidad contada de un ingrediente, ya convertida a su unidad
+type StockCount struct {
+    IngredientID int32
+    Quantity     float64
+}
+
+// Conteos por estado, del más reciente al más antiguo; status vacío trae todos
+func (r *StockTakeRepository) Find(status string) ([]data.StockTake, error) {
+    query := `
+        SELECT ` + stockTakeColumns + `
+        FROM stock_takes
+        WHERE $1 = '' OR status = $1
+        ORDER BY created_at DESC, id DESC
+    `
+
+    rows, err := r.db.Query(query, status)
+    if err != nil {
+        return nil, fmt.Errorf("error querying stock takes: %w", err)
+    }
+    defer rows.Close()
+
+    takes := make([]data.StockTake, 0)
+    if err := ScanRowsToStruct(rows, &takes); err != nil {
+        return nil, fmt.Errorf("error scanning stock takes: %w", err)
+    }
+
+    return takes, nil
+}
+
+func (r *StockTakeRepository) GetByID(id int32) (*data.StockTake, error) {
+    var take data.StockTake
+    err := r.db.QueryRow(`SELECT `+stockTakeColumns+` FROM stock_takes WHERE id = $1`, id).Scan(
+        &take.ID,
+        &take.Status,
+        &take.Notes,
+        &take.CreatedBy,
+        &take.ApprovedBy,
+        &take.ApprovedAt,
+        &take.TotalVarianceValue,
+        &take.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting stock take: %w", err)
+    }
+
+    return &take, nil
+}
+
+// Líneas del conteo; mientras no se aprueba, la diferencia se valoriza con el
+// costo actual del ingrediente
+func (r *StockTakeRepository) GetLines(stockTakeID int32) ([]data.StockTakeLine, error) {
+    query := `
+        SELECT l.id, l.stock_take_id, l.ingredient_id, i.name AS ingredient_name, i.unit,
+               l.snapshot_quantity, l.counted_quantity, l.expected_quantity,
+               l.expected_quantity - l.snapshot_quantity AS moved_during_count,
+               l.counted_quantity - l.expected_quantity AS variance,
+               COALESCE(l.unit_cost, i.cost_per_unit) AS unit_cost,
+               COALESCE(l.variance_value, (l.counted_quantity - l.expected_quantity) * i.cost_per_unit) AS variance_value,
+               l.counted_by, l.counted_at, l.movement_id
+        FROM stock_take_lines l
+        JOIN ingredients i ON i.id = l.ingredient_id
+        WHERE l.stock_take_id = $1
+        ORDER BY i.name
+    `
+
+    rows, err := r.db.Query(query, stockTakeID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying stock take lines: %w", err)
+    }
+    defer rows.Close()
+
+    lines := make([]data.StockTakeLine, 0)
+    if err := ScanRowsToStruct(rows, &lines); err != nil {
+        return nil, fmt.Errorf("error scanning stock take lines: %w", err)
+    }
+
+    return lines, nil
+}
+
+// Abre un conteo tomando la foto del stock de los ingredientes indicados
+// (todos si la lista está vacía). Solo puede haber un conteo abierto.
+func (r *StockTakeRepository) Create(take *data.StockTake, ingredientIDs []int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    // Serializa la apertura para que dos conteos simultáneos no pasen el chequeo
+    if _, err := tx.Exec(`LOCK TABLE stock_takes IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+        return fmt.Errorf("error locking stock takes: %w", err)
+    }
+
+    var open bool
+    if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM stock_takes WHERE status = $1)`, data.StockTakeOpen).Scan(&open); err != nil {
+        return fmt.Errorf("error checking open stock takes: %w", err)
+    }
+    if open {
+        return fmt.Errorf("there is already an open stock take")
+    }
+
+    err = tx.QueryRow(`
+        INSERT INTO stock_takes (status, notes, created_by)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `, data.StockTakeOpen, take.Notes, take.CreatedBy).Scan(&take.ID, &take.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating stock take: %w", err)
+    }
+    take.Status = data.StockTakeOpen
+
+    result, err := tx.Exec(`
+        INSERT INTO stock_take_lines (stock_take_id, ingredient_id, snapshot_quantity)
+        SELECT $1, id, stock_quantity
+        FROM ingredients
+        WHERE $2 OR id = ANY($3)
+    `, take.ID, len(ingredientIDs) == 0, pq.Array(ingredientIDs))
+    if err != nil {
+        return fmt.Errorf("error creating stock take lines: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+    if rowsAffected == 0 {
+        return fmt.Errorf("no ingredients to count")
+    }
+    if len(ingredientIDs) > 0 && rowsAffected != int64(len(ingredientIDs)) {
+        return fmt.Errorf("ingredient not found")
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Guarda lo contado; un ingrediente se puede recontar mientras el conteo siga
+// abierto. El stock esperado se toma en el mismo momento.
+func (r *StockTakeRepository) RecordCounts(id, userID int32, counts []StockCount) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := lockOpenStockTakeTx(tx, id); err != nil {
+        return err
+    }
+
+    for _, count := range counts {
+        result, err := tx.Exec(`
+            UPDATE stock_take_lines l
+            SET counted_quantity = $3, expected_quantity = i.stock_quantity, counted_by = $4, counted_at = CURRENT_TIMESTAMP
+            FROM ingredients i
+            WHERE l.stock_take_id = $1 AND l.ingredient_id = $2 AND i.id = l.ingredient_id
+        `, id, count.IngredientID, count.Quantity, userID)
+        if err != nil {
+            return fmt.Errorf("error recording stock count: %w", err)
+        }
+
+        rowsAffected, err := result.RowsAffected()
+        if err != nil {
+            return fmt.Errorf("error getting rows affected: %w", err)
+        }
+        if rowsAffected == 0 {
+            return fmt.Errorf("ingredient %d is not part of the stock take", count.IngredientID)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Aprueba el conteo: cada diferencia se registra como un ajuste valorizado al
+// costo del ingrediente en ese momento. Las líneas sin contar no se ajustan.
+func (r *StockTakeRepository) Approve(id, userID int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := lockOpenStockTakeTx(tx, id); err != nil {
+        return err
+    }
+
+    rows, err := tx.Query(`
+        SELECT l.id, l.ingredient_id, l.counted_quantity - l.expected_quantity, i.cost_per_unit
+        FROM stock_take_lines l
+        JOIN ingredients i ON i.id = l.ingredient_id
+        WHERE l.stock_take_id = $1 AND l.counted_quantity IS NOT NULL
+        ORDER BY l.id
+    `, id)
+    if err != nil {
+        return fmt.Errorf("error querying stock take lines: %w", err)
+    }
+
+    type varianceLine struct {
+        id, ingredientID int32
+        variance, cost   float64
+    }
+    var lines []varianceLine
+    for rows.Next() {
+        var line varianceLine
+        if err := rows.Scan(&line.id, &line.ingredientID, &line.variance, &line.cost); err != nil {
+            rows.Close()
+            return fmt.Errorf("error scanning stock take line: %w", err)
+        }
+        lines = append(lines, line)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("error reading stock take lines: %w", err)
+    }
+
+    if len(lines) == 0 {
+        return fmt.Errorf("stock take has no counted ingredients")
+    }
+
+    total := 0.0
+    for _, line := range lines {
+        // Evita ajustes por ruido de punto flotante (10 - 9.999999999)
+        variance := math.Round(line.variance*1e6) / 1e6
+        value := variance * line.cost
+        total += value
+
+        var movementID *int32
+        if variance != 0 {
+            movement := &data.InventoryMovement{
+                IngredientID: line.ingredientID,
+                MovementType: data.MovementTypeAjuste,
+                Quantity:     variance,
+                Reason:       fmt.Sprintf("Stock take %d", id),
+                UserID:       &userID,
+                StockTakeID:  &id,
+            }
+            // El conteo físico manda: el ajuste se aplica aunque deje el stock en negativo
+            if err := recordMovementTx(tx, movement, true); err != nil {
+                return err
+            }
+            movementID = &movement.ID
+        }
+
+        _, err := tx.Exec(`
+            UPDATE stock_take_lines SET unit_cost = $2, variance_value = $3, movement_id = $4
+            WHERE id = $1
+        `, line.id, line.cost, value, movementID)
+        if err != nil {
+            return fmt.Errorf("error updating stock take line: %w", err)
+        }
+    }
+
+    _, err = tx.Exec(`
+        UPDATE stock_takes
+        SET status = $2, approved_by = $3, approved_at = CURRENT_TIMESTAMP, total_variance_value = $4
+        WHERE id = $1
+    `, id, data.StockTakeApproved, userID, total)
+    if err != nil {
+        return fmt.Errorf("error approving stock take: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Descarta un conteo abierto sin tocar el inventario
+func (r *StockTakeRepository) Cancel(id int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := lockOpenStockTakeTx(tx, id); err != nil {
+        return err
+    }
+
+    if _, err := tx.Exec(`UPDATE stock_takes SET status = $2 WHERE id = $1`, id, data.StockTakeCancelled); err != nil {
+        return fmt.Errorf("error cancelling stock take: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func lockOpenStockTakeTx(tx *sql.Tx, id int32) error {
+    var status string
+    if err := tx.QueryRow(`SELECT status FROM stock_takes WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("stock take not found")
+        }
+        return fmt.Errorf("error getting stock take: %w", err)
+    }
+
+    if status != data.StockTakeOpen {
+        return fmt.Errorf("stock take is %s", status)
+    }
+
+    return nil
+}
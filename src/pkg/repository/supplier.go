@@ -0,0 +1,147 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type SupplierRepository struct {
+    *BaseRepository
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+    return &SupplierRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const supplierColumns = `id, name, contact_name, phone, email, is_active, created_at`
+
+func (r *SupplierRepository) GetAll() ([]data.Supplier, error) {
+    query := `
+        SELECT ` + supplierColumns + `
+        FROM suppliers
+        ORDER BY name
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying suppliers: %w", err)
+    }
+    defer rows.Close()
+
+    var suppliers []data.Supplier
+    if err := ScanRowsToStruct(rows, &suppliers); err != nil {
+        return nil, fmt.Errorf("error scanning suppliers: %w", err)
+    }
+
+    return suppliers, nil
+}
+
+func (r *SupplierRepository) GetByID(id int32) (*data.Supplier, error) {
+    query := `
+        SELECT ` + supplierColumns + `
+        FROM suppliers
+        WHERE id = $1
+    `
+
+    return r.getOne(query, id)
+}
+
+func (r *SupplierRepository) GetByName(name string) (*data.Supplier, error) {
+    query := `
+        SELECT ` + supplierColumns + `
+        FROM suppliers
+        WHERE LOWER(name) = LOWER($1)
+    `
+
+    return r.getOne(query, name)
+}
+
+func (r *SupplierRepository) getOne(query string, args ...any) (*data.Supplier, error) {
+    var supplier data.Supplier
+    err := r.db.QueryRow(query, args...).Scan(
+        &supplier.ID,
+        &supplier.Name,
+        &supplier.ContactName,
+        &supplier.Phone,
+        &supplier.Email,
+        &supplier.IsActive,
+        &supplier.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting supplier: %w", err)
+    }
+
+    return &supplier, nil
+}
+
+func (r *SupplierRepository) Create(supplier *data.Supplier) error {
+    query := `
+        INSERT INTO suppliers (name, contact_name, phone, email, is_active)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(query, supplier.Name, supplier.ContactName, supplier.Phone, supplier.Email, supplier.IsActive).
+        Scan(&supplier.ID, &supplier.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating supplier: %w", err)
+    }
+
+    return nil
+}
+
+func (r *SupplierRepository) Update(supplier *data.Supplier) error {
+    result, err := r.db.Exec(`
+        UPDATE suppliers SET name = $2, contact_name = $3, phone = $4, email = $5, is_active = $6
+        WHERE id = $1
+    `, supplier.ID, supplier.Name, supplier.ContactName, supplier.Phone, supplier.Email, supplier.IsActive)
+    if err != nil {
+        return fmt.Errorf("error updating supplier: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("supplier not found")
+    }
+
+    return nil
+}
+
+// Un proveedor con órdenes de compra se desactiva en lugar de eliminarse
+func (r *SupplierRepository) Delete(id int32) error {
+    var used bool
+    if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE supplier_id = $1)`, id).Scan(&used); err != nil {
+        return fmt.Errorf("error checking supplier purchase orders: %w", err)
+    }
+    if used {
+        return fmt.Errorf("supplier has purchase orders, deactivate it instead")
+    }
+
+    result, err := r.db.Exec(`DELETE FROM suppliers WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("error deleting supplier: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("supplier not found")
+    }
+
+    return nil
+}
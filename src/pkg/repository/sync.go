@@ -0,0 +1,226 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/lib/pq"
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type SyncRepository struct {
+    *BaseRepository
+    // Estado de la orden en el que se descuenta su inventario
+    deductOn string
+}
+
+func NewSyncRepository(db *sql.DB, deductOn string) *SyncRepository {
+    return &SyncRepository{
+        BaseRepository: NewBaseRepository(db),
+        deductOn:       deductOn,
+    }
+}
+
+func (r *SyncRepository) GetSyncedOrder(clientUUID string) (*data.SyncedOrder, error) {
+    query := `
+        SELECT client_uuid, device_id, order_id, client_created_at, synced_at
+        FROM synced_orders
+        WHERE client_uuid = $1
+    `
+
+    var synced data.SyncedOrder
+    err := r.db.QueryRow(query, clientUUID).Scan(
+        &synced.ClientUUID,
+        &synced.DeviceID,
+        &synced.OrderID,
+        &synced.ClientCreatedAt,
+        &synced.SyncedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting synced order: %w", err)
+    }
+
+    return &synced, nil
+}
+
+// Crea una orden tomada sin conexión conservando la hora del dispositivo.
+// Devuelve false si el UUID ya fue sincronizado (por otro envío concurrente).
+func (r *SyncRepository) CreateOfflineOrder(synced *data.SyncedOrder, order *data.Order, items []data.OrderItem) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    // Reservar el UUID primero: un segundo envío espera aquí y luego no inserta nada
+    err = tx.QueryRow(`
+        INSERT INTO synced_orders (client_uuid, device_id, client_created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (client_uuid) DO NOTHING
+        RETURNING synced_at
+    `, synced.ClientUUID, synced.DeviceID, synced.ClientCreatedAt).Scan(&synced.SyncedAt)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return false, nil
+        }
+        return false, fmt.Errorf("error registering synced order: %w", err)
+    }
+
+    if err := createOrderWithItemsTx(tx, order, items, r.deductOn); err != nil {
+        return false, err
+    }
+
+    if _, err := tx.Exec(`UPDATE orders SET created_at = $2 WHERE id = $1`, order.ID, synced.ClientCreatedAt); err != nil {
+        return false, fmt.Errorf("error setting order creation time: %w", err)
+    }
+    order.CreatedAt = synced.ClientCreatedAt
+
+    if _, err := tx.Exec(`UPDATE synced_orders SET order_id = $2 WHERE client_uuid = $1`, synced.ClientUUID, order.ID); err != nil {
+        return false, fmt.Errorf("error linking synced order: %w", err)
+    }
+    synced.OrderID = order.ID
+
+    if err := tx.Commit(); err != nil {
+        return false, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return true, nil
+}
+
+// Cambios del catálogo posteriores a la versión since. Sin versión, o con una
+// que el servidor no conoce, se devuelve el catálogo completo.
+//
+// La versión es el xmin de la foto: toda transacción con id menor ya terminó,
+// así un cambio que confirma tarde nunca queda detrás de una versión entregada.
+// Se envían los cambios con tx_id >= since; los que también son >= a la nueva
+// versión se repiten en la siguiente sincronización, lo que es inofensivo.
+func (r *SyncRepository) GetCatalogDelta(since int64) (*data.CatalogDelta, error) {
+    // Una sola foto de la base para que la versión coincida con los datos enviados
+    tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+    if err != nil {
+        return nil, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    delta := &data.CatalogDelta{
+        Products:           []data.Product{},
+        DeletedProductIDs:  []int32{},
+        Categories:         []data.Category{},
+        DeletedCategoryIDs: []int32{},
+    }
+
+    if err := tx.QueryRow(`SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`).Scan(&delta.Version); err != nil {
+        return nil, fmt.Errorf("error getting catalog version: %w", err)
+    }
+
+    delta.Full = since <= 0 || since > delta.Version
+
+    var productIDs, categoryIDs []int32
+    if !delta.Full {
+        if productIDs, err = changedCatalogIDsTx(tx, data.CatalogEntityProduct, since); err != nil {
+            return nil, err
+        }
+        if categoryIDs, err = changedCatalogIDsTx(tx, data.CatalogEntityCategory, since); err != nil {
+            return nil, err
+        }
+    }
+
+    productQuery := `
+        SELECT id, name, description, price, category_id, image_url, is_available, tax_rate_id, station_id, prep_minutes, created_at
+        FROM products
+        WHERE $1 OR id = ANY($2)
+        ORDER BY id
+    `
+
+    rows, err := tx.Query(productQuery, delta.Full, pq.Array(productIDs))
+    if err != nil {
+        return nil, fmt.Errorf("error querying changed products: %w", err)
+    }
+    err = ScanRowsToStruct(rows, &delta.Products)
+    rows.Close()
+    if err != nil {
+        return nil, fmt.Errorf("error scanning changed products: %w", err)
+    }
+
+    categoryQuery := `
+        SELECT id, name, tax_rate_id, created_at
+        FROM categories
+        WHERE $1 OR id = ANY($2)
+        ORDER BY id
+    `
+
+    rows, err = tx.Query(categoryQuery, delta.Full, pq.Array(categoryIDs))
+    if err != nil {
+        return nil, fmt.Errorf("error querying changed categories: %w", err)
+    }
+    err = ScanRowsToStruct(rows, &delta.Categories)
+    rows.Close()
+    if err != nil {
+        return nil, fmt.Errorf("error scanning changed categories: %w", err)
+    }
+
+    // Los que cambiaron y ya no existen fueron eliminados
+    found := make(map[int32]bool, len(delta.Products))
+    for _, product := range delta.Products {
+        found[product.ID] = true
+    }
+    for _, id := range productIDs {
+        if !found[id] {
+            delta.DeletedProductIDs = append(delta.DeletedProductIDs, id)
+        }
+    }
+
+    found = make(map[int32]bool, len(delta.Categories))
+    for _, category := range delta.Categories {
+        found[category.ID] = true
+    }
+    for _, id := range categoryIDs {
+        if !found[id] {
+            delta.DeletedCategoryIDs = append(delta.DeletedCategoryIDs, id)
+        }
+    }
+
+    return delta, nil
+}
+
+func changedCatalogIDsTx(tx *sql.Tx, entity string, since int64) ([]int32, error) {
+    rows, err := tx.Query(`
+        SELECT DISTINCT entity_id
+        FROM catalog_changes
+        WHERE entity = $1 AND tx_id >= $2
+    `, entity, since)
+    if err != nil {
+        return nil, fmt.Errorf("error querying catalog changes: %w", err)
+    }
+    defer rows.Close()
+
+    var ids []int32
+    for rows.Next() {
+        var id int32
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("error scanning catalog changes: %w", err)
+        }
+        ids = append(ids, id)
+    }
+
+    return ids, rows.Err()
+}
+
+// Registra que un producto o categoría cambió, para enviarlo en la próxima
+// sincronización de los POS
+func recordCatalogChangeTx(tx *sql.Tx, entity string, id int32) error {
+    _, err := tx.Exec(`
+        INSERT INTO catalog_changes (entity, entity_id, tx_id)
+        VALUES ($1, $2, pg_current_xact_id()::text::bigint)
+    `, entity, id)
+    if err != nil {
+        return fmt.Errorf("error recording catalog change: %w", err)
+    }
+
+    return nil
+}
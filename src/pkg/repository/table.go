@@ -0,0 +1,260 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type TableRepository struct {
+    *BaseRepository
+}
+
+func NewTableRepository(db *sql.DB) *TableRepository {
+    return &TableRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+func (r *TableRepository) GetAll() ([]data.Table, error) {
+    query := `
+        SELECT id, number, zone, capacity, status, created_at
+        FROM restaurant_tables
+        ORDER BY zone, number
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying tables: %w", err)
+    }
+    defer rows.Close()
+
+    var tables []data.Table
+    if err := ScanRowsToStruct(rows, &tables); err != nil {
+        return nil, fmt.Errorf("error scanning tables: %w", err)
+    }
+
+    return tables, nil
+}
+
+func (r *TableRepository) GetByID(id int32) (*data.Table, error) {
+    query := `
+        SELECT id, number, zone, capacity, status, created_at
+        FROM restaurant_tables
+        WHERE id = $1
+    `
+
+    var table data.Table
+    err := r.db.QueryRow(query, id).Scan(
+        &table.ID,
+        &table.Number,
+        &table.Zone,
+        &table.Capacity,
+        &table.Status,
+        &table.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting table: %w", err)
+    }
+
+    return &table, nil
+}
+
+func (r *TableRepository) GetByNumber(number int32) (*data.Table, error) {
+    query := `
+        SELECT id, number, zone, capacity, status, created_at
+        FROM restaurant_tables
+        WHERE number = $1
+    `
+
+    var table data.Table
+    err := r.db.QueryRow(query, number).Scan(
+        &table.ID,
+        &table.Number,
+        &table.Zone,
+        &table.Capacity,
+        &table.Status,
+        &table.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting table: %w", err)
+    }
+
+    return &table, nil
+}
+
+func (r *TableRepository) Create(table *data.Table) error {
+    query := `
+        INSERT INTO restaurant_tables (number, zone, capacity, status)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(
+        query,
+        table.Number,
+        table.Zone,
+        table.Capacity,
+        table.Status,
+    ).Scan(&table.ID, &table.CreatedAt)
+
+    if err != nil {
+        return fmt.Errorf("error creating table: %w", err)
+    }
+
+    return nil
+}
+
+func (r *TableRepository) Update(table *data.Table) error {
+    query := `
+        UPDATE restaurant_tables
+        SET number = $2, zone = $3, capacity = $4, status = $5
+        WHERE id = $1
+    `
+
+    result, err := r.db.Exec(
+        query,
+        table.ID,
+        table.Number,
+        table.Zone,
+        table.Capacity,
+        table.Status,
+    )
+
+    if err != nil {
+        return fmt.Errorf("error updating table: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("table not found")
+    }
+
+    return nil
+}
+
+func (r *TableRepository) Delete(id int32) error {
+    query := "DELETE FROM restaurant_tables WHERE id = $1"
+
+    result, err := r.db.Exec(query, id)
+    if err != nil {
+        return fmt.Errorf("error deleting table: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("table not found")
+    }
+
+    return nil
+}
+
+// Plano del salón: cada mesa con sus órdenes abiertas y el total acumulado
+func (r *TableRepository) GetFloorPlan() ([]data.TableOccupancy, error) {
+    query := `
+        SELECT t.id, t.number, t.zone, t.capacity, t.status, t.created_at,
+               COUNT(o.id), COALESCE(SUM(o.total_amount), 0), MIN(o.created_at)
+        FROM restaurant_tables t
+        LEFT JOIN orders o ON o.table_id = t.id AND o.` + openOrderCondition + `
+        GROUP BY t.id
+        ORDER BY t.zone, t.number
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying floor plan: %w", err)
+    }
+    defer rows.Close()
+
+    var plan []data.TableOccupancy
+    for rows.Next() {
+        var occupancy data.TableOccupancy
+        err := rows.Scan(
+            &occupancy.Table.ID,
+            &occupancy.Table.Number,
+            &occupancy.Table.Zone,
+            &occupancy.Table.Capacity,
+            &occupancy.Table.Status,
+            &occupancy.Table.CreatedAt,
+            &occupancy.OpenOrders,
+            &occupancy.RunningTotal,
+            &occupancy.OpenedAt,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning floor plan: %w", err)
+        }
+        plan = append(plan, occupancy)
+    }
+
+    return plan, rows.Err()
+}
+
+func setTableStatusTx(tx *sql.Tx, tableID int32, status string) error {
+    result, err := tx.Exec(`UPDATE restaurant_tables SET status = $2 WHERE id = $1`, tableID, status)
+    if err != nil {
+        return fmt.Errorf("error updating table status: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("table not found")
+    }
+
+    return nil
+}
+
+// Ocupa la mesa para una orden nueva o que llega a ella; una mesa fuera de
+// servicio no recibe órdenes
+func occupyTableTx(tx *sql.Tx, tableID int32) error {
+    var status string
+    err := tx.QueryRow(`SELECT status FROM restaurant_tables WHERE id = $1 FOR UPDATE`, tableID).Scan(&status)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("table not found")
+        }
+        return fmt.Errorf("error getting table: %w", err)
+    }
+
+    if status == data.TableStatusOutOfService {
+        return fmt.Errorf("table is out of service")
+    }
+
+    return setTableStatusTx(tx, tableID, data.TableStatusOccupied)
+}
+
+// Marca la mesa como disponible si ya no tiene órdenes abiertas
+func releaseTableIfIdleTx(tx *sql.Tx, tableID int32) error {
+    query := `
+        UPDATE restaurant_tables
+        SET status = $2
+        WHERE id = $1 AND status = $3
+          AND NOT EXISTS (SELECT 1 FROM orders WHERE table_id = $1 AND ` + openOrderCondition + `)
+    `
+
+    if _, err := tx.Exec(query, tableID, data.TableStatusAvailable, data.TableStatusOccupied); err != nil {
+        return fmt.Errorf("error releasing table: %w", err)
+    }
+
+    return nil
+}
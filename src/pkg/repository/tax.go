@@ -0,0 +1,292 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type TaxRepository struct {
+    *BaseRepository
+}
+
+func NewTaxRepository(db *sql.DB) *TaxRepository {
+    return &TaxRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+func (r *TaxRepository) GetAll() ([]data.TaxRate, error) {
+    query := `
+        SELECT id, name, rate, is_active, created_at
+        FROM tax_rates
+        ORDER BY name
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying tax rates: %w", err)
+    }
+    defer rows.Close()
+
+    var rates []data.TaxRate
+    if err := ScanRowsToStruct(rows, &rates); err != nil {
+        return nil, fmt.Errorf("error scanning tax rates: %w", err)
+    }
+
+    return rates, nil
+}
+
+func (r *TaxRepository) GetByID(id int32) (*data.TaxRate, error) {
+    query := `
+        SELECT id, name, rate, is_active, created_at
+        FROM tax_rates
+        WHERE id = $1
+    `
+
+    var rate data.TaxRate
+    err := r.db.QueryRow(query, id).Scan(
+        &rate.ID,
+        &rate.Name,
+        &rate.Rate,
+        &rate.IsActive,
+        &rate.CreatedAt,
+    )
+
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting tax rate: %w", err)
+    }
+
+    return &rate, nil
+}
+
+func (r *TaxRepository) Create(rate *data.TaxRate) error {
+    query := `
+        INSERT INTO tax_rates (name, rate, is_active)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(query, rate.Name, rate.Rate, rate.IsActive).Scan(&rate.ID, &rate.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating tax rate: %w", err)
+    }
+
+    return nil
+}
+
+// Los items ya vendidos conservan la tarifa con la que se registraron
+func (r *TaxRepository) Update(rate *data.TaxRate) error {
+    query := `
+        UPDATE tax_rates
+        SET name = $2, rate = $3, is_active = $4
+        WHERE id = $1
+    `
+
+    result, err := r.db.Exec(query, rate.ID, rate.Name, rate.Rate, rate.IsActive)
+    if err != nil {
+        return fmt.Errorf("error updating tax rate: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("tax rate not found")
+    }
+
+    return nil
+}
+
+// Asigna (o quita, con nil) la tarifa propia de un producto
+func (r *TaxRepository) AssignToProduct(productID int32, taxRateID *int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`UPDATE products SET tax_rate_id = $2 WHERE id = $1`, productID, taxRateID)
+    if err != nil {
+        return fmt.Errorf("error assigning product tax rate: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("product not found")
+    }
+
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityProduct, productID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Asigna (o quita, con nil) la tarifa por defecto de una categoría
+func (r *TaxRepository) AssignToCategory(categoryID int32, taxRateID *int32) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`UPDATE categories SET tax_rate_id = $2 WHERE id = $1`, categoryID, taxRateID)
+    if err != nil {
+        return fmt.Errorf("error assigning category tax rate: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("category not found")
+    }
+
+    if err := recordCatalogChangeTx(tx, data.CatalogEntityCategory, categoryID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Impuestos por tarifa de las órdenes del periodo (excluye canceladas y unidas)
+func (r *TaxRepository) GetReport(filter OrderFilter) ([]data.TaxSummary, error) {
+    where, args := filter.where(nil)
+    if where == "" {
+        where = " WHERE "
+    } else {
+        where += " AND "
+    }
+    where += "status NOT IN ('cancelled', 'merged')"
+
+    query := `
+        SELECT tax_rate_id, name, rate, COUNT(DISTINCT order_id),
+               COALESCE(SUM(base), 0), COALESCE(SUM(amount), 0)
+        FROM order_taxes
+        WHERE order_id IN (SELECT id FROM orders` + where + `)
+        GROUP BY tax_rate_id, name, rate
+        ORDER BY rate DESC, name
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying tax report: %w", err)
+    }
+    defer rows.Close()
+
+    var report []data.TaxSummary
+    for rows.Next() {
+        var line data.TaxSummary
+        if err := rows.Scan(&line.TaxRateID, &line.Name, &line.Rate, &line.Orders, &line.Base, &line.Amount); err != nil {
+            return nil, fmt.Errorf("error scanning tax report: %w", err)
+        }
+        report = append(report, line)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating tax report: %w", err)
+    }
+
+    return report, nil
+}
+
+// Tarifa vigente de un producto: la propia o, en su defecto, la de su categoría
+func productTaxRateTx(tx *sql.Tx, productID int32) (*int32, float64, error) {
+    query := `
+        SELECT t.id, t.rate
+        FROM products p
+        LEFT JOIN categories c ON c.id = p.category_id
+        JOIN tax_rates t ON t.id = COALESCE(p.tax_rate_id, c.tax_rate_id)
+        WHERE p.id = $1 AND t.is_active = true
+    `
+
+    var id int32
+    var rate float64
+    err := tx.QueryRow(query, productID).Scan(&id, &rate)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, 0, nil
+        }
+        return nil, 0, fmt.Errorf("error getting product tax rate: %w", err)
+    }
+
+    return &id, rate, nil
+}
+
+// Nombres de las tarifas usadas por los items de la orden
+func orderTaxNamesTx(tx *sql.Tx, orderID int32) (map[int32]string, error) {
+    rows, err := tx.Query(`
+        SELECT DISTINCT t.id, t.name
+        FROM order_items oi
+        JOIN tax_rates t ON t.id = oi.tax_rate_id
+        WHERE oi.order_id = $1
+    `, orderID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying order tax rates: %w", err)
+    }
+    defer rows.Close()
+
+    names := make(map[int32]string)
+    for rows.Next() {
+        var id int32
+        var name string
+        if err := rows.Scan(&id, &name); err != nil {
+            return nil, fmt.Errorf("error scanning order tax rates: %w", err)
+        }
+        names[id] = name
+    }
+
+    return names, rows.Err()
+}
+
+// Reemplaza el desglose de impuestos de la orden
+func replaceOrderTaxesTx(tx *sql.Tx, orderID int32, taxes []data.OrderTax) error {
+    if _, err := tx.Exec(`DELETE FROM order_taxes WHERE order_id = $1`, orderID); err != nil {
+        return fmt.Errorf("error deleting order taxes: %w", err)
+    }
+
+    query := `
+        INSERT INTO order_taxes (order_id, tax_rate_id, name, rate, base, amount)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `
+
+    for i := range taxes {
+        taxes[i].OrderID = orderID
+        err := tx.QueryRow(
+            query,
+            orderID,
+            taxes[i].TaxRateID,
+            taxes[i].Name,
+            taxes[i].Rate,
+            taxes[i].Base,
+            taxes[i].Amount,
+        ).Scan(&taxes[i].ID)
+        if err != nil {
+            return fmt.Errorf("error creating order tax: %w", err)
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,322 @@
+-- Esquema mínimo para las pruebas del repositorio contra PostgreSQL.
+-- Se recarga completo antes de cada prueba (ver db_test.go).
+
+DROP SCHEMA IF EXISTS public CASCADE;
+CREATE SCHEMA public;
+
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    full_name VARCHAR(100) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL DEFAULT 'staff',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE tax_rates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    tax_rate_id INTEGER REFERENCES tax_rates(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE kitchen_stations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE products (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    category_id INTEGER REFERENCES categories(id),
+    image_url TEXT NOT NULL DEFAULT '',
+    is_available BOOLEAN NOT NULL DEFAULT true,
+    tax_rate_id INTEGER REFERENCES tax_rates(id),
+    station_id INTEGER REFERENCES kitchen_stations(id),
+    prep_minutes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE ingredients (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    unit VARCHAR(20) NOT NULL,
+    stock_quantity DOUBLE PRECISION NOT NULL DEFAULT 0,
+    min_stock DOUBLE PRECISION NOT NULL DEFAULT 0,
+    cost_per_unit DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE product_recipes (
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id),
+    quantity DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (product_id, ingredient_id)
+);
+
+CREATE TABLE bundle_components (
+    bundle_id INTEGER NOT NULL REFERENCES products(id),
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (bundle_id, product_id)
+);
+
+CREATE TABLE restaurant_tables (
+    id SERIAL PRIMARY KEY,
+    number INTEGER NOT NULL UNIQUE,
+    zone VARCHAR(50) NOT NULL DEFAULT '',
+    capacity INTEGER NOT NULL DEFAULT 4,
+    status VARCHAR(20) NOT NULL DEFAULT 'available',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE orders (
+    id SERIAL PRIMARY KEY,
+    order_number VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    previous_status VARCHAR(20),
+    total_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    notes TEXT NOT NULL DEFAULT '',
+    table_id INTEGER REFERENCES restaurant_tables(id),
+    order_type VARCHAR(20) NOT NULL DEFAULT 'dine_in',
+    customer_address TEXT NOT NULL DEFAULT '',
+    customer_phone VARCHAR(30) NOT NULL DEFAULT '',
+    delivery_fee DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pickup_time TIMESTAMP,
+    payment_status VARCHAR(20) NOT NULL DEFAULT 'unpaid',
+    discount_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tax_mode VARCHAR(20) NOT NULL DEFAULT 'exclusive',
+    tax_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    party_size INTEGER NOT NULL DEFAULT 0,
+    service_charge_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    service_charge DOUBLE PRECISION NOT NULL DEFAULT 0,
+    scheduled_for TIMESTAMP,
+    fired_course INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE order_items (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL,
+    unit_price DOUBLE PRECISION NOT NULL,
+    discount_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    subtotal DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tax_rate_id INTEGER REFERENCES tax_rates(id),
+    tax_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    station_id INTEGER REFERENCES kitchen_stations(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    notes TEXT NOT NULL DEFAULT '',
+    course INTEGER NOT NULL DEFAULT 0,
+    fired_at TIMESTAMP,
+    started_at TIMESTAMP,
+    stock_deducted BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE TABLE order_item_modifiers (
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id),
+    modifier_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    price_delta DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ingredient_id INTEGER REFERENCES ingredients(id),
+    quantity DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE TABLE order_events (
+    id BIGSERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    tx_id BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_events_cursor ON order_events (tx_id, id);
+
+CREATE TABLE order_taxes (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    tax_rate_id INTEGER REFERENCES tax_rates(id),
+    name VARCHAR(100) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    base DOUBLE PRECISION NOT NULL,
+    amount DOUBLE PRECISION NOT NULL
+);
+
+CREATE TABLE discount_presets (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE coupons (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    type VARCHAR(20) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    min_order_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    max_uses_per_customer INTEGER NOT NULL DEFAULT 0,
+    times_used INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE coupon_redemptions (
+    coupon_id INTEGER NOT NULL REFERENCES coupons(id),
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    customer_ref VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE order_discounts (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    order_item_id INTEGER REFERENCES order_items(id),
+    preset_id INTEGER REFERENCES discount_presets(id),
+    coupon_id INTEGER REFERENCES coupons(id),
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE order_splits (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    split_number INTEGER NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE order_split_items (
+    id SERIAL PRIMARY KEY,
+    split_id INTEGER NOT NULL REFERENCES order_splits(id),
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id),
+    quantity DOUBLE PRECISION NOT NULL,
+    amount DOUBLE PRECISION NOT NULL
+);
+
+CREATE TABLE cash_shifts (
+    id SERIAL PRIMARY KEY,
+    register VARCHAR(50) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    opening_float DOUBLE PRECISION NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    expected_cash DOUBLE PRECISION,
+    counted_cash DOUBLE PRECISION,
+    discrepancy DOUBLE PRECISION,
+    notes TEXT NOT NULL DEFAULT '',
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE TABLE cash_movements (
+    id SERIAL PRIMARY KEY,
+    shift_id INTEGER NOT NULL REFERENCES cash_shifts(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    type VARCHAR(10) NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE cash_shift_tenders (
+    shift_id INTEGER NOT NULL REFERENCES cash_shifts(id),
+    method VARCHAR(20) NOT NULL,
+    payment_count INTEGER NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    tips DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (shift_id, method)
+);
+
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    split_id INTEGER REFERENCES order_splits(id),
+    shift_id INTEGER REFERENCES cash_shifts(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    method VARCHAR(20) NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    tendered DOUBLE PRECISION NOT NULL DEFAULT 0,
+    change_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    tip_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tip_staff_id INTEGER REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    reversal_reason TEXT NOT NULL DEFAULT '',
+    reversed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE inventory_lots (
+    id SERIAL PRIMARY KEY,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id),
+    lot_number VARCHAR(50) NOT NULL DEFAULT '',
+    expires_at DATE,
+    quantity_received DOUBLE PRECISION NOT NULL,
+    quantity_remaining DOUBLE PRECISION NOT NULL,
+    purchase_order_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE inventory_movements (
+    id SERIAL PRIMARY KEY,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id),
+    movement_type VARCHAR(20) NOT NULL,
+    quantity DOUBLE PRECISION NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    user_id INTEGER REFERENCES users(id),
+    stock_after DOUBLE PRECISION NOT NULL,
+    order_id INTEGER REFERENCES orders(id),
+    order_item_id INTEGER REFERENCES order_items(id),
+    purchase_order_id INTEGER,
+    stock_take_id INTEGER,
+    lot_id INTEGER REFERENCES inventory_lots(id),
+    reversal_of INTEGER REFERENCES inventory_movements(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE lot_consumptions (
+    lot_id INTEGER NOT NULL REFERENCES inventory_lots(id),
+    movement_id INTEGER NOT NULL REFERENCES inventory_movements(id),
+    quantity DOUBLE PRECISION NOT NULL
+);
+
+CREATE TABLE stock_alerts (
+    id SERIAL PRIMARY KEY,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id),
+    stock_quantity DOUBLE PRECISION NOT NULL,
+    min_stock DOUBLE PRECISION NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    notified_at TIMESTAMP,
+    acknowledged_at TIMESTAMP,
+    acknowledged_by INTEGER REFERENCES users(id),
+    resolved_at TIMESTAMP,
+    resolved_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,302 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/lib/pq"
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type UnitRepository struct {
+    *BaseRepository
+}
+
+func NewUnitRepository(db *sql.DB) *UnitRepository {
+    return &UnitRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+// Catálogo inicial de unidades; Factor en la unidad base de cada dimensión
+var defaultUnits = []data.MeasurementUnit{
+    {Code: "g", Name: "Gramo", Dimension: data.DimensionMass, Factor: 1},
+    {Code: "kg", Name: "Kilogramo", Dimension: data.DimensionMass, Factor: 1000},
+    {Code: "lb", Name: "Libra", Dimension: data.DimensionMass, Factor: 453.592},
+    {Code: "ml", Name: "Mililitro", Dimension: data.DimensionVolume, Factor: 1},
+    {Code: "l", Name: "Litro", Dimension: data.DimensionVolume, Factor: 1000},
+    {Code: "un", Name: "Unidad", Dimension: data.DimensionCount, Factor: 1},
+    {Code: "doc", Name: "Docena", Dimension: data.DimensionCount, Factor: 12},
+}
+
+// Textos libres que usaban los ingredientes antes del catálogo y su código
+var legacyUnitAliases = map[string]string{
+    "gr": "g", "gramo": "g", "gramos": "g",
+    "kilo": "kg", "kilos": "kg", "kilogramo": "kg", "kilogramos": "kg",
+    "libra": "lb", "libras": "lb",
+    "cc": "ml", "mililitro": "ml", "mililitros": "ml",
+    "lt": "l", "lts": "l", "litro": "l", "litros": "l",
+    "u": "un", "und": "un", "unidad": "un", "unidades": "un",
+    "docena": "doc", "docenas": "doc",
+}
+
+// Crea las unidades del catálogo inicial que falten y pasa al código del
+// catálogo las unidades en texto libre de ingredientes y presentaciones que
+// lo permitan ("Kilos" -> kg). Es idempotente; se llama al arrancar.
+func (r *UnitRepository) SeedDefaults() error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    for _, unit := range defaultUnits {
+        _, err := tx.Exec(`
+            INSERT INTO units (code, name, dimension, factor)
+            SELECT $1, $2, $3, $4
+            WHERE NOT EXISTS (SELECT 1 FROM units WHERE LOWER(code) = LOWER($1))
+        `, unit.Code, unit.Name, unit.Dimension, unit.Factor)
+        if err != nil {
+            return fmt.Errorf("error seeding units: %w", err)
+        }
+    }
+
+    aliases := make([]string, 0, len(legacyUnitAliases))
+    codes := make([]string, 0, len(legacyUnitAliases))
+    for alias, code := range legacyUnitAliases {
+        aliases = append(aliases, alias)
+        codes = append(codes, code)
+    }
+
+    // Primero las que ya coinciden con un código salvo mayúsculas o espacios,
+    // luego los alias conocidos si su código existe en el catálogo
+    for _, table := range []string{"ingredients", "pack_units"} {
+        _, err := tx.Exec(`
+            UPDATE ` + table + ` t SET unit = u.code
+            FROM units u
+            WHERE LOWER(TRIM(t.unit)) = LOWER(u.code) AND t.unit <> u.code
+        `)
+        if err != nil {
+            return fmt.Errorf("error normalizing %s units: %w", table, err)
+        }
+
+        _, err = tx.Exec(`
+            UPDATE `+table+` t SET unit = u.code
+            FROM UNNEST($1::text[], $2::text[]) AS a (alias, code)
+            JOIN units u ON u.code = a.code
+            WHERE LOWER(TRIM(t.unit)) = a.alias
+        `, pq.Array(aliases), pq.Array(codes))
+        if err != nil {
+            return fmt.Errorf("error normalizing %s units: %w", table, err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+func (r *UnitRepository) GetAll() ([]data.MeasurementUnit, error) {
+    query := `
+        SELECT id, code, name, dimension, factor
+        FROM units
+        ORDER BY dimension, factor, code
+    `
+
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying units: %w", err)
+    }
+    defer rows.Close()
+
+    var units []data.MeasurementUnit
+    if err := ScanRowsToStruct(rows, &units); err != nil {
+        return nil, fmt.Errorf("error scanning units: %w", err)
+    }
+
+    return units, nil
+}
+
+func (r *UnitRepository) GetByID(id int32) (*data.MeasurementUnit, error) {
+    return r.getOne(`SELECT id, code, name, dimension, factor FROM units WHERE id = $1`, id)
+}
+
+func (r *UnitRepository) GetByCode(code string) (*data.MeasurementUnit, error) {
+    return r.getOne(`SELECT id, code, name, dimension, factor FROM units WHERE LOWER(code) = LOWER($1)`, code)
+}
+
+func (r *UnitRepository) getOne(query string, args ...any) (*data.MeasurementUnit, error) {
+    var unit data.MeasurementUnit
+    err := r.db.QueryRow(query, args...).Scan(&unit.ID, &unit.Code, &unit.Name, &unit.Dimension, &unit.Factor)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting unit: %w", err)
+    }
+
+    return &unit, nil
+}
+
+func (r *UnitRepository) Create(unit *data.MeasurementUnit) error {
+    err := r.db.QueryRow(`
+        INSERT INTO units (code, name, dimension, factor)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, unit.Code, unit.Name, unit.Dimension, unit.Factor).Scan(&unit.ID)
+    if err != nil {
+        return fmt.Errorf("error creating unit: %w", err)
+    }
+
+    return nil
+}
+
+func (r *UnitRepository) Update(unit *data.MeasurementUnit) error {
+    result, err := r.db.Exec(
+        `UPDATE units SET code = $2, name = $3, dimension = $4, factor = $5 WHERE id = $1`,
+        unit.ID, unit.Code, unit.Name, unit.Dimension, unit.Factor,
+    )
+    if err != nil {
+        return fmt.Errorf("error updating unit: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("unit not found")
+    }
+
+    return nil
+}
+
+// Indica si algún ingrediente, presentación de compra u orden de compra usa la unidad
+func (r *UnitRepository) IsUsed(code string) (bool, error) {
+    var used bool
+    err := r.db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM ingredients WHERE LOWER(unit) = LOWER($1))
+            OR EXISTS (SELECT 1 FROM pack_units WHERE LOWER(unit) = LOWER($1))
+            OR EXISTS (SELECT 1 FROM purchase_order_items WHERE LOWER(purchase_unit) = LOWER($1))
+    `, code).Scan(&used)
+    if err != nil {
+        return false, fmt.Errorf("error checking unit usage: %w", err)
+    }
+
+    return used, nil
+}
+
+func (r *UnitRepository) Delete(id int32) error {
+    unit, err := r.GetByID(id)
+    if err != nil {
+        return err
+    }
+    if unit == nil {
+        return fmt.Errorf("unit not found")
+    }
+
+    used, err := r.IsUsed(unit.Code)
+    if err != nil {
+        return err
+    }
+    if used {
+        return fmt.Errorf("unit is used by ingredients or purchase orders")
+    }
+
+    if _, err := r.db.Exec(`DELETE FROM units WHERE id = $1`, id); err != nil {
+        return fmt.Errorf("error deleting unit: %w", err)
+    }
+
+    return nil
+}
+
+func (r *UnitRepository) GetPacks(ingredientID int32) ([]data.PackUnit, error) {
+    query := `
+        SELECT id, ingredient_id, name, quantity, unit
+        FROM pack_units
+        WHERE ingredient_id = $1
+        ORDER BY name
+    `
+
+    rows, err := r.db.Query(query, ingredientID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying pack units: %w", err)
+    }
+    defer rows.Close()
+
+    packs := make([]data.PackUnit, 0)
+    if err := ScanRowsToStruct(rows, &packs); err != nil {
+        return nil, fmt.Errorf("error scanning pack units: %w", err)
+    }
+
+    return packs, nil
+}
+
+func (r *UnitRepository) GetPackByName(ingredientID int32, name string) (*data.PackUnit, error) {
+    var pack data.PackUnit
+    err := r.db.QueryRow(`
+        SELECT id, ingredient_id, name, quantity, unit
+        FROM pack_units
+        WHERE ingredient_id = $1 AND LOWER(name) = LOWER($2)
+    `, ingredientID, name).Scan(&pack.ID, &pack.IngredientID, &pack.Name, &pack.Quantity, &pack.Unit)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error getting pack unit: %w", err)
+    }
+
+    return &pack, nil
+}
+
+func (r *UnitRepository) CreatePack(pack *data.PackUnit) error {
+    err := r.db.QueryRow(`
+        INSERT INTO pack_units (ingredient_id, name, quantity, unit)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, pack.IngredientID, pack.Name, pack.Quantity, pack.Unit).Scan(&pack.ID)
+    if err != nil {
+        return fmt.Errorf("error creating pack unit: %w", err)
+    }
+
+    return nil
+}
+
+// Una presentación que ya se usó en órdenes de compra no se elimina: sus
+// cantidades dejarían de poder interpretarse
+func (r *UnitRepository) DeletePack(ingredientID, packID int32) error {
+    var used bool
+    err := r.db.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM pack_units p
+            JOIN purchase_order_items poi ON poi.ingredient_id = p.ingredient_id
+                                         AND LOWER(poi.purchase_unit) = LOWER(p.name)
+            WHERE p.id = $1 AND p.ingredient_id = $2
+        )
+    `, packID, ingredientID).Scan(&used)
+    if err != nil {
+        return fmt.Errorf("error checking pack unit usage: %w", err)
+    }
+    if used {
+        return fmt.Errorf("pack unit is used by purchase orders")
+    }
+
+    result, err := r.db.Exec(`DELETE FROM pack_units WHERE id = $1 AND ingredient_id = $2`, packID, ingredientID)
+    if err != nil {
+        return fmt.Errorf("error deleting pack unit: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("pack unit not found")
+    }
+
+    return nil
+}
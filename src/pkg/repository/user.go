@@ -19,7 +19,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) GetByUsername(username string) (*data.User, error) {
     query := `
-        SELECT id, username, password_hash, full_name, created_at
+        SELECT id, username, password_hash, full_name, role, created_at
         FROM users 
         WHERE username = $1
     `
@@ -30,6 +30,7 @@ func (r *UserRepository) GetByUsername(username string) (*data.User, error) {
         &user.Username,
         &user.PasswordHash,
         &user.FullName,
+        &user.Role,
         &user.CreatedAt,
     )
     
@@ -45,8 +46,8 @@ func (r *UserRepository) GetByUsername(username string) (*data.User, error) {
 
 func (r *UserRepository) Create(user *data.User) error {
     query := `
-        INSERT INTO users (username, password_hash, full_name)
-        VALUES ($1, $2, $3)
+        INSERT INTO users (username, password_hash, full_name, role)
+        VALUES ($1, $2, $3, $4)
         RETURNING id, created_at
     `
     
@@ -55,6 +56,7 @@ func (r *UserRepository) Create(user *data.User) error {
         user.Username,
         user.PasswordHash,
         user.FullName,
+        user.Role,
     ).Scan(&user.ID, &user.CreatedAt)
     
     if err != nil {
@@ -110,4 +112,22 @@ func (r *UserRepository) UpdatePassword(id int32, passwordHash string) error {
     }
 
     return nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepository) UpdateRole(id int32, role string) error {
+    result, err := r.db.Exec(`UPDATE users SET role = $2 WHERE id = $1`, id, role)
+    if err != nil {
+        return fmt.Errorf("error updating user role: %w", err)
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("error getting rows affected: %w", err)
+    }
+
+    if rowsAffected == 0 {
+        return fmt.Errorf("user not found")
+    }
+
+    return nil
+}
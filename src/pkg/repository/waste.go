@@ -0,0 +1,326 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+type WasteRepository struct {
+    *BaseRepository
+}
+
+func NewWasteRepository(db *sql.DB) *WasteRepository {
+    return &WasteRepository{
+        BaseRepository: NewBaseRepository(db),
+    }
+}
+
+const wasteColumns = `w.id, w.ingredient_id, w.product_id, COALESCE(i.name, p.name) AS item_name, w.quantity,
+        COALESCE(i.unit, '') AS unit, w.reason, w.notes, w.cost, w.lot_id, w.user_id,
+        COALESCE(u.full_name, '') AS user_name, w.created_at`
+
+const wasteJoins = `
+        FROM waste_records w
+        LEFT JOIN ingredients i ON i.id = w.ingredient_id
+        LEFT JOIN products p ON p.id = w.product_id
+        LEFT JOIN users u ON u.id = w.user_id`
+
+// Filtros del listado de mermas; el periodo es [From, To)
+type WasteFilter struct {
+    From   *time.Time
+    To     *time.Time
+    Reason string
+    UserID int32
+}
+
+func (f WasteFilter) where(args []any) (string, []any) {
+    clauses := make([]string, 0)
+
+    if f.From != nil {
+        args = append(args, *f.From)
+        clauses = append(clauses, fmt.Sprintf("w.created_at >= $%d", len(args)))
+    }
+    if f.To != nil {
+        args = append(args, *f.To)
+        clauses = append(clauses, fmt.Sprintf("w.created_at < $%d", len(args)))
+    }
+    if f.Reason != "" {
+        args = append(args, f.Reason)
+        clauses = append(clauses, fmt.Sprintf("w.reason = $%d", len(args)))
+    }
+    if f.UserID != 0 {
+        args = append(args, f.UserID)
+        clauses = append(clauses, fmt.Sprintf("w.user_id = $%d", len(args)))
+    }
+
+    if len(clauses) == 0 {
+        return "", args
+    }
+    return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *WasteRepository) Find(filter WasteFilter) ([]data.WasteRecord, error) {
+    where, args := filter.where(nil)
+    query := `
+        SELECT ` + wasteColumns + wasteJoins + where + `
+        ORDER BY w.created_at DESC, w.id DESC
+    `
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying waste records: %w", err)
+    }
+    defer rows.Close()
+
+    records := make([]data.WasteRecord, 0)
+    if err := ScanRowsToStruct(rows, &records); err != nil {
+        return nil, fmt.Errorf("error scanning waste records: %w", err)
+    }
+
+    return records, nil
+}
+
+func (r *WasteRepository) GetByID(id int32) (*data.WasteRecord, error) {
+    rows, err := r.db.Query(`SELECT `+wasteColumns+wasteJoins+` WHERE w.id = $1`, id)
+    if err != nil {
+        return nil, fmt.Errorf("error getting waste record: %w", err)
+    }
+    defer rows.Close()
+
+    var records []data.WasteRecord
+    if err := ScanRowsToStruct(rows, &records); err != nil {
+        return nil, fmt.Errorf("error scanning waste record: %w", err)
+    }
+
+    if len(records) == 0 {
+        return nil, nil
+    }
+
+    return &records[0], nil
+}
+
+func (r *WasteRepository) GetLines(wasteID int32) ([]data.WasteLine, error) {
+    query := `
+        SELECT wl.id, wl.waste_id, wl.ingredient_id, i.name AS ingredient_name, i.unit,
+               wl.quantity, wl.unit_cost, wl.quantity * wl.unit_cost AS cost, wl.movement_id
+        FROM waste_lines wl
+        JOIN ingredients i ON i.id = wl.ingredient_id
+        WHERE wl.waste_id = $1
+        ORDER BY i.name
+    `
+
+    rows, err := r.db.Query(query, wasteID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying waste lines: %w", err)
+    }
+    defer rows.Close()
+
+    lines := make([]data.WasteLine, 0)
+    if err := ScanRowsToStruct(rows, &lines); err != nil {
+        return nil, fmt.Errorf("error scanning waste lines: %w", err)
+    }
+
+    return lines, nil
+}
+
+// Registra la merma y descarga del inventario el ingrediente o los
+// ingredientes de la receta del producto, valorizados a su costo actual
+func (r *WasteRepository) Create(record *data.WasteRecord) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := createWasteTx(tx, record); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return nil
+}
+
+// Con lote, la merma debe ser de un ingrediente de ese lote y no superar lo
+// que le queda
+func checkWasteLotTx(tx *sql.Tx, record *data.WasteRecord) error {
+    var ingredientID int32
+    var remaining float64
+    err := tx.QueryRow(`
+        SELECT ingredient_id, quantity_remaining FROM inventory_lots WHERE id = $1 FOR UPDATE
+    `, *record.LotID).Scan(&ingredientID, &remaining)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("lot not found")
+        }
+        return fmt.Errorf("error getting lot: %w", err)
+    }
+
+    if record.IngredientID == nil || *record.IngredientID != ingredientID {
+        return fmt.Errorf("lot %d belongs to another ingredient", *record.LotID)
+    }
+
+    if record.Quantity > remaining {
+        return fmt.Errorf("lot %d has %g left", *record.LotID, remaining)
+    }
+
+    return nil
+}
+
+func createWasteTx(tx *sql.Tx, record *data.WasteRecord) error {
+    if record.LotID != nil {
+        if err := checkWasteLotTx(tx, record); err != nil {
+            return err
+        }
+    }
+
+    var consumption []data.RecipeItem
+    var err error
+    if record.ProductID != nil {
+        consumption, err = productConsumptionTx(tx, *record.ProductID, record.Quantity)
+        if err != nil {
+            return err
+        }
+        if len(consumption) == 0 {
+            return fmt.Errorf("product has no recipe to waste")
+        }
+    } else {
+        consumption = []data.RecipeItem{{IngredientID: *record.IngredientID, Quantity: record.Quantity}}
+    }
+
+    err = tx.QueryRow(`
+        INSERT INTO waste_records (ingredient_id, product_id, quantity, reason, notes, lot_id, user_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `, record.IngredientID, record.ProductID, record.Quantity, record.Reason, record.Notes, record.LotID, record.UserID).
+        Scan(&record.ID, &record.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("error creating waste record: %w", err)
+    }
+
+    record.Cost = 0
+    for _, item := range consumption {
+        var unitCost float64
+        err := tx.QueryRow(`SELECT cost_per_unit FROM ingredients WHERE id = $1`, item.IngredientID).Scan(&unitCost)
+        if err != nil {
+            if err == sql.ErrNoRows {
+                return fmt.Errorf("ingredient not found")
+            }
+            return fmt.Errorf("error getting ingredient cost: %w", err)
+        }
+
+        movement := &data.InventoryMovement{
+            IngredientID: item.IngredientID,
+            MovementType: data.MovementTypeSalida,
+            Quantity:     item.Quantity,
+            Reason:       fmt.Sprintf("Waste %d: %s", record.ID, record.Reason),
+            UserID:       record.UserID,
+            LotID:        record.LotID,
+        }
+        // La merma ya ocurrió: se registra aunque el stock quede en negativo
+        if err := recordMovementTx(tx, movement, true); err != nil {
+            return err
+        }
+
+        _, err = tx.Exec(`
+            INSERT INTO waste_lines (waste_id, ingredient_id, quantity, unit_cost, movement_id)
+            VALUES ($1, $2, $3, $4, $5)
+        `, record.ID, item.IngredientID, item.Quantity, unitCost, movement.ID)
+        if err != nil {
+            return fmt.Errorf("error creating waste line: %w", err)
+        }
+
+        record.Cost += item.Quantity * unitCost
+    }
+
+    if _, err := tx.Exec(`UPDATE waste_records SET cost = $2 WHERE id = $1`, record.ID, record.Cost); err != nil {
+        return fmt.Errorf("error updating waste cost: %w", err)
+    }
+
+    return nil
+}
+
+// Costo de las mermas del periodo [from, to) agrupado por motivo, ingrediente
+// y empleado
+func (r *WasteRepository) GetReport(from, to *time.Time) (*data.WasteReport, error) {
+    where, args := WasteFilter{From: from, To: to}.where(nil)
+    report := &data.WasteReport{
+        ByReason:     make([]data.WasteReasonSummary, 0),
+        ByIngredient: make([]data.WasteIngredientSummary, 0),
+        ByStaff:      make([]data.WasteStaffSummary, 0),
+    }
+
+    rows, err := r.db.Query(`
+        SELECT w.reason, COUNT(*), COALESCE(SUM(w.cost), 0)
+        FROM waste_records w`+where+`
+        GROUP BY w.reason
+        ORDER BY SUM(w.cost) DESC, w.reason
+    `, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying waste by reason: %w", err)
+    }
+    for rows.Next() {
+        var summary data.WasteReasonSummary
+        if err := rows.Scan(&summary.Reason, &summary.Records, &summary.Cost); err != nil {
+            rows.Close()
+            return nil, fmt.Errorf("error scanning waste by reason: %w", err)
+        }
+        report.ByReason = append(report.ByReason, summary)
+        report.TotalCost += summary.Cost
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error reading waste by reason: %w", err)
+    }
+
+    rows, err = r.db.Query(`
+        SELECT wl.ingredient_id, i.name, i.unit, SUM(wl.quantity), SUM(wl.quantity * wl.unit_cost)
+        FROM waste_lines wl
+        JOIN waste_records w ON w.id = wl.waste_id
+        JOIN ingredients i ON i.id = wl.ingredient_id`+where+`
+        GROUP BY wl.ingredient_id, i.name, i.unit
+        ORDER BY SUM(wl.quantity * wl.unit_cost) DESC, i.name
+    `, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying waste by ingredient: %w", err)
+    }
+    for rows.Next() {
+        var summary data.WasteIngredientSummary
+        if err := rows.Scan(&summary.IngredientID, &summary.Name, &summary.Unit, &summary.Quantity, &summary.Cost); err != nil {
+            rows.Close()
+            return nil, fmt.Errorf("error scanning waste by ingredient: %w", err)
+        }
+        report.ByIngredient = append(report.ByIngredient, summary)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error reading waste by ingredient: %w", err)
+    }
+
+    rows, err = r.db.Query(`
+        SELECT w.user_id, u.full_name, COUNT(*), COALESCE(SUM(w.cost), 0)
+        FROM waste_records w
+        JOIN users u ON u.id = w.user_id`+where+`
+        GROUP BY w.user_id, u.full_name
+        ORDER BY SUM(w.cost) DESC, u.full_name
+    `, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying waste by staff: %w", err)
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var summary data.WasteStaffSummary
+        if err := rows.Scan(&summary.StaffID, &summary.FullName, &summary.Records, &summary.Cost); err != nil {
+            return nil, fmt.Errorf("error scanning waste by staff: %w", err)
+        }
+        report.ByStaff = append(report.ByStaff, summary)
+    }
+
+    return report, rows.Err()
+}
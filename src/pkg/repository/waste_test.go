@@ -0,0 +1,57 @@
+package repository
+
+import (
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/pkgzx/liliApi/src/pkg/data"
+)
+
+func TestWasteFilterWhere(t *testing.T) {
+    from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        name      string
+        filter    WasteFilter
+        args      []any
+        wantWhere string
+        wantArgs  []any
+    }{
+        {
+            name: "no filters",
+        },
+        {
+            name:      "reason only",
+            filter:    WasteFilter{Reason: data.WasteReasonBurnt},
+            wantWhere: " WHERE w.reason = $1",
+            wantArgs:  []any{data.WasteReasonBurnt},
+        },
+        {
+            name:      "every filter",
+            filter:    WasteFilter{From: &from, To: &to, Reason: data.WasteReasonExpired, UserID: 7},
+            wantWhere: " WHERE w.created_at >= $1 AND w.created_at < $2 AND w.reason = $3 AND w.user_id = $4",
+            wantArgs:  []any{from, to, data.WasteReasonExpired, int32(7)},
+        },
+        {
+            name:      "placeholders continue after existing args",
+            filter:    WasteFilter{UserID: 3},
+            args:      []any{"x"},
+            wantWhere: " WHERE w.user_id = $2",
+            wantArgs:  []any{"x", int32(3)},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            where, args := tt.filter.where(tt.args)
+            if where != tt.wantWhere {
+                t.Errorf("where = %q, want %q", where, tt.wantWhere)
+            }
+            if !reflect.DeepEqual(args, tt.wantArgs) {
+                t.Errorf("args = %v, want %v", args, tt.wantArgs)
+            }
+        })
+    }
+}